@@ -3,12 +3,14 @@ package main
 import (
 	"context"
 	. "gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/logger"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/observability"
 	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/report_service"
 	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/storage/pgdatabase"
 	"go.uber.org/zap"
 )
 
 func main() {
+	observability.InitTracing(Log, "report-service")
 	db, err := pgdatabase.InitDB(context.Background(), "user=postgres password=postgres host=localhost dbname=postgres sslmode=disable")
 	if err != nil {
 		Log.Fatal("db init failed:", zap.Error(err))
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// FromReportRequest converts the request of the bot to the message sent to the report service.
+func FromReportRequest(r *model.ReportRequest) *ReportRequest {
+	msg := &ReportRequest{
+		Version:   r.Version,
+		RequestId: r.RequestId,
+		UserId:    r.UserId,
+		Start:     timestamppb.New(r.Start),
+		End:       timestamppb.New(r.End),
+		Mode:      string(r.Mode),
+		Top:       int32(r.Top),
+		Chart:     r.Chart,
+		Export:    r.Export,
+	}
+	if r.Currency != nil {
+		msg.Currency = &Currency{Code: r.Currency.Code, Ratio: r.Currency.Ratio.String()}
+		if !r.Currency.UpdatedAt.IsZero() {
+			msg.Currency.UpdatedAt = timestamppb.New(r.Currency.UpdatedAt)
+		}
+	}
+	return msg
+}
+
+// ToModel converts the message back, requests of a version newer than model.ReportRequestVersion are rejected.
+func (x *ReportRequest) ToModel() (*model.ReportRequest, error) {
+	if x.GetVersion() == 0 || x.GetVersion() > model.ReportRequestVersion {
+		return nil, fmt.Errorf("unsupported report request version %v", x.GetVersion())
+	}
+	r := &model.ReportRequest{
+		Version:   x.GetVersion(),
+		RequestId: x.GetRequestId(),
+		UserId:    x.GetUserId(),
+		Start:     x.GetStart().AsTime(),
+		End:       x.GetEnd().AsTime(),
+		Mode:      model.ReportMode(x.GetMode()),
+		Top:       int(x.GetTop()),
+		Chart:     x.GetChart(),
+		Export:    x.GetExport(),
+	}
+	if c := x.GetCurrency(); c != nil {
+		ratio, err := decimal.NewFromString(c.GetRatio())
+		if err != nil {
+			return nil, fmt.Errorf("parsing currency ratio: %w", err)
+		}
+		r.Currency = &model.Currency{Code: c.GetCode(), Ratio: ratio}
+		if c.GetUpdatedAt() != nil {
+			r.Currency.UpdatedAt = c.GetUpdatedAt().AsTime()
+		}
+	}
+	return r, nil
+}
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
+	"google.golang.org/protobuf/proto"
+)
+
+func Test_ReportRequest_shouldSurviveMarshaling(t *testing.T) {
+	request := model.NewReportRequest(123, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC))
+	request.Mode = model.TopReport
+	request.Top = 5
+	request.Export = "csv"
+	request.Currency = &model.Currency{Code: "usd", Ratio: decimal.RequireFromString("0.0125"), UpdatedAt: time.Date(2026, 3, 31, 9, 30, 0, 0, time.UTC)}
+
+	data, err := proto.Marshal(FromReportRequest(request))
+	assert.NoError(t, err)
+	msg := &ReportRequest{}
+	assert.NoError(t, proto.Unmarshal(data, msg))
+	got, err := msg.ToModel()
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, got.RequestId)
+	assert.Equal(t, request, got)
+}
+
+func Test_ReportRequest_shouldRejectUnknownVersion(t *testing.T) {
+	msg := FromReportRequest(model.NewReportRequest(123, time.Now(), time.Now()))
+	msg.Version = model.ReportRequestVersion + 1
+
+	_, err := msg.ToModel()
+
+	assert.Error(t, err)
+}
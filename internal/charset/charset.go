@@ -0,0 +1,30 @@
+// Package charset converts texts in legacy russian encodings to utf-8.
+package charset
+
+import "bytes"
+
+// вторая половина windows-1251, первая совпадает с ascii
+var windows1251 = [128]rune{
+	'Ђ', 'Ѓ', '‚', 'ѓ', '„', '…', '†', '‡', '€', '‰', 'Љ', '‹', 'Њ', 'Ќ', 'Ћ', 'Џ',
+	'ђ', '‘', '’', '“', '”', '•', '–', '—', '\uFFFD', '™', 'љ', '›', 'њ', 'ќ', 'ћ', 'џ',
+	'\u00a0', 'Ў', 'ў', 'Ј', '¤', 'Ґ', '¦', '§', 'Ё', '©', 'Є', '«', '¬', '\u00ad', '®', 'Ї',
+	'°', '±', 'І', 'і', 'ґ', 'µ', '¶', '·', 'ё', '№', 'є', '»', 'ј', 'Ѕ', 'ѕ', 'ї',
+}
+
+// DecodeWindows1251 converts text in windows-1251 to utf-8.
+func DecodeWindows1251(data []byte) []byte {
+	var buf bytes.Buffer
+	buf.Grow(len(data) * 2)
+	for _, b := range data {
+		switch {
+		case b < 0x80:
+			buf.WriteByte(b)
+		case b >= 0xC0:
+			// А-я идут подряд
+			buf.WriteRune(rune(b-0xC0) + 'А')
+		default:
+			buf.WriteRune(windows1251[b-0x80])
+		}
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,14 @@
+package charset
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeWindows1251(t *testing.T) {
+	// "Курс ЦБ №1, Ёж" в windows-1251
+	data := []byte{0xCA, 0xF3, 0xF0, 0xF1, 0x20, 0xD6, 0xC1, 0x20, 0xB9, 0x31, 0x2C, 0x20, 0xA8, 0xE6}
+
+	assert.Equal(t, "Курс ЦБ №1, Ёж", string(DecodeWindows1251(data)))
+}
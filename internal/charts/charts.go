@@ -0,0 +1,327 @@
+// Package charts renders report charts to PNG using only the standard library.
+package charts
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var ErrNoData = errors.New("no data for chart")
+
+// Item is a labeled value of a chart: a category of a pie or a day of a bar chart.
+type Item struct {
+	Label string
+	Value float64
+}
+
+const (
+	width   = 800
+	height  = 500
+	padding = 50
+)
+
+var (
+	background = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	axisColor  = color.RGBA{R: 120, G: 120, B: 120, A: 255}
+	gridColor  = color.RGBA{R: 225, G: 225, B: 225, A: 255}
+	barColor   = color.RGBA{R: 85, G: 172, B: 238, A: 255}
+	lineColor  = color.RGBA{R: 85, G: 172, B: 238, A: 255}
+	limitColor = color.RGBA{R: 221, G: 46, B: 68, A: 255}
+)
+
+// цвета секторов совпадают с цветными квадратами эмодзи, которыми подписана легенда
+var palette = []struct {
+	color color.RGBA
+	emoji string
+}{
+	{color.RGBA{R: 221, G: 46, B: 68, A: 255}, "🟥"},
+	{color.RGBA{R: 244, G: 144, B: 12, A: 255}, "🟧"},
+	{color.RGBA{R: 253, G: 203, B: 88, A: 255}, "🟨"},
+	{color.RGBA{R: 120, G: 177, B: 89, A: 255}, "🟩"},
+	{color.RGBA{R: 85, G: 172, B: 238, A: 255}, "🟦"},
+	{color.RGBA{R: 170, G: 142, B: 214, A: 255}, "🟪"},
+	{color.RGBA{R: 193, G: 105, B: 79, A: 255}, "🟫"},
+	{color.RGBA{R: 49, G: 55, B: 61, A: 255}, "⬛"},
+}
+
+// Pie renders the share of every item and returns the legend for the caption of the image.
+// Items beyond the palette are merged into "other".
+func Pie(items []Item) ([]byte, string, error) {
+	items = positive(items)
+	if len(items) == 0 {
+		return nil, "", ErrNoData
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Value > items[j].Value })
+	if len(items) > len(palette) {
+		rest := Item{Label: "other"}
+		for i := len(palette) - 1; i < len(items); i++ {
+			rest.Value += items[i].Value
+		}
+		items = append(items[:len(palette)-1:len(palette)-1], rest)
+	}
+	total := 0.0
+	for i := 0; i < len(items); i++ {
+		total += items[i].Value
+	}
+
+	img := newCanvas()
+	cx, cy, r := float64(width)/2, float64(height)/2, float64(height)/2-padding
+	for y := int(cy - r); y <= int(cy+r); y++ {
+		for x := int(cx - r); x <= int(cx+r); x++ {
+			dx, dy := float64(x)-cx, float64(y)-cy
+			if dx*dx+dy*dy > r*r {
+				continue
+			}
+			// сектора идут по часовой стрелке от 12 часов
+			share := math.Atan2(dx, -dy) / (2 * math.Pi)
+			if share < 0 {
+				share++
+			}
+			img.Set(x, y, palette[sliceAt(items, total, share)].color)
+		}
+	}
+
+	var legend strings.Builder
+	for i := 0; i < len(items); i++ {
+		legend.WriteString(fmt.Sprintf("%v %v - %.0f%%\n", palette[i].emoji, items[i].Label, items[i].Value/total*100))
+	}
+	png, err := encode(img)
+	return png, legend.String(), err
+}
+
+func sliceAt(items []Item, total, share float64) int {
+	acc := 0.0
+	for i := 0; i < len(items); i++ {
+		acc += items[i].Value / total
+		if share < acc {
+			return i
+		}
+	}
+	return len(items) - 1
+}
+
+// Bars renders a bar per item, labels are drawn under every few bars and should be short numbers like days.
+func Bars(items []Item) ([]byte, error) {
+	if len(items) == 0 {
+		return nil, ErrNoData
+	}
+	max := 0.0
+	for i := 0; i < len(items); i++ {
+		max = math.Max(max, items[i].Value)
+	}
+	img := newCanvas()
+	top := drawAxes(img, max)
+	plotW := float64(width - 2*padding)
+	step := plotW / float64(len(items))
+	for i := 0; i < len(items); i++ {
+		if items[i].Value <= 0 {
+			continue
+		}
+		x0 := padding + int(float64(i)*step+step*0.15)
+		x1 := padding + int(float64(i+1)*step-step*0.15)
+		if x1 <= x0 {
+			x1 = x0 + 1
+		}
+		y0 := height - padding - int(items[i].Value/top*float64(height-2*padding))
+		fillRect(img, x0, y0, x1, height-padding, barColor)
+	}
+	drawXLabels(img, items, step)
+	return encode(img)
+}
+
+// CumulativeLine renders the running total of the items against the budget, budget is skipped if it is not positive.
+func CumulativeLine(items []Item, budget float64) ([]byte, error) {
+	if len(items) == 0 {
+		return nil, ErrNoData
+	}
+	cumulative := make([]float64, len(items))
+	sum := 0.0
+	for i := 0; i < len(items); i++ {
+		sum += items[i].Value
+		cumulative[i] = sum
+	}
+	img := newCanvas()
+	top := drawAxes(img, math.Max(sum, budget))
+	plotW, plotH := float64(width-2*padding), float64(height-2*padding)
+	step := plotW / float64(len(items))
+	toY := func(v float64) int { return height - padding - int(v/top*plotH) }
+
+	if budget > 0 {
+		y := toY(budget)
+		for x := padding; x < width-padding; x++ {
+			// пунктир
+			if (x/8)%2 == 0 {
+				fillRect(img, x, y-1, x+1, y+1, limitColor)
+			}
+		}
+	}
+	prevX, prevY := padding, toY(0)
+	for i := 0; i < len(cumulative); i++ {
+		x, y := padding+int(float64(i+1)*step), toY(cumulative[i])
+		drawLine(img, prevX, prevY, x, y, lineColor)
+		prevX, prevY = x, y
+	}
+	drawXLabels(img, items, step)
+	return encode(img)
+}
+
+// Line renders the values of the items as a line, the axis starts near the minimum so small changes like of a rate are visible.
+func Line(items []Item) ([]byte, error) {
+	if len(items) == 0 {
+		return nil, ErrNoData
+	}
+	min, max := items[0].Value, items[0].Value
+	for i := 1; i < len(items); i++ {
+		min, max = math.Min(min, items[i].Value), math.Max(max, items[i].Value)
+	}
+	img := newCanvas()
+	bottom, top := drawAxesRange(img, min, max)
+	plotW, plotH := float64(width-2*padding), float64(height-2*padding)
+	step := plotW / float64(len(items))
+	toY := func(v float64) int { return height - padding - int((v-bottom)/(top-bottom)*plotH) }
+
+	prevX, prevY := padding+int(step/2), toY(items[0].Value)
+	for i := 1; i < len(items); i++ {
+		x, y := padding+int(float64(i)*step+step/2), toY(items[i].Value)
+		drawLine(img, prevX, prevY, x, y, lineColor)
+		prevX, prevY = x, y
+	}
+	// одна точка видна как короткий отрезок
+	if len(items) == 1 {
+		fillRect(img, prevX-3, prevY-1, prevX+3, prevY+1, lineColor)
+	}
+	drawXLabels(img, items, step)
+	return encode(img)
+}
+
+func positive(items []Item) []Item {
+	r := make([]Item, 0, len(items))
+	for i := 0; i < len(items); i++ {
+		if items[i].Value > 0 {
+			r = append(r, items[i])
+		}
+	}
+	return r
+}
+
+func newCanvas() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	fillRect(img, 0, 0, width, height, background)
+	return img
+}
+
+const gridLines = 4
+
+// drawAxes draws the axes with a grid and returns the value at the top of the plot.
+func drawAxes(img *image.RGBA, max float64) float64 {
+	top := niceCeil(max)
+	drawGrid(img, 0, top/gridLines, formatValue)
+	return top
+}
+
+// drawAxesRange draws the axes with a grid from a round value below min to a round value above max.
+func drawAxesRange(img *image.RGBA, min, max float64) (float64, float64) {
+	span := max - min
+	if span <= 0 {
+		span = math.Max(math.Abs(max)/100, 0.01)
+	}
+	step := niceCeil(span / gridLines)
+	bottom := math.Floor(min/step) * step
+	for bottom+step*gridLines < max {
+		step = niceCeil(step * 1.5)
+		bottom = math.Floor(min/step) * step
+	}
+	decimals := 0
+	if step < 1 {
+		decimals = int(math.Ceil(-math.Log10(step)))
+	}
+	drawGrid(img, bottom, step, func(v float64) string {
+		if decimals == 0 {
+			return formatValue(v)
+		}
+		return strconv.FormatFloat(v, 'f', decimals, 64)
+	})
+	return bottom, bottom + step*gridLines
+}
+
+func drawGrid(img *image.RGBA, bottom, step float64, format func(float64) string) {
+	for i := 0; i <= gridLines; i++ {
+		y := height - padding - i*(height-2*padding)/gridLines
+		c := gridColor
+		if i == 0 {
+			c = axisColor
+		}
+		fillRect(img, padding, y, width-padding, y+1, c)
+		drawText(img, 4, y-glyphH, format(bottom+step*float64(i)))
+	}
+	fillRect(img, padding, padding, padding+1, height-padding, axisColor)
+}
+
+func drawXLabels(img *image.RGBA, items []Item, step float64) {
+	every := int(math.Ceil(float64(len(items)) / 15))
+	for i := 0; i < len(items); i += every {
+		x := padding + int(float64(i)*step+step/2) - textWidth(items[i].Label)/2
+		drawText(img, x, height-padding+8, items[i].Label)
+	}
+}
+
+// niceCeil rounds the value up to 1, 2 or 5 multiplied by a power of ten.
+func niceCeil(v float64) float64 {
+	if v <= 0 {
+		return 1
+	}
+	pow := math.Pow(10, math.Floor(math.Log10(v)))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if v <= m*pow {
+			return m * pow
+		}
+	}
+	return 10 * pow
+}
+
+func formatValue(v float64) string {
+	if v >= 1000 && math.Mod(v, 1000) == 0 {
+		return fmt.Sprintf("%.0fk", v/1000)
+	}
+	if v >= 1000 {
+		return fmt.Sprintf("%.1fk", v/1000)
+	}
+	return fmt.Sprintf("%.0f", v)
+}
+
+func fillRect(img *image.RGBA, x0, y0, x1, y1 int, c color.RGBA) {
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
+
+// drawLine draws a line 3px thick.
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.RGBA) {
+	steps := int(math.Max(math.Abs(float64(x1-x0)), math.Abs(float64(y1-y0))))
+	if steps == 0 {
+		steps = 1
+	}
+	for i := 0; i <= steps; i++ {
+		x := x0 + (x1-x0)*i/steps
+		y := y0 + (y1-y0)*i/steps
+		fillRect(img, x-1, y-1, x+2, y+2, c)
+	}
+}
+
+func encode(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
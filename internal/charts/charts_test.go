@@ -0,0 +1,71 @@
+package charts
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPie(t *testing.T) {
+	data, legend, err := Pie([]Item{{Label: "taxi", Value: 25}, {Label: "food", Value: 75}, {Label: "zero", Value: 0}})
+	assert.NoError(t, err)
+	assert.Equal(t, "🟥 food - 75%\n🟧 taxi - 25%\n", legend)
+
+	img, err := png.Decode(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, width, img.Bounds().Dx())
+	// food занимает сектор от 12 до 9 часов, taxi - от 9 до 12
+	assert.Equal(t, palette[0].color, img.At(width/2+50, height/2))
+	assert.Equal(t, palette[1].color, img.At(width/2-50, height/2-50))
+}
+
+func TestPie_MergesRestIntoOther(t *testing.T) {
+	items := make([]Item, 10)
+	for i := 0; i < len(items); i++ {
+		items[i] = Item{Label: string(rune('a' + i)), Value: float64(10 - i)}
+	}
+	_, legend, err := Pie(items)
+	assert.NoError(t, err)
+	assert.Contains(t, legend, "⬛ other - 11%\n")
+}
+
+func TestCharts_NoData(t *testing.T) {
+	_, _, err := Pie([]Item{{Label: "food", Value: 0}})
+	assert.ErrorIs(t, err, ErrNoData)
+	_, err = Bars(nil)
+	assert.ErrorIs(t, err, ErrNoData)
+	_, err = CumulativeLine(nil, 100)
+	assert.ErrorIs(t, err, ErrNoData)
+}
+
+func TestBarsAndLine(t *testing.T) {
+	items := []Item{{Label: "1", Value: 100}, {Label: "2", Value: 0}, {Label: "3", Value: 250}}
+	bars, err := Bars(items)
+	assert.NoError(t, err)
+	_, err = png.Decode(bytes.NewReader(bars))
+	assert.NoError(t, err)
+
+	line, err := CumulativeLine(items, 1000)
+	assert.NoError(t, err)
+	_, err = png.Decode(bytes.NewReader(line))
+	assert.NoError(t, err)
+}
+
+func TestLine(t *testing.T) {
+	line, err := Line([]Item{{Label: "01.03", Value: 80.5}, {Label: "02.03", Value: 81.25}, {Label: "03.03", Value: 79.9}})
+	assert.NoError(t, err)
+	_, err = png.Decode(bytes.NewReader(line))
+	assert.NoError(t, err)
+
+	_, err = Line([]Item{{Label: "01.03", Value: 0.0125}})
+	assert.NoError(t, err)
+}
+
+func Test_niceCeil(t *testing.T) {
+	assert.Equal(t, 1.0, niceCeil(0))
+	assert.Equal(t, 200.0, niceCeil(150))
+	assert.Equal(t, 5000.0, niceCeil(3500))
+	assert.Equal(t, 10000.0, niceCeil(7000))
+}
@@ -0,0 +1,52 @@
+package charts
+
+import "image"
+
+const (
+	glyphScale = 2
+	glyphH     = 5 * glyphScale
+	glyphW     = 3 * glyphScale
+	glyphGap   = glyphScale
+)
+
+// глифы 3x5 только для подписей осей: цифры, k и разделители, подписи категорий идут в легенду
+var glyphs = map[rune][5]string{
+	'0': {"###", "#.#", "#.#", "#.#", "###"},
+	'1': {".#.", "##.", ".#.", ".#.", "###"},
+	'2': {"###", "..#", "###", "#..", "###"},
+	'3': {"###", "..#", "###", "..#", "###"},
+	'4': {"#.#", "#.#", "###", "..#", "..#"},
+	'5': {"###", "#..", "###", "..#", "###"},
+	'6': {"###", "#..", "###", "#.#", "###"},
+	'7': {"###", "..#", "..#", "..#", "..#"},
+	'8': {"###", "#.#", "###", "#.#", "###"},
+	'9': {"###", "#.#", "###", "..#", "###"},
+	'k': {"#..", "#.#", "##.", "#.#", "#.#"},
+	'.': {"...", "...", "...", "...", ".#."},
+	'-': {"...", "...", "###", "...", "..."},
+	'/': {"..#", "..#", ".#.", "#..", "#.."},
+}
+
+func textWidth(text string) int {
+	n := len([]rune(text))
+	if n == 0 {
+		return 0
+	}
+	return n*(glyphW+glyphGap) - glyphGap
+}
+
+// drawText draws the text with the top left corner at x, y, unknown characters are skipped.
+func drawText(img *image.RGBA, x, y int, text string) {
+	for _, r := range text {
+		if g, ok := glyphs[r]; ok {
+			for row := 0; row < len(g); row++ {
+				for col := 0; col < len(g[row]); col++ {
+					if g[row][col] == '#' {
+						fillRect(img, x+col*glyphScale, y+row*glyphScale, x+(col+1)*glyphScale, y+(row+1)*glyphScale, axisColor)
+					}
+				}
+			}
+		}
+		x += glyphW + glyphGap
+	}
+}
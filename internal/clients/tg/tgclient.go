@@ -2,12 +2,16 @@ package tg
 
 import (
 	"context"
+	"io"
+	"net/http"
+	"strings"
 	"sync"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
 	"github.com/pkg/errors"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/i18n"
 	. "gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/logger"
 	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
 	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/observability"
@@ -40,6 +44,76 @@ func (c *Client) SendMessage(text string, userID int64) error {
 	return nil
 }
 
+func (c *Client) SendKeyboard(text string, keyboard model.Keyboard, userID int64) error {
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(keyboard))
+	for _, row := range keyboard {
+		buttons := make([]tgbotapi.InlineKeyboardButton, 0, len(row))
+		for _, b := range row {
+			buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(b.Text, b.Data))
+		}
+		rows = append(rows, buttons)
+	}
+	msg := tgbotapi.NewMessage(userID, text)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	if _, err := c.client.Send(msg); err != nil {
+		return errors.Wrap(err, "client.Send keyboard")
+	}
+	return nil
+}
+
+// telegram не принимает подписи к фото длиннее 1024 символов
+const maxCaptionLen = 1024
+
+func (c *Client) SendPhoto(png []byte, caption string, userID int64) error {
+	photo := tgbotapi.NewPhoto(userID, tgbotapi.FileBytes{Name: "chart.png", Bytes: png})
+	if runes := []rune(caption); len(runes) > maxCaptionLen {
+		caption = string(runes[:maxCaptionLen])
+	}
+	photo.Caption = caption
+	if _, err := c.client.Send(photo); err != nil {
+		return errors.Wrap(err, "client.Send photo")
+	}
+	return nil
+}
+
+func (c *Client) SendDocument(data []byte, name string, userID int64) error {
+	if _, err := c.client.Send(tgbotapi.NewDocument(userID, tgbotapi.FileBytes{Name: name, Bytes: data})); err != nil {
+		return errors.Wrap(err, "client.Send document")
+	}
+	return nil
+}
+
+// telegram отдаёт ботам файлы до 20 Мб, выписки и фото чеков намного меньше
+const maxDocumentSize = 5 << 20
+
+var errDocumentTooLarge = errors.New("file is too large")
+
+func (c *Client) downloadFile(fileID string, size int) ([]byte, error) {
+	if size > maxDocumentSize {
+		return nil, errDocumentTooLarge
+	}
+	url, err := c.client.GetFileDirectURL(fileID)
+	if err != nil {
+		return nil, errors.Wrap(err, "client.GetFileDirectURL")
+	}
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, errors.Wrap(err, "downloading document")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("downloading document: status %v", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDocumentSize+1))
+	if err != nil {
+		return nil, errors.Wrap(err, "reading document")
+	}
+	if len(data) > maxDocumentSize {
+		return nil, errDocumentTooLarge
+	}
+	return data, nil
+}
+
 func (c *Client) ListenUpdates(handler *services.MessageHandlerService, ctx context.Context) {
 	c.runOnce.Do(func() {
 		u := tgbotapi.NewUpdate(0)
@@ -56,6 +130,9 @@ func (c *Client) ListenUpdates(handler *services.MessageHandlerService, ctx cont
 				Log.Info("stop listening messages")
 				return
 			case update := <-updates:
+				if q := update.CallbackQuery; q != nil {
+					c.handleCallback(handler, q, ctx)
+				}
 
 				if update.Message != nil { // If we got a message
 					Log.Info("inocming msg", zap.String("username", update.Message.From.UserName), zap.String("text", update.Message.Text))
@@ -63,10 +140,27 @@ func (c *Client) ListenUpdates(handler *services.MessageHandlerService, ctx cont
 					span, newCtx := opentracing.StartSpanFromContext(ctx, "handling message")
 
 					observability.LogRequest(func() error {
-						err := handler.HandleMsg(&model.Message{
-							Text:   update.Message.Text,
-							UserID: update.Message.From.ID,
-						}, newCtx)
+						msg := newMessage(update.Message)
+						var err error
+						switch doc, photos := update.Message.Document, update.Message.Photo; {
+						case doc != nil && strings.HasPrefix(doc.MimeType, "image/"):
+							// фото, отправленное файлом, не сжато и QR чека читается лучше
+							msg.Photo, err = c.downloadFile(doc.FileID, doc.FileSize)
+						case doc != nil:
+							msg.Document, err = c.downloadFile(doc.FileID, doc.FileSize)
+							msg.DocumentName = doc.FileName
+						case len(photos) > 0:
+							// размеры фото идут по возрастанию, для QR нужен самый большой
+							largest := photos[len(photos)-1]
+							msg.Photo, err = c.downloadFile(largest.FileID, largest.FileSize)
+						}
+						if err != nil {
+							Log.Error("error downloading file:", zap.Error(err))
+							ext.Error.Set(span, true)
+							// до обработчика язык известен только из telegram
+							return c.SendMessage(i18n.Detect(msg.LanguageCode).Error(err), update.Message.From.ID)
+						}
+						err = handler.HandleMsg(msg, newCtx)
 						if err != nil {
 							Log.Error("error processing message:", zap.Error(err))
 							ext.Error.Set(span, true)
@@ -80,3 +174,44 @@ func (c *Client) ListenUpdates(handler *services.MessageHandlerService, ctx cont
 		}
 	})
 }
+
+// newMessage converts the incoming message without its files,
+// the text of a photo or a document comes in the caption.
+func newMessage(m *tgbotapi.Message) *model.Message {
+	text := m.Text
+	if text == "" {
+		text = m.Caption
+	}
+	return &model.Message{
+		Text:         text,
+		UserID:       m.From.ID,
+		LanguageCode: m.From.LanguageCode,
+	}
+}
+
+// handleCallback passes the data of the pressed button to the handler as a command
+// and removes the buttons, so the same choice is not made twice.
+func (c *Client) handleCallback(handler *services.MessageHandlerService, q *tgbotapi.CallbackQuery, ctx context.Context) {
+	Log.Info("incoming callback", zap.String("username", q.From.UserName), zap.String("data", q.Data))
+	// без ответа кнопка в клиенте крутит индикатор загрузки
+	if _, err := c.client.Request(tgbotapi.NewCallback(q.ID, "")); err != nil {
+		Log.Error("error answering callback:", zap.Error(err))
+	}
+	if q.Message != nil {
+		edit := tgbotapi.NewEditMessageReplyMarkup(q.Message.Chat.ID, q.Message.MessageID, tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}})
+		if _, err := c.client.Request(edit); err != nil {
+			Log.Error("error removing buttons:", zap.Error(err))
+		}
+	}
+
+	span, newCtx := opentracing.StartSpanFromContext(ctx, "handling callback")
+	defer span.Finish()
+	observability.LogRequest(func() error {
+		err := handler.HandleMsg(&model.Message{Text: q.Data, UserID: q.From.ID, LanguageCode: q.From.LanguageCode}, newCtx)
+		if err != nil {
+			Log.Error("error processing callback:", zap.Error(err))
+			ext.Error.Set(span, true)
+		}
+		return err
+	})
+}
@@ -0,0 +1,20 @@
+package tg
+
+import (
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_newMessage_shouldTakeTextOfPhotoFromCaption(t *testing.T) {
+	from := &tgbotapi.User{ID: 123, LanguageCode: "ru"}
+
+	msg := newMessage(&tgbotapi.Message{From: from, Caption: "food", Photo: []tgbotapi.PhotoSize{{FileID: "1"}}})
+	assert.Equal(t, "food", msg.Text)
+	assert.Equal(t, int64(123), msg.UserID)
+	assert.Equal(t, "ru", msg.LanguageCode)
+
+	msg = newMessage(&tgbotapi.Message{From: from, Text: "/report m"})
+	assert.Equal(t, "/report m", msg.Text)
+}
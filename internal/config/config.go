@@ -2,9 +2,13 @@ package config
 
 import (
 	"os"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/rates"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/statement"
 	"gopkg.in/yaml.v3"
 )
 
@@ -18,8 +22,31 @@ type Config struct {
 	CachePort                int           `yaml:"cache_port"`
 	TopicReport              string        `yaml:"topic_report"`
 	KafkaBrokers             []string      `yaml:"kafka_brokers"`
+	// владелец бота: ему отходят записи, сделанные до разделения по пользователям, и только он меняет категории.
+	// Пока эта миграция не применена, бот без него не запустится
+	OwnerUserId int64 `yaml:"owner_user_id"`
+	// как часто проверять, не пора ли провести регулярные траты
+	RecurringSpendingsInterval time.Duration `yaml:"recurring_spendings_interval"`
+	// колонки csv-выписок банков, по умолчанию statement.DefaultMappings
+	ImportMappings []statement.CSVMapping `yaml:"import_mappings"`
+	// сколько ждать ответа на вопрос бота, прежде чем забыть начатую трату
+	DialogTTL time.Duration `yaml:"dialog_ttl"`
+	// валюта, в которой хранятся все суммы, к ней загружаются курсы; по умолчанию rub.
+	// Менять её для базы, где уже есть суммы, нельзя - бот не запустится
+	BaseCurrency string `yaml:"base_currency"`
+	// источники курсов по порядку: если первый не ответил, спрашиваем следующий, по умолчанию rates.DefaultProviders
+	RateProviders []rates.ProviderConfig `yaml:"rate_providers"`
+	// валюты, курсы которых загружаются
+	TrackedCurrencies []string      `yaml:"tracked_currencies"`
+	RatesTimeout      time.Duration `yaml:"rates_timeout"`
+	// курс старше этого считается устаревшим, при тратах в валюте по нему бот предупреждает
+	MaxRateAge time.Duration `yaml:"max_rate_age"`
+	// отклонять траты по устаревшему курсу вместо предупреждения
+	RefuseStaleRates bool `yaml:"refuse_stale_rates"`
 }
 
+var currencyCodeRe = regexp.MustCompile(`^[a-z]{3}$`)
+
 func New() (*Config, error) {
 	c := &Config{}
 
@@ -32,6 +59,34 @@ func New() (*Config, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "parsing yaml")
 	}
+	if c.RecurringSpendingsInterval == 0 {
+		c.RecurringSpendingsInterval = time.Minute
+	}
+	if c.DialogTTL == 0 {
+		c.DialogTTL = 30 * time.Minute
+	}
+	if len(c.ImportMappings) == 0 {
+		c.ImportMappings = statement.DefaultMappings
+	}
+	if c.BaseCurrency == "" {
+		c.BaseCurrency = "rub"
+	}
+	c.BaseCurrency = strings.ToLower(c.BaseCurrency)
+	if !currencyCodeRe.MatchString(c.BaseCurrency) {
+		return nil, errors.Errorf("base_currency %q is not a three-letter currency code", c.BaseCurrency)
+	}
+	if len(c.RateProviders) == 0 {
+		c.RateProviders = rates.DefaultProviders(c.BaseCurrency)
+	}
+	if len(c.TrackedCurrencies) == 0 {
+		c.TrackedCurrencies = []string{"usd", "eur", "cny"}
+	}
+	if c.RatesTimeout == 0 {
+		c.RatesTimeout = 10 * time.Second
+	}
+	if c.MaxRateAge == 0 {
+		c.MaxRateAge = 48 * time.Hour
+	}
 
 	return c, nil
 }
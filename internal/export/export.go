@@ -0,0 +1,154 @@
+// Package export writes spendings to CSV and XLSX files.
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
+)
+
+var ErrWrongFormat = errors.New("wrong export format")
+
+type Format string
+
+const (
+	CSV  Format = "csv"
+	XLSX Format = "xlsx"
+)
+
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case CSV, XLSX:
+		return f, nil
+	}
+	return "", ErrWrongFormat
+}
+
+// FileName returns the name of the export file for the period.
+func FileName(format Format, start, end time.Time) string {
+	return fmt.Sprintf("spendings_%v_%v.%v", start.Format("2006-01-02"), end.Format("2006-01-02"), format)
+}
+
+// Write renders entries with values in rub, currency is the display currency of the user.
+// Entries are converted at their own rates on the dates of the spendings, the rate of currency is used only when it is unknown.
+func Write(format Format, entries []model.ReportEntry, currency model.Currency) ([]byte, error) {
+	rows := make([][]string, 0, len(entries)+1)
+	rows = append(rows, []string{"date", "category", "amount rub", "amount " + currency.Code, "note"})
+	for i := 0; i < len(entries); i++ {
+		rate := entries[i].Rate
+		if rate.IsZero() {
+			rate = currency.Ratio
+		}
+		value := rate.Mul(entries[i].Value)
+		// трата в валюте выгрузки выводится без пересчёта туда и обратно
+		if entries[i].CurrencyCode == currency.Code && !entries[i].OriginalValue.IsZero() {
+			value = entries[i].OriginalValue
+		}
+		rows = append(rows, []string{
+			entries[i].Date.Format("2006-01-02"),
+			entries[i].Category,
+			entries[i].Value.Round(2).String(),
+			value.Round(2).String(),
+			entries[i].Note,
+		})
+	}
+	switch format {
+	case CSV:
+		return writeCSV(rows)
+	case XLSX:
+		return writeXLSX(rows)
+	}
+	return nil, ErrWrongFormat
+}
+
+func writeCSV(rows [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// колонки сумм пишем числами, остальные - строками
+var numericColumns = map[int]bool{2: true, 3: true}
+
+// writeXLSX writes the minimal workbook with one sheet, strings are inline so no shared strings part is needed.
+func writeXLSX(rows [][]string) ([]byte, error) {
+	var sheet strings.Builder
+	sheet.WriteString(xml.Header)
+	sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for i := 0; i < len(rows); i++ {
+		sheet.WriteString(fmt.Sprintf(`<row r="%d">`, i+1))
+		for j := 0; j < len(rows[i]); j++ {
+			ref := fmt.Sprintf("%c%d", 'A'+j, i+1)
+			if i > 0 && numericColumns[j] {
+				sheet.WriteString(fmt.Sprintf(`<c r="%v"><v>%v</v></c>`, ref, rows[i][j]))
+				continue
+			}
+			sheet.WriteString(fmt.Sprintf(`<c r="%v" t="inlineStr"><is><t xml:space="preserve">`, ref))
+			if err := xml.EscapeText(&writer{&sheet}, []byte(rows[i][j])); err != nil {
+				return nil, err
+			}
+			sheet.WriteString(`</t></is></c>`)
+		}
+		sheet.WriteString(`</row>`)
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", contentTypes},
+		{"_rels/.rels", rootRels},
+		{"xl/workbook.xml", workbook},
+		{"xl/_rels/workbook.xml.rels", workbookRels},
+		{"xl/worksheets/sheet1.xml", sheet.String()},
+	}
+	for _, p := range parts {
+		f, err := zw.Create(p.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.Write([]byte(p.body)); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type writer struct {
+	b *strings.Builder
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	return w.b.Write(p)
+}
+
+const contentTypes = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+	`</Types>`
+
+const rootRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const workbook = xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+	`<sheets><sheet name="spendings" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+const workbookRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+	`</Relationships>`
@@ -0,0 +1,75 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
+)
+
+var entries = []model.ReportEntry{
+	{Date: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), Category: "food", Value: decimal.NewFromInt(300), Note: "lunch, with <friends>"},
+	{Date: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), Category: "taxi", Value: decimal.NewFromInt(150)},
+}
+
+var usd = model.Currency{Code: "usd", Ratio: decimal.RequireFromString("0.0125")}
+
+func TestWrite_CSV(t *testing.T) {
+	b, err := Write(CSV, entries, usd)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "date,category,amount rub,amount usd,note\n"+
+		"2026-03-01,food,300,3.75,\"lunch, with <friends>\"\n"+
+		"2026-03-02,taxi,150,1.88,\n", string(b))
+}
+
+func TestWrite_shouldUseRatesOfSpendingDates(t *testing.T) {
+	withRates := []model.ReportEntry{
+		{Date: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), Category: "food", Value: decimal.NewFromInt(300), Rate: decimal.RequireFromString("0.01")},
+		{Date: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), Category: "taxi", Value: decimal.RequireFromString("166.67"),
+			OriginalValue: decimal.NewFromInt(2), CurrencyCode: "usd", Rate: decimal.RequireFromString("0.0125")},
+	}
+	b, err := Write(CSV, withRates, usd)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "date,category,amount rub,amount usd,note\n"+
+		"2025-03-01,food,300,3,\n"+
+		"2026-03-02,taxi,166.67,2,\n", string(b))
+}
+
+func TestWrite_XLSX(t *testing.T) {
+	b, err := Write(XLSX, entries, usd)
+	assert.NoError(t, err)
+
+	r, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	assert.NoError(t, err)
+	names := make([]string, 0)
+	var sheet string
+	for _, f := range r.File {
+		names = append(names, f.Name)
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			rc, err := f.Open()
+			assert.NoError(t, err)
+			data, _ := io.ReadAll(rc)
+			sheet = string(data)
+		}
+	}
+	assert.ElementsMatch(t, []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"}, names)
+	assert.Contains(t, sheet, `<c r="C2"><v>300</v></c><c r="D2"><v>3.75</v></c>`)
+	assert.Contains(t, sheet, `lunch, with &lt;friends&gt;`)
+	assert.Contains(t, sheet, `<c r="A3" t="inlineStr"><is><t xml:space="preserve">2026-03-02</t></is></c>`)
+}
+
+func TestParseFormat(t *testing.T) {
+	f, err := ParseFormat("XLSX")
+	assert.NoError(t, err)
+	assert.Equal(t, XLSX, f)
+
+	_, err = ParseFormat("pdf")
+	assert.ErrorIs(t, err, ErrWrongFormat)
+}
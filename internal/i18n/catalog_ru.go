@@ -0,0 +1,289 @@
+package i18n
+
+// ключи - английский текст из кода, поэтому непереведённое сообщение показывается по-английски
+var messages = map[Lang]map[string]string{
+	Russian: {
+		// общие ответы
+		"hello":                                "привет",
+		"unknown command, see /help":           "не знаю эту команду, см. /help",
+		"wrong format":                         "неверный формат",
+		"no data":                              "нет данных",
+		"nothing found":                        "ничего не найдено",
+		"successfully changed":                 "изменено",
+		"successfully renamed":                 "переименовано",
+		"successfully deleted":                 "удалено",
+		"deleted":                              "удалено",
+		"ok, nothing changed":                  "хорошо, ничего не меняю",
+		"nothing to confirm":                   "нечего подтверждать",
+		"yes":                                  "да",
+		"no":                                   "нет",
+		"on":                                   "вкл",
+		"off":                                  "выкл",
+		"new":                                  "новое",
+		" or ":                                 " или ",
+		"language: %v":                         "язык: %v",
+		"unknown language, use en, ru or auto": "неизвестный язык, используйте en, ru или auto",
+		"%v\nsend /confirm %v to do it":        "%v\nотправьте /confirm %v, чтобы выполнить",
+
+		// траты
+		"added":       "добавлено",
+		"added to %v": "добавлено в %v",
+		"yes, %v":     "да, %v",
+		"is it %v? /yes - save, or send the category": "это %v? /yes - сохранить, или пришлите категорию",
+		"%v, current balance: %v":                     "%v, текущий баланс: %v",
+		"changed, current balance: %v":                "изменено, текущий баланс: %v",
+		"deleted, current balance: %v":                "удалено, текущий баланс: %v",
+		"deleted %v %v - %v, current balance: %v":     "удалено %v %v - %v, текущий баланс: %v",
+		"sum must be a number":                        "сумма должна быть числом",
+		"sum must be a positive number":               "сумма должна быть положительным числом",
+		"sum must be greater than zero":               "сумма должна быть больше нуля",
+		"id must be a number":                         "id должен быть числом",
+		"count must be a positive number":             "количество должно быть положительным числом",
+		"wrong date format":                           "неверный формат даты",
+		"spending not found":                          "трата не найдена",
+		"delete spending %v?":                         "удалить трату %v?",
+		"delete %v %v?":                               "удалить %v %v?",
+		"recurring":                                   "регулярную трату",
+		"rule":                                        "правило",
+
+		// разбор /add и диалог
+		"which currency: %v?":                                       "какая валюта: %v?",
+		"which one is the sum: %v?":                                 "что из этого сумма: %v?",
+		"which date: %v?":                                           "какая дата: %v?",
+		"how much? e.g. /add 350 food":                              "сколько? например, /add 350 еда",
+		"which category is it? %q is unknown, see /categories":      "какая это категория? %q не знаю, см. /categories",
+		"which category is it? e.g. /add 350 food, see /categories": "какая это категория? например, /add 350 еда, см. /categories",
+		"how much? e.g. 350 or 20$, /cancel to stop":                "сколько? например, 350 или 20$, /cancel - прервать",
+		"which category is it?":                                     "какая это категория?",
+		"which date? e.g. 12.03, today if not set":                  "какая дата? например, 12.03, если не указана - сегодня",
+		"%v: %v, which category is it?":                             "%v: %v, какая это категория?",
+		"wrong date format, which date? e.g. 12.03 or yesterday":    "неверный формат даты, какая дата? например, 12.03 или вчера",
+		"today":     "сегодня",
+		"yesterday": "вчера",
+
+		// категории
+		"unknown category":                 "неизвестная категория",
+		"category already exists":          "такая категория уже есть",
+		"can't merge category into itself": "нельзя перенести категорию в саму себя",
+		"categories are shared by all users, only the owner of the bot can change them": "категории общие для всех пользователей, менять их может только владелец бота",
+		"category name can't be a number":                                               "название категории не может быть числом",
+		"category added: %v - %v":                                                       "категория добавлена: %v - %v",
+		"income category added: %v - %v":                                                "категория доходов добавлена: %v - %v",
+		"delete category %v?":                                                           "удалить категорию %v?",
+		"delete category %v and move its spendings to %v?":                              "удалить категорию %v и перенести её траты в %v?",
+		"successfully deleted, spendings moved to %v":                                   "удалено, траты перенесены в %v",
+
+		// бюджет и лимиты
+		"limit for %v exceeded: %v of %v":     "лимит по %v превышен: %v из %v",
+		"%v%% of limit for %v used: %v of %v": "израсходовано %v%% лимита по %v: %v из %v",
+		"limit must be a non-negative number": "лимит должен быть неотрицательным числом",
+		"limit for %v removed":                "лимит по %v снят",
+		"limit for %v set: %v":                "лимит по %v установлен: %v",
+		"no limits":                           "лимитов нет",
+		"%v - %v of %v (%v%%)":                "%v - %v из %v (%v%%)",
+		"%v, day %v":                          "%v, день %v",
+		"period: %v\nrollover: %v\ncurrent: %v - %v\nbudget: %v, balance: %v": "период: %v\nперенос остатка: %v\nтекущий: %v - %v\nбюджет: %v, баланс: %v",
+		"%v - %v: budget %v, left %v":                                         "%v - %v: бюджет %v, осталось %v",
+		"day must be a number":                                                "день должен быть числом",
+		"day must be between 1 and 31":                                        "день должен быть от 1 до 31",
+		"wrong budget period":                                                 "неверный период бюджета",
+		"week":                                                                "неделя",
+		"2weeks":                                                              "2 недели",
+		"month":                                                               "месяц",
+		"year":                                                                "год",
+		"day":                                                                 "день",
+
+		// регулярные траты
+		"recurring spending %v added, next: %v":                     "регулярная трата %v добавлена, следующая: %v",
+		"%v. %v - %v, %v, next: %v":                                 "%v. %v - %v, %v, следующая: %v",
+		"recurring spending added: %v %v - %v, current balance: %v": "добавлена регулярная трата: %v %v - %v, текущий баланс: %v",
+		"recurring spending not found":                              "регулярная трата не найдена",
+		"wrong schedule format":                                     "неверный формат расписания",
+
+		// отчёты
+		"which period?":            "за какой период?",
+		"this month":               "этот месяц",
+		"last month":               "прошлый месяц",
+		"this month by day":        "этот месяц по дням",
+		"month compare":            "сравнить месяцы",
+		"calculating report...":    "считаю отчёт...",
+		"wrong report period":      "неверный период отчёта",
+		"wrong report mode":        "неверный вид отчёта",
+		"from: %v, to: %v\n":       "с %v по %v\n",
+		"from: %v, to: %v by %v\n": "с %v по %v, разбивка: %v\n",
+		"expenses:\n":              "расходы:\n",
+		"income:\n":                "доходы:\n",
+		"expenses total: %v\n":     "всего расходов: %v\n",
+		"income total: %v\n":       "всего доходов: %v\n",
+		"net: %v\n":                "итог: %v\n",
+		"%v - %v vs %v - %v\n":     "%v - %v против %v - %v\n",
+		"total: %v\n":              "всего: %v\n",
+		"%v (was %v, %v%v, %v)":    "%v (было %v, %v%v, %v)",
+		"top %v from: %v, to: %v":  "топ %v с %v по %v",
+		"expenses %v":              "расходы %v",
+		"expenses by %v %v":        "расходы, разбивка: %v, %v",
+		"spent vs budget %v":       "потрачено и бюджет %v",
+		"no data for chart":        "нет данных для графика",
+		"wrong currency type":      "неизвестная валюта",
+
+		// экспорт и импорт
+		"no spendings for the period":                                      "за период трат нет",
+		"export is being prepared, the file will be sent when it is ready": "готовлю выгрузку, файл придёт, когда будет готов",
+		"exported spendings: %v":                                           "выгружено трат: %v",
+		"wrong export format":                                              "неверный формат выгрузки",
+		"spendings to import: %v, duplicates skipped: %v\n":                "трат к импорту: %v, пропущено повторов: %v\n",
+		"... and %v more\n":                                                "... и ещё %v\n",
+		", maybe %v":                                                       ", возможно %v",
+		" (guessed)":                                                       " (угадано)",
+		"/import confirm - save, /import cancel - discard":                 "/import confirm - сохранить, /import cancel - отменить",
+		"imported spendings: %v, current balance: %v":                      "импортировано трат: %v, текущий баланс: %v",
+		"failed to build the report, try again later":                      "не удалось построить отчёт, попробуйте позже",
+		"import canceled":                                                  "импорт отменён",
+		"/import confirm - save, /import confirm all - save with the guessed categories too, /import cancel - discard": "/import confirm - сохранить, /import confirm all - сохранить и с угаданными категориями, /import cancel - отменить",
+		"nothing to import, send a bank statement file first":                                                          "нечего импортировать, сначала пришлите файл выписки",
+		"no spendings in the statement":                                                                                "в выписке нет трат",
+		"unknown statement format, csv, ofx and qif are supported":                                                     "неизвестный формат выписки, поддерживаются csv, ofx и qif",
+		"wrong amount in statement":                                                                                    "неверная сумма в выписке",
+		"wrong date in statement":                                                                                      "неверная дата в выписке",
+		"unknown csv columns, add a mapping to import_mappings in config":                                              "неизвестные колонки csv, добавьте сопоставление в import_mappings в конфиге",
+		"unknown rate provider, cbr, ecb and file are supported":                                                       "неизвестный источник курсов, поддерживаются cbr, ecb и file",
+		"no rates in the period":                                                                                       "нет курсов за период",
+		"rates from %v to %v:":                                                                                         "курсы с %v по %v:",
+		"%v: %v (%v), updated %v":                                                                                      "%v: %v (%v), обновлён %v",
+		"%v - %v, updated %v":                                                                                          "%v - %v, обновлён %v",
+		"%v rate in %v %v - %v":                                                                                        "курс %v в %v %v - %v",
+		"never":                                                                                                        "никогда",
+		"the rate is outdated":                                                                                         "курс устарел",
+		"rates of the currency are not loaded yet":                                                                     "курсы валюты ещё не загружены",
+		"%v: the %v rate was loaded %v hours ago, try again later":                                                     "%v: курс %v загружен %v ч. назад, попробуйте позже",
+		"the %v rate was updated %v, the sum in %v may be inaccurate":                                                  "курс %v обновлён %v, сумма в %v может быть неточной",
+		"file is too large":                                                                                            "файл слишком большой",
+
+		// правила категорий
+		"rule %v added: %v %q - %v":                      "правило %v добавлено: %v %q - %v",
+		"category rule not found":                        "правило не найдено",
+		"wrong rule kind, use exact, substring or regex": "неверный вид правила, используйте exact, substring или regex",
+		"wrong regex: %v":                                "неверное регулярное выражение: %v",
+
+		// чеки
+		"receipt of %v for %v, which category is it? /receipt [category]":        "чек от %v на %v, какая это категория? /receipt [категория]",
+		"no receipt waiting for the category, send a photo of the receipt first": "нет чека, ждущего категорию, сначала пришлите фото чека",
+		"can't read the photo": "не получается прочитать фото",
+		"no receipt qr code found on the photo, try to take it closer and without glare": "на фото не найден QR код чека, попробуйте снять ближе и без бликов",
+		"it is not a qr code of a fiscal receipt":                                        "это не QR код кассового чека",
+		"it is a refund receipt, spendings are added only from purchase receipts":        "это чек возврата, траты добавляются только из чеков прихода",
+		"qr code not found":                 "QR код не найден",
+		"qr code is damaged or unsupported": "QR код повреждён или не поддерживается",
+		"too many errors to correct":        "слишком много ошибок для исправления",
+		"wrong data in qr code":             "неверные данные в QR коде",
+
+		`
+/help - call this help
+/categories - show all categories
+/addcategory [name] - add category
+/renamecategory [category] [name] - rename category
+/deletecategory [category] [target category] - archive category, spendings are moved to the target category if it is set
+/currencies - show all currencies with rates in the base currency and the time they were loaded
+/convert [sum] [currency] [currency] - convert the sum at the stored rates, e.g. /convert 100 usd eur
+/rates [currency] [period] - show rates in the base currency and their change over the period like in /report, last month if not set
+/rates chart [currency] [period] - show the chart of the rate, e.g. /rates chart usd 90d
+/add [category] [sum] [date] [note] - add spending in any order, e.g. /add 350 food, /add food 350,50 yesterday #trip, /add 20$ taxi 12.03, /add food 20 eur. category is an id or a name, date is today if not set, words of the note starting with # are tags, a sum in another currency is converted at its rate and the display currency stays the same
+/add without arguments asks the sum, the category and the date one by one, /cancel - stop at any step
+/find [text|#tag] [period] - find spendings by note text or tag in the period like in /report, all time if not set
+/income [category] [sum] [date] - add income, date is today if not set
+/incomecategories - show all income categories
+/addincomecategory [name] - add income category
+/limit [category] [sum] - set limit of the category for the budget period in the base currency, 0 removes the limit
+/budget - show spent vs limit for each category
+/period - show budget period settings
+/period [type] [day] - change budget period. type: week, 2weeks, month. day - day of month for the monthly period
+/rollover [on|off] - carry unspent or overspent budget into the next period
+/periods - show closed budget periods
+/history [count] - show last spendings with their ids
+/edit [id] [category] [sum] [date] - change spending
+/delete [id] - delete spending, asks to confirm like other deletions
+/confirm [command] - do the deletion without asking
+/undo - delete the last added spending
+/recurring add [category] [sum] [schedule] - add recurring spending. schedule: cron expression "minute hour day month weekday" or @daily, @weekly, @monthly, @yearly
+/recurring list - show recurring spendings
+/recurring delete [id] - delete recurring spending
+/report [period] [mode] - show report. period: w, m, y - last week, month, year; 90d, 2w, 6m - last days, weeks, months; today, yesterday; this/last week, month, year; q1..q4 [year]; 2025; 03-2026; 01-03-2026; 01-03-2026 31-03-2026
+  mode: by day|week|month|category - breakdown, compare - compare with the previous period, top [count] - largest spendings
+  add chart at the end to get charts, e.g. /report m chart
+send a bank statement file (csv, ofx, qif) to import spendings from it
+/import confirm - save the imported spendings, /import confirm all - with the uncertainly guessed categories too, /import cancel - discard them
+/rule add [category] [exact|substring|regex] [pattern] - set the category of spendings whose note or statement merchant matches the pattern, used when the category is not set in /add
+/rule list - show category rules
+/rule delete [id] - delete category rule
+/yes - save the spending with the guessed category, /no or /cancel - drop it
+send a photo of a receipt with a qr code to add its sum and date, a category in the caption saves it at once
+/receipt [category] - save the spending from the last receipt photo to the category
+/export [period] [csv|xlsx] - export spendings of the period like in /report to a file, last month in csv if not set
+/currency [type] - change currency
+/lang [en|ru|auto] - change language, auto follows the language of telegram
+`: `
+/help - эта справка
+/categories - все категории
+/addcategory [название] - добавить категорию
+/renamecategory [категория] [название] - переименовать категорию
+/deletecategory [категория] [целевая категория] - архивировать категорию, траты переносятся в целевую категорию, если она указана
+/currencies - все валюты с курсами в базовой валюте и временем их загрузки
+/convert [сумма] [валюта] [валюта] - перевести сумму по сохранённым курсам, например /convert 100 usd eur
+/rates [валюта] [период] - курсы в базовой валюте и их изменение за период как в /report, по умолчанию за последний месяц
+/rates chart [валюта] [период] - график курса, например /rates chart usd 90d
+/add [категория] [сумма] [дата] [заметка] - добавить трату в любом порядке, например /add 350 еда, /add еда 350,50 вчера #поездка, /add 20$ такси 12.03, /add еда 20 eur. категория - id или название, дата по умолчанию сегодня, слова заметки с # - теги, сумма в другой валюте пересчитывается по её курсу, а валюта отображения не меняется
+/add без аргументов спрашивает сумму, категорию и дату по очереди, /cancel - прервать на любом шаге
+/find [текст|#тег] [период] - найти траты по тексту заметки или тегу за период как в /report, по умолчанию за всё время
+/income [категория] [сумма] [дата] - добавить доход, дата по умолчанию сегодня
+/incomecategories - все категории доходов
+/addincomecategory [название] - добавить категорию доходов
+/limit [категория] [сумма] - лимит категории на период бюджета в базовой валюте, 0 снимает лимит
+/budget - потрачено и лимит по каждой категории
+/period - настройки периода бюджета
+/period [вид] [день] - изменить период бюджета. вид: week, 2weeks, month. день - день месяца для месячного периода
+/rollover [on|off] - переносить остаток или перерасход бюджета на следующий период
+/periods - закрытые периоды бюджета
+/history [количество] - последние траты с их id
+/edit [id] [категория] [сумма] [дата] - изменить трату
+/delete [id] - удалить трату, как и другие удаления просит подтверждение
+/confirm [команда] - удалить без вопроса
+/undo - удалить последнюю добавленную трату
+/recurring add [категория] [сумма] [расписание] - добавить регулярную трату. расписание: cron "минута час день месяц день_недели" или @daily, @weekly, @monthly, @yearly
+/recurring list - регулярные траты
+/recurring delete [id] - удалить регулярную трату
+/report [период] [вид] - отчёт. период: w, m, y - последние неделя, месяц, год; 90d, 2w, 6m - последние дни, недели, месяцы; today, yesterday; this/last week, month, year; q1..q4 [год]; 2025; 03-2026; 01-03-2026; 01-03-2026 31-03-2026
+  вид: by day|week|month|category - разбивка, compare - сравнение с прошлым периодом, top [количество] - самые крупные траты
+  добавьте chart в конце, чтобы получить графики, например /report m chart
+пришлите файл выписки банка (csv, ofx, qif), чтобы импортировать из него траты
+/import confirm - сохранить импортированные траты, /import confirm all - вместе с неуверенно угаданными категориями, /import cancel - отменить
+/rule add [категория] [exact|substring|regex] [шаблон] - категория для трат, у которых заметка или продавец в выписке подходит под шаблон, используется, когда категория не указана в /add
+/rule list - правила категорий
+/rule delete [id] - удалить правило
+/yes - сохранить трату с угаданной категорией, /no или /cancel - отменить
+пришлите фото чека с QR кодом, чтобы добавить его сумму и дату, категория в подписи сохраняет трату сразу
+/receipt [категория] - сохранить трату из последнего фото чека в категорию
+/export [период] [csv|xlsx] - выгрузить траты за период как в /report в файл, по умолчанию прошлый месяц в csv
+/currency [валюта] - сменить валюту
+/lang [en|ru|auto] - сменить язык, auto - как в telegram
+`,
+	},
+}
+
+// формы множественного числа: в английском одна и много, в русском одна, несколько и много
+var plurals = map[Lang]map[string][]string{
+	English: {
+		"total: %v in %v spendings": {"total: %v in %v spending", "total: %v in %v spendings"},
+		"no category for %v spendings, they will be skipped, add rules with /rule add [category] substring [merchant] and send the file again:\n": {
+			"no category for %v spending, it will be skipped, add rules with /rule add [category] substring [merchant] and send the file again:\n",
+			"no category for %v spendings, they will be skipped, add rules with /rule add [category] substring [merchant] and send the file again:\n",
+		},
+	},
+	Russian: {
+		"total: %v in %v spendings": {"итого: %v, %v трата", "итого: %v, %v траты", "итого: %v, %v трат"},
+		"no category for %v spendings, they will be skipped, add rules with /rule add [category] substring [merchant] and send the file again:\n": {
+			"нет категории у %v траты, она будет пропущена, добавьте правила через /rule add [категория] substring [продавец] и пришлите файл снова:\n",
+			"нет категории у %v трат, они будут пропущены, добавьте правила через /rule add [категория] substring [продавец] и пришлите файл снова:\n",
+			"нет категории у %v трат, они будут пропущены, добавьте правила через /rule add [категория] substring [продавец] и пришлите файл снова:\n",
+		},
+	},
+}
@@ -0,0 +1,90 @@
+package i18n
+
+import (
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type numberFormat struct {
+	group   string
+	decimal string
+}
+
+var numberFormats = map[Lang]numberFormat{
+	English: {group: ",", decimal: "."},
+	Russian: {group: " ", decimal: ","},
+}
+
+var dateTemplates = map[Lang]string{
+	English: "02-01-2006",
+	Russian: "02.01.2006",
+}
+
+var currencySymbols = map[string]string{
+	"rub": "₽",
+	"usd": "$",
+	"eur": "€",
+	"cny": "¥",
+}
+
+// Number formats the value with the separators of the language: 1,234.5 or 1 234,5.
+func (l Lang) Number(v decimal.Decimal) string {
+	return l.formatNumber(v.String())
+}
+
+func (l Lang) formatNumber(s string) string {
+	f, ok := numberFormats[l]
+	if !ok {
+		f = numberFormats[Default]
+	}
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	intPart, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, frac = s[:i], s[i+1:]
+	}
+	var sb strings.Builder
+	sb.WriteString(sign)
+	for i := 0; i < len(intPart); i++ {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			sb.WriteString(f.group)
+		}
+		sb.WriteByte(intPart[i])
+	}
+	if frac != "" {
+		sb.WriteString(f.decimal)
+		sb.WriteString(frac)
+	}
+	return sb.String()
+}
+
+// Money formats the sum with kopecks and the symbol of the currency: $1,234.50 or 1 234,50 ₽.
+func (l Lang) Money(v decimal.Decimal, currencyCode string) string {
+	number := l.formatNumber(v.StringFixed(2))
+	symbol, ok := currencySymbols[strings.ToLower(currencyCode)]
+	if !ok {
+		symbol = strings.ToUpper(currencyCode)
+	}
+	switch {
+	case symbol == "":
+		return number
+	case l == Russian || !ok:
+		return number + " " + symbol
+	case strings.HasPrefix(number, "-"):
+		return "-" + symbol + number[1:]
+	}
+	return symbol + number
+}
+
+// Date formats the date in the way the user writes it in commands.
+func (l Lang) Date(t time.Time) string {
+	tmpl, ok := dateTemplates[l]
+	if !ok {
+		tmpl = dateTemplates[Default]
+	}
+	return t.Format(tmpl)
+}
@@ -0,0 +1,44 @@
+package i18n
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLang_Money(t *testing.T) {
+	tests := []struct {
+		name  string
+		lang  Lang
+		value string
+		code  string
+		text  string
+	}{
+		{name: "russian", lang: Russian, value: "1234.5", code: "rub", text: "1 234,50 ₽"},
+		{name: "russian millions", lang: Russian, value: "1234567", code: "usd", text: "1 234 567,00 $"},
+		{name: "english", lang: English, value: "1234.5", code: "usd", text: "$1,234.50"},
+		{name: "english negative", lang: English, value: "-5", code: "rub", text: "-₽5.00"},
+		{name: "small", lang: English, value: "0.123", code: "eur", text: "€0.12"},
+		{name: "unknown currency", lang: English, value: "100", code: "xyz", text: "100.00 XYZ"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.text, tt.lang.Money(decimal.RequireFromString(tt.value), tt.code))
+		})
+	}
+}
+
+func TestLang_Number(t *testing.T) {
+	assert.Equal(t, "1 100", Russian.Number(decimal.NewFromInt(1100)))
+	assert.Equal(t, "-12 345,6", Russian.Number(decimal.RequireFromString("-12345.6")))
+	assert.Equal(t, "999", English.Number(decimal.NewFromInt(999)))
+	assert.Equal(t, "1,000", English.Number(decimal.NewFromInt(1000)))
+}
+
+func TestLang_Date(t *testing.T) {
+	dt := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, "01.03.2026", Russian.Date(dt))
+	assert.Equal(t, "01-03-2026", English.Date(dt))
+}
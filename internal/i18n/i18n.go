@@ -0,0 +1,149 @@
+// Package i18n translates replies of the bot and formats numbers, money and dates for the language of the user.
+// Messages are keyed by their english text, so a missing translation falls back to english.
+package i18n
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type Lang string
+
+const (
+	English Lang = "en"
+	Russian Lang = "ru"
+)
+
+// Default is used when the language of the user is unknown.
+const Default = English
+
+var Langs = []Lang{English, Russian}
+
+// языки, пользователям которых русский понятнее английского
+var russianSpeaking = map[string]bool{"ru": true, "be": true, "uk": true, "kk": true}
+
+// Detect returns the language for the language_code of a telegram user like "ru" or "en-US".
+func Detect(code string) Lang {
+	code = strings.ToLower(code)
+	if i := strings.IndexAny(code, "-_"); i >= 0 {
+		code = code[:i]
+	}
+	if russianSpeaking[code] {
+		return Russian
+	}
+	return Default
+}
+
+// Parse returns the supported language by its code.
+func Parse(code string) (Lang, bool) {
+	for _, l := range Langs {
+		if string(l) == strings.ToLower(code) {
+			return l, true
+		}
+	}
+	return "", false
+}
+
+// Of returns the supported language by its code or the default one.
+func Of(code string) Lang {
+	if l, ok := Parse(code); ok {
+		return l
+	}
+	return Default
+}
+
+type ctxKey struct{}
+
+func WithLang(ctx context.Context, l Lang) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext returns the language of the user the message is handled for.
+func FromContext(ctx context.Context) Lang {
+	if l, ok := ctx.Value(ctxKey{}).(Lang); ok {
+		return l
+	}
+	return Default
+}
+
+// T translates the message and formats it with the args like fmt.Sprintf.
+func (l Lang) T(msg string, args ...interface{}) string {
+	if tr, ok := messages[l][msg]; ok {
+		msg = tr
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// N translates the message in the plural form for n, the message is the english form for many.
+func (l Lang) N(n int, msg string, args ...interface{}) string {
+	if forms, ok := plurals[l][msg]; ok {
+		msg = forms[pluralForm(l, n)]
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// Has reports whether the message has a translation to the language.
+func (l Lang) Has(msg string) bool {
+	_, ok := messages[l][msg]
+	_, plural := plurals[l][msg]
+	return ok || plural
+}
+
+func pluralForm(l Lang, n int) int {
+	if n < 0 {
+		n = -n
+	}
+	if l == Russian {
+		switch {
+		case n%10 == 1 && n%100 != 11:
+			return 0
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+			return 1
+		}
+		return 2
+	}
+	if n == 1 {
+		return 0
+	}
+	return 1
+}
+
+// Error is an error whose message is translated with its args, the errors among the args are translated too.
+type Error struct {
+	format string
+	args   []interface{}
+	err    error
+}
+
+// Errorf creates the error like fmt.Errorf, %w keeps the wrapped error for errors.Is.
+func Errorf(format string, args ...interface{}) error {
+	return &Error{format: format, args: args, err: fmt.Errorf(format, args...)}
+}
+
+func (e *Error) Error() string {
+	return e.err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return errors.Unwrap(e.err)
+}
+
+// Error translates the message of the error, unknown errors are returned as is.
+func (l Lang) Error(err error) string {
+	if e, ok := err.(*Error); ok {
+		args := make([]interface{}, len(e.args))
+		for i, a := range e.args {
+			if wrapped, ok := a.(error); ok {
+				a = l.Error(wrapped)
+			}
+			args[i] = a
+		}
+		return l.T(strings.ReplaceAll(e.format, "%w", "%v"), args...)
+	}
+	return l.T(err.Error())
+}
@@ -0,0 +1,165 @@
+package i18n
+
+import (
+	"context"
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		code string
+		lang Lang
+	}{
+		{code: "ru", lang: Russian},
+		{code: "ru-RU", lang: Russian},
+		{code: "uk", lang: Russian},
+		{code: "en-US", lang: English},
+		{code: "de", lang: English},
+		{code: "", lang: English},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			assert.Equal(t, tt.lang, Detect(tt.code))
+		})
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	assert.Equal(t, Default, FromContext(context.Background()))
+	assert.Equal(t, Russian, FromContext(WithLang(context.Background(), Russian)))
+}
+
+func TestLang_T(t *testing.T) {
+	assert.Equal(t, "добавлено в food", Russian.T("added to %v", "food"))
+	assert.Equal(t, "added to food", English.T("added to %v", "food"))
+	// без перевода остаётся английский текст
+	assert.Equal(t, "no such message 5", Russian.T("no such message %v", 5))
+}
+
+func TestLang_N(t *testing.T) {
+	tests := []struct {
+		lang Lang
+		n    int
+		text string
+	}{
+		{lang: English, n: 1, text: "total: 5 in 1 spending"},
+		{lang: English, n: 2, text: "total: 5 in 2 spendings"},
+		{lang: Russian, n: 1, text: "итого: 5, 1 трата"},
+		{lang: Russian, n: 3, text: "итого: 5, 3 траты"},
+		{lang: Russian, n: 5, text: "итого: 5, 5 трат"},
+		{lang: Russian, n: 11, text: "итого: 5, 11 трат"},
+		{lang: Russian, n: 21, text: "итого: 5, 21 трата"},
+		{lang: Russian, n: 112, text: "итого: 5, 112 трат"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			assert.Equal(t, tt.text, tt.lang.N(tt.n, "total: %v in %v spendings", 5, tt.n))
+		})
+	}
+}
+
+func TestLang_Error(t *testing.T) {
+	errUnknown := errors.New("unknown category")
+	err := Errorf("%w: %v", errUnknown, "fod")
+
+	assert.ErrorIs(t, err, errUnknown)
+	assert.Equal(t, "unknown category: fod", err.Error())
+	assert.Equal(t, "неизвестная категория: fod", Russian.Error(err))
+	assert.Equal(t, "неверный формат", Russian.Error(errors.New("wrong format")))
+}
+
+// Test_catalogIsComplete checks that every message passed to T, N and Errorf and every error text has the russian translation.
+func Test_catalogIsComplete(t *testing.T) {
+	fset := token.NewFileSet()
+	err := filepath.WalkDir("..", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == "mocks" {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+		for _, msg := range messagesOf(file) {
+			// в формате без слов переводить нечего
+			if strings.Trim(msg, "%vwdq:., \n") == "" {
+				continue
+			}
+			// Error ищет перевод формата с %w, заменённым на %v
+			assert.True(t, Russian.Has(strings.ReplaceAll(msg, "%w", "%v")), "%v: no translation for %q", path, msg)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func messagesOf(file *ast.File) []string {
+	consts := make(map[string]string)
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok {
+			for _, spec := range gen.Specs {
+				if v, ok := spec.(*ast.ValueSpec); ok && len(v.Names) == 1 && len(v.Values) == 1 {
+					if s, ok := stringLit(v.Values[0]); ok {
+						consts[v.Names[0].Name] = s
+					}
+				}
+			}
+		}
+	}
+
+	msgs := make([]string, 0)
+	ast.Inspect(file, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		pkg, _ := sel.X.(*ast.Ident)
+		arg := -1
+		switch {
+		case sel.Sel.Name == "T" || (sel.Sel.Name == "Errorf" && pkg != nil && pkg.Name == "i18n"):
+			arg = 0
+		case sel.Sel.Name == "N":
+			arg = 1
+		case sel.Sel.Name == "New" && pkg != nil && pkg.Name == "errors":
+			arg = 0
+		}
+		if arg < 0 || len(call.Args) <= arg {
+			return true
+		}
+		if s, ok := stringLit(call.Args[arg]); ok {
+			msgs = append(msgs, s)
+		} else if id, ok := call.Args[arg].(*ast.Ident); ok && consts[id.Name] != "" {
+			msgs = append(msgs, consts[id.Name])
+		}
+		return true
+	})
+	return msgs
+}
+
+func stringLit(e ast.Expr) (string, bool) {
+	lit, ok := e.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	return s, err == nil
+}
@@ -45,11 +45,15 @@ func NewProducer(ctx context.Context, topic string, brokers []string) (*Producer
 	return &Producer{producer, topic}, nil
 }
 
-func (producer *Producer) Send(key string, value string) {
+// Send writes the message and waits until it is written, headers carry the tracing context.
+func (producer *Producer) Send(key string, value []byte, headers map[string]string) {
 	msg := sarama.ProducerMessage{
 		Topic: producer.topic,
 		Key:   sarama.StringEncoder(key),
-		Value: sarama.StringEncoder(value),
+		Value: sarama.ByteEncoder(value),
+	}
+	for k, v := range headers {
+		msg.Headers = append(msg.Headers, sarama.RecordHeader{Key: []byte(k), Value: []byte(v)})
 	}
 	producer.p.Input() <- &msg
 	successMsg := <-producer.p.Successes()
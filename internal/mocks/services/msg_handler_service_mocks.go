@@ -37,6 +37,34 @@ func (m *MockMessageSender) EXPECT() *MockMessageSenderMockRecorder {
 	return m.recorder
 }
 
+// SendDocument mocks base method.
+func (m *MockMessageSender) SendDocument(data []byte, name string, userID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendDocument", data, name, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendDocument indicates an expected call of SendDocument.
+func (mr *MockMessageSenderMockRecorder) SendDocument(data, name, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendDocument", reflect.TypeOf((*MockMessageSender)(nil).SendDocument), data, name, userID)
+}
+
+// SendKeyboard mocks base method.
+func (m *MockMessageSender) SendKeyboard(text string, keyboard model.Keyboard, userID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendKeyboard", text, keyboard, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendKeyboard indicates an expected call of SendKeyboard.
+func (mr *MockMessageSenderMockRecorder) SendKeyboard(text, keyboard, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendKeyboard", reflect.TypeOf((*MockMessageSender)(nil).SendKeyboard), text, keyboard, userID)
+}
+
 // SendMessage mocks base method.
 func (m *MockMessageSender) SendMessage(text string, userID int64) error {
 	m.ctrl.T.Helper()
@@ -51,6 +79,20 @@ func (mr *MockMessageSenderMockRecorder) SendMessage(text, userID interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMessage", reflect.TypeOf((*MockMessageSender)(nil).SendMessage), text, userID)
 }
 
+// SendPhoto mocks base method.
+func (m *MockMessageSender) SendPhoto(png []byte, caption string, userID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendPhoto", png, caption, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendPhoto indicates an expected call of SendPhoto.
+func (mr *MockMessageSenderMockRecorder) SendPhoto(png, caption, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendPhoto", reflect.TypeOf((*MockMessageSender)(nil).SendPhoto), png, caption, userID)
+}
+
 // MockSpendingServiceI is a mock of SpendingServiceI interface.
 type MockSpendingServiceI struct {
 	ctrl     *gomock.Controller
@@ -74,10 +116,89 @@ func (m *MockSpendingServiceI) EXPECT() *MockSpendingServiceIMockRecorder {
 	return m.recorder
 }
 
+// Delete mocks base method.
+func (m *MockSpendingServiceI) Delete(arg0 context.Context, arg1, arg2 int64) (decimal.Decimal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1, arg2)
+	ret0, _ := ret[0].(decimal.Decimal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockSpendingServiceIMockRecorder) Delete(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockSpendingServiceI)(nil).Delete), arg0, arg1, arg2)
+}
+
+// Find mocks base method.
+func (m *MockSpendingServiceI) Find(arg0 context.Context, arg1 int64, arg2 string, arg3, arg4 time.Time) ([]model.Spending, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Find", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].([]model.Spending)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Find indicates an expected call of Find.
+func (mr *MockSpendingServiceIMockRecorder) Find(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*MockSpendingServiceI)(nil).Find), arg0, arg1, arg2, arg3, arg4)
+}
+
+// GetBreakdown mocks base method.
+func (m *MockSpendingServiceI) GetBreakdown(arg0 context.Context, arg1 int64, arg2, arg3 time.Time, arg4 model.ReportMode) ([]model.ReportBucket, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBreakdown", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].([]model.ReportBucket)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetBreakdown indicates an expected call of GetBreakdown.
+func (mr *MockSpendingServiceIMockRecorder) GetBreakdown(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBreakdown", reflect.TypeOf((*MockSpendingServiceI)(nil).GetBreakdown), arg0, arg1, arg2, arg3, arg4)
+}
+
+// GetEntries mocks base method.
+func (m *MockSpendingServiceI) GetEntries(arg0 context.Context, arg1 int64, arg2, arg3 time.Time, arg4 int) ([]model.ReportEntry, model.Currency, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEntries", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].([]model.ReportEntry)
+	ret1, _ := ret[1].(model.Currency)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetEntries indicates an expected call of GetEntries.
+func (mr *MockSpendingServiceIMockRecorder) GetEntries(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEntries", reflect.TypeOf((*MockSpendingServiceI)(nil).GetEntries), arg0, arg1, arg2, arg3, arg4)
+}
+
+// GetLast mocks base method.
+func (m *MockSpendingServiceI) GetLast(arg0 context.Context, arg1 int64, arg2 int) ([]model.Spending, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLast", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]model.Spending)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetLast indicates an expected call of GetLast.
+func (mr *MockSpendingServiceIMockRecorder) GetLast(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLast", reflect.TypeOf((*MockSpendingServiceI)(nil).GetLast), arg0, arg1, arg2)
+}
+
 // GetStatsBy mocks base method.
-func (m *MockSpendingServiceI) GetStatsBy(arg0 context.Context, arg1, arg2 time.Time) (map[string]decimal.Decimal, string, error) {
+func (m *MockSpendingServiceI) GetStatsBy(arg0 context.Context, arg1 int64, arg2, arg3 time.Time) (map[string]decimal.Decimal, string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetStatsBy", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "GetStatsBy", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].(map[string]decimal.Decimal)
 	ret1, _ := ret[1].(string)
 	ret2, _ := ret[2].(error)
@@ -85,24 +206,72 @@ func (m *MockSpendingServiceI) GetStatsBy(arg0 context.Context, arg1, arg2 time.
 }
 
 // GetStatsBy indicates an expected call of GetStatsBy.
-func (mr *MockSpendingServiceIMockRecorder) GetStatsBy(arg0, arg1, arg2 interface{}) *gomock.Call {
+func (mr *MockSpendingServiceIMockRecorder) GetStatsBy(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatsBy", reflect.TypeOf((*MockSpendingServiceI)(nil).GetStatsBy), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatsBy", reflect.TypeOf((*MockSpendingServiceI)(nil).GetStatsBy), arg0, arg1, arg2, arg3)
 }
 
-// SaveTx mocks base method.
-func (m *MockSpendingServiceI) SaveTx(arg0 context.Context, arg1 model.Spending) (decimal.Decimal, error) {
+// GetTop mocks base method.
+func (m *MockSpendingServiceI) GetTop(arg0 context.Context, arg1 int64, arg2, arg3 time.Time, arg4 int) ([]model.ReportEntry, string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SaveTx", arg0, arg1)
+	ret := m.ctrl.Call(m, "GetTop", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].([]model.ReportEntry)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetTop indicates an expected call of GetTop.
+func (mr *MockSpendingServiceIMockRecorder) GetTop(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTop", reflect.TypeOf((*MockSpendingServiceI)(nil).GetTop), arg0, arg1, arg2, arg3, arg4)
+}
+
+// Save mocks base method.
+func (m *MockSpendingServiceI) Save(arg0 context.Context, arg1 model.Spending) (decimal.Decimal, *model.Currency, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", arg0, arg1)
+	ret0, _ := ret[0].(decimal.Decimal)
+	ret1, _ := ret[1].(*model.Currency)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockSpendingServiceIMockRecorder) Save(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockSpendingServiceI)(nil).Save), arg0, arg1)
+}
+
+// Undo mocks base method.
+func (m *MockSpendingServiceI) Undo(arg0 context.Context, arg1 int64) (model.Spending, decimal.Decimal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Undo", arg0, arg1)
+	ret0, _ := ret[0].(model.Spending)
+	ret1, _ := ret[1].(decimal.Decimal)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Undo indicates an expected call of Undo.
+func (mr *MockSpendingServiceIMockRecorder) Undo(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Undo", reflect.TypeOf((*MockSpendingServiceI)(nil).Undo), arg0, arg1)
+}
+
+// Update mocks base method.
+func (m *MockSpendingServiceI) Update(arg0 context.Context, arg1 model.Spending) (decimal.Decimal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", arg0, arg1)
 	ret0, _ := ret[0].(decimal.Decimal)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// SaveTx indicates an expected call of SaveTx.
-func (mr *MockSpendingServiceIMockRecorder) SaveTx(arg0, arg1 interface{}) *gomock.Call {
+// Update indicates an expected call of Update.
+func (mr *MockSpendingServiceIMockRecorder) Update(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveTx", reflect.TypeOf((*MockSpendingServiceI)(nil).SaveTx), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockSpendingServiceI)(nil).Update), arg0, arg1)
 }
 
 // MockCurrencyService is a mock of CurrencyService interface.
@@ -128,6 +297,21 @@ func (m *MockCurrencyService) EXPECT() *MockCurrencyServiceMockRecorder {
 	return m.recorder
 }
 
+// Convert mocks base method.
+func (m *MockCurrencyService) Convert(value decimal.Decimal, from, to string) (decimal.Decimal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Convert", value, from, to)
+	ret0, _ := ret[0].(decimal.Decimal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Convert indicates an expected call of Convert.
+func (mr *MockCurrencyServiceMockRecorder) Convert(value, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Convert", reflect.TypeOf((*MockCurrencyService)(nil).Convert), value, from, to)
+}
+
 // GetAll mocks base method.
 func (m *MockCurrencyService) GetAll() []model.Currency {
 	m.ctrl.T.Helper()
@@ -142,18 +326,117 @@ func (mr *MockCurrencyServiceMockRecorder) GetAll() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockCurrencyService)(nil).GetAll))
 }
 
+// GetCurrentCurrency mocks base method.
+func (m *MockCurrencyService) GetCurrentCurrency(ctx context.Context, userId int64) (model.Currency, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCurrentCurrency", ctx, userId)
+	ret0, _ := ret[0].(model.Currency)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCurrentCurrency indicates an expected call of GetCurrentCurrency.
+func (mr *MockCurrencyServiceMockRecorder) GetCurrentCurrency(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCurrentCurrency", reflect.TypeOf((*MockCurrencyService)(nil).GetCurrentCurrency), ctx, userId)
+}
+
+// GetHistory mocks base method.
+func (m *MockCurrencyService) GetHistory(ctx context.Context, code string, start, end time.Time) ([]model.CurrencyRate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHistory", ctx, code, start, end)
+	ret0, _ := ret[0].([]model.CurrencyRate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHistory indicates an expected call of GetHistory.
+func (mr *MockCurrencyServiceMockRecorder) GetHistory(ctx, code, start, end interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHistory", reflect.TypeOf((*MockCurrencyService)(nil).GetHistory), ctx, code, start, end)
+}
+
+// GetRate mocks base method.
+func (m *MockCurrencyService) GetRate(ctx context.Context, code string, date time.Time) (decimal.Decimal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRate", ctx, code, date)
+	ret0, _ := ret[0].(decimal.Decimal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRate indicates an expected call of GetRate.
+func (mr *MockCurrencyServiceMockRecorder) GetRate(ctx, code, date interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRate", reflect.TypeOf((*MockCurrencyService)(nil).GetRate), ctx, code, date)
+}
+
 // UpdateCurrentCurrency mocks base method.
-func (m *MockCurrencyService) UpdateCurrentCurrency(c string) error {
+func (m *MockCurrencyService) UpdateCurrentCurrency(userId int64, c string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateCurrentCurrency", c)
+	ret := m.ctrl.Call(m, "UpdateCurrentCurrency", userId, c)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateCurrentCurrency indicates an expected call of UpdateCurrentCurrency.
-func (mr *MockCurrencyServiceMockRecorder) UpdateCurrentCurrency(c interface{}) *gomock.Call {
+func (mr *MockCurrencyServiceMockRecorder) UpdateCurrentCurrency(userId, c interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCurrentCurrency", reflect.TypeOf((*MockCurrencyService)(nil).UpdateCurrentCurrency), c)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCurrentCurrency", reflect.TypeOf((*MockCurrencyService)(nil).UpdateCurrentCurrency), userId, c)
+}
+
+// MockIncomeServiceI is a mock of IncomeServiceI interface.
+type MockIncomeServiceI struct {
+	ctrl     *gomock.Controller
+	recorder *MockIncomeServiceIMockRecorder
+}
+
+// MockIncomeServiceIMockRecorder is the mock recorder for MockIncomeServiceI.
+type MockIncomeServiceIMockRecorder struct {
+	mock *MockIncomeServiceI
+}
+
+// NewMockIncomeServiceI creates a new mock instance.
+func NewMockIncomeServiceI(ctrl *gomock.Controller) *MockIncomeServiceI {
+	mock := &MockIncomeServiceI{ctrl: ctrl}
+	mock.recorder = &MockIncomeServiceIMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIncomeServiceI) EXPECT() *MockIncomeServiceIMockRecorder {
+	return m.recorder
+}
+
+// GetStatsBy mocks base method.
+func (m *MockIncomeServiceI) GetStatsBy(arg0 context.Context, arg1 int64, arg2, arg3 time.Time) (map[string]decimal.Decimal, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStatsBy", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(map[string]decimal.Decimal)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetStatsBy indicates an expected call of GetStatsBy.
+func (mr *MockIncomeServiceIMockRecorder) GetStatsBy(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatsBy", reflect.TypeOf((*MockIncomeServiceI)(nil).GetStatsBy), arg0, arg1, arg2, arg3)
+}
+
+// SaveTx mocks base method.
+func (m *MockIncomeServiceI) SaveTx(arg0 context.Context, arg1 model.Income) (decimal.Decimal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveTx", arg0, arg1)
+	ret0, _ := ret[0].(decimal.Decimal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveTx indicates an expected call of SaveTx.
+func (mr *MockIncomeServiceIMockRecorder) SaveTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveTx", reflect.TypeOf((*MockIncomeServiceI)(nil).SaveTx), arg0, arg1)
 }
 
 // MockCategoryService is a mock of CategoryService interface.
@@ -179,6 +462,50 @@ func (m *MockCategoryService) EXPECT() *MockCategoryServiceMockRecorder {
 	return m.recorder
 }
 
+// Add mocks base method.
+func (m *MockCategoryService) Add(userId int64, name string) (model.Category, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Add", userId, name)
+	ret0, _ := ret[0].(model.Category)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Add indicates an expected call of Add.
+func (mr *MockCategoryServiceMockRecorder) Add(userId, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockCategoryService)(nil).Add), userId, name)
+}
+
+// Archive mocks base method.
+func (m *MockCategoryService) Archive(userId int64, ref string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Archive", userId, ref)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Archive indicates an expected call of Archive.
+func (mr *MockCategoryServiceMockRecorder) Archive(userId, ref interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Archive", reflect.TypeOf((*MockCategoryService)(nil).Archive), userId, ref)
+}
+
+// Find mocks base method.
+func (m *MockCategoryService) Find(ref string) (model.Category, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Find", ref)
+	ret0, _ := ret[0].(model.Category)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// Find indicates an expected call of Find.
+func (mr *MockCategoryServiceMockRecorder) Find(ref interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*MockCategoryService)(nil).Find), ref)
+}
+
 // GetAll mocks base method.
 func (m *MockCategoryService) GetAll() []model.Category {
 	m.ctrl.T.Helper()
@@ -193,6 +520,34 @@ func (mr *MockCategoryServiceMockRecorder) GetAll() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockCategoryService)(nil).GetAll))
 }
 
+// Merge mocks base method.
+func (m *MockCategoryService) Merge(userId int64, fromRef, toRef string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Merge", userId, fromRef, toRef)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Merge indicates an expected call of Merge.
+func (mr *MockCategoryServiceMockRecorder) Merge(userId, fromRef, toRef interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Merge", reflect.TypeOf((*MockCategoryService)(nil).Merge), userId, fromRef, toRef)
+}
+
+// Rename mocks base method.
+func (m *MockCategoryService) Rename(userId int64, ref, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rename", userId, ref, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Rename indicates an expected call of Rename.
+func (mr *MockCategoryServiceMockRecorder) Rename(userId, ref, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rename", reflect.TypeOf((*MockCategoryService)(nil).Rename), userId, ref, name)
+}
+
 // MockStateService is a mock of StateService interface.
 type MockStateService struct {
 	ctrl     *gomock.Controller
@@ -217,16 +572,471 @@ func (m *MockStateService) EXPECT() *MockStateServiceMockRecorder {
 }
 
 // GetBalance mocks base method.
-func (m *MockStateService) GetBalance() (decimal.Decimal, error) {
+func (m *MockStateService) GetBalance(userId int64) (decimal.Decimal, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetBalance")
+	ret := m.ctrl.Call(m, "GetBalance", userId)
 	ret0, _ := ret[0].(decimal.Decimal)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetBalance indicates an expected call of GetBalance.
-func (mr *MockStateServiceMockRecorder) GetBalance() *gomock.Call {
+func (mr *MockStateServiceMockRecorder) GetBalance(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalance", reflect.TypeOf((*MockStateService)(nil).GetBalance), userId)
+}
+
+// GetCategoryBudget mocks base method.
+func (m *MockStateService) GetCategoryBudget(userId int64, categoryId int) (model.CategoryBudget, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCategoryBudget", userId, categoryId)
+	ret0, _ := ret[0].(model.CategoryBudget)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetCategoryBudget indicates an expected call of GetCategoryBudget.
+func (mr *MockStateServiceMockRecorder) GetCategoryBudget(userId, categoryId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategoryBudget", reflect.TypeOf((*MockStateService)(nil).GetCategoryBudget), userId, categoryId)
+}
+
+// GetCategoryBudgets mocks base method.
+func (m *MockStateService) GetCategoryBudgets(userId int64) ([]model.CategoryBudget, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCategoryBudgets", userId)
+	ret0, _ := ret[0].([]model.CategoryBudget)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCategoryBudgets indicates an expected call of GetCategoryBudgets.
+func (mr *MockStateServiceMockRecorder) GetCategoryBudgets(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategoryBudgets", reflect.TypeOf((*MockStateService)(nil).GetCategoryBudgets), userId)
+}
+
+// GetClosedPeriods mocks base method.
+func (m *MockStateService) GetClosedPeriods(userId int64, count int) ([]model.ClosedPeriod, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetClosedPeriods", userId, count)
+	ret0, _ := ret[0].([]model.ClosedPeriod)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetClosedPeriods indicates an expected call of GetClosedPeriods.
+func (mr *MockStateServiceMockRecorder) GetClosedPeriods(userId, count interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClosedPeriods", reflect.TypeOf((*MockStateService)(nil).GetClosedPeriods), userId, count)
+}
+
+// GetState mocks base method.
+func (m *MockStateService) GetState(userId int64) (model.State, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetState", userId)
+	ret0, _ := ret[0].(model.State)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetState indicates an expected call of GetState.
+func (mr *MockStateServiceMockRecorder) GetState(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetState", reflect.TypeOf((*MockStateService)(nil).GetState), userId)
+}
+
+// SetCategoryLimit mocks base method.
+func (m *MockStateService) SetCategoryLimit(userId int64, categoryId int, v decimal.Decimal) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetCategoryLimit", userId, categoryId, v)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetCategoryLimit indicates an expected call of SetCategoryLimit.
+func (mr *MockStateServiceMockRecorder) SetCategoryLimit(userId, categoryId, v interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCategoryLimit", reflect.TypeOf((*MockStateService)(nil).SetCategoryLimit), userId, categoryId, v)
+}
+
+// SetPeriod mocks base method.
+func (m *MockStateService) SetPeriod(userId int64, period model.BudgetPeriod, day int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPeriod", userId, period, day)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPeriod indicates an expected call of SetPeriod.
+func (mr *MockStateServiceMockRecorder) SetPeriod(userId, period, day interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPeriod", reflect.TypeOf((*MockStateService)(nil).SetPeriod), userId, period, day)
+}
+
+// SetRollover mocks base method.
+func (m *MockStateService) SetRollover(userId int64, rollover bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRollover", userId, rollover)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRollover indicates an expected call of SetRollover.
+func (mr *MockStateServiceMockRecorder) SetRollover(userId, rollover interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRollover", reflect.TypeOf((*MockStateService)(nil).SetRollover), userId, rollover)
+}
+
+// MockRecurringServiceI is a mock of RecurringServiceI interface.
+type MockRecurringServiceI struct {
+	ctrl     *gomock.Controller
+	recorder *MockRecurringServiceIMockRecorder
+}
+
+// MockRecurringServiceIMockRecorder is the mock recorder for MockRecurringServiceI.
+type MockRecurringServiceIMockRecorder struct {
+	mock *MockRecurringServiceI
+}
+
+// NewMockRecurringServiceI creates a new mock instance.
+func NewMockRecurringServiceI(ctrl *gomock.Controller) *MockRecurringServiceI {
+	mock := &MockRecurringServiceI{ctrl: ctrl}
+	mock.recorder = &MockRecurringServiceIMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRecurringServiceI) EXPECT() *MockRecurringServiceIMockRecorder {
+	return m.recorder
+}
+
+// Add mocks base method.
+func (m *MockRecurringServiceI) Add(ctx context.Context, userId int64, categoryId int, value decimal.Decimal, schedule string) (model.RecurringSpending, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Add", ctx, userId, categoryId, value, schedule)
+	ret0, _ := ret[0].(model.RecurringSpending)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Add indicates an expected call of Add.
+func (mr *MockRecurringServiceIMockRecorder) Add(ctx, userId, categoryId, value, schedule interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockRecurringServiceI)(nil).Add), ctx, userId, categoryId, value, schedule)
+}
+
+// Delete mocks base method.
+func (m *MockRecurringServiceI) Delete(userId, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", userId, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockRecurringServiceIMockRecorder) Delete(userId, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockRecurringServiceI)(nil).Delete), userId, id)
+}
+
+// GetAll mocks base method.
+func (m *MockRecurringServiceI) GetAll(userId int64) ([]model.RecurringSpending, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll", userId)
+	ret0, _ := ret[0].([]model.RecurringSpending)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockRecurringServiceIMockRecorder) GetAll(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockRecurringServiceI)(nil).GetAll), userId)
+}
+
+// MockImportServiceI is a mock of ImportServiceI interface.
+type MockImportServiceI struct {
+	ctrl     *gomock.Controller
+	recorder *MockImportServiceIMockRecorder
+}
+
+// MockImportServiceIMockRecorder is the mock recorder for MockImportServiceI.
+type MockImportServiceIMockRecorder struct {
+	mock *MockImportServiceI
+}
+
+// NewMockImportServiceI creates a new mock instance.
+func NewMockImportServiceI(ctrl *gomock.Controller) *MockImportServiceI {
+	mock := &MockImportServiceI{ctrl: ctrl}
+	mock.recorder = &MockImportServiceIMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockImportServiceI) EXPECT() *MockImportServiceIMockRecorder {
+	return m.recorder
+}
+
+// Cancel mocks base method.
+func (m *MockImportServiceI) Cancel(userId int64) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Cancel", userId)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Cancel indicates an expected call of Cancel.
+func (mr *MockImportServiceIMockRecorder) Cancel(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cancel", reflect.TypeOf((*MockImportServiceI)(nil).Cancel), userId)
+}
+
+// Confirm mocks base method.
+func (m *MockImportServiceI) Confirm(ctx context.Context, userId int64, withGuesses bool) (int, decimal.Decimal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Confirm", ctx, userId, withGuesses)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(decimal.Decimal)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Confirm indicates an expected call of Confirm.
+func (mr *MockImportServiceIMockRecorder) Confirm(ctx, userId, withGuesses interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Confirm", reflect.TypeOf((*MockImportServiceI)(nil).Confirm), ctx, userId, withGuesses)
+}
+
+// Preview mocks base method.
+func (m *MockImportServiceI) Preview(ctx context.Context, userId int64, name string, data []byte) (model.ImportPreview, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Preview", ctx, userId, name, data)
+	ret0, _ := ret[0].(model.ImportPreview)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Preview indicates an expected call of Preview.
+func (mr *MockImportServiceIMockRecorder) Preview(ctx, userId, name, data interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Preview", reflect.TypeOf((*MockImportServiceI)(nil).Preview), ctx, userId, name, data)
+}
+
+// MockCategorizerI is a mock of CategorizerI interface.
+type MockCategorizerI struct {
+	ctrl     *gomock.Controller
+	recorder *MockCategorizerIMockRecorder
+}
+
+// MockCategorizerIMockRecorder is the mock recorder for MockCategorizerI.
+type MockCategorizerIMockRecorder struct {
+	mock *MockCategorizerI
+}
+
+// NewMockCategorizerI creates a new mock instance.
+func NewMockCategorizerI(ctrl *gomock.Controller) *MockCategorizerI {
+	mock := &MockCategorizerI{ctrl: ctrl}
+	mock.recorder = &MockCategorizerIMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCategorizerI) EXPECT() *MockCategorizerIMockRecorder {
+	return m.recorder
+}
+
+// AddRule mocks base method.
+func (m *MockCategorizerI) AddRule(userId int64, categoryId int, kind model.RuleKind, pattern string) (model.CategoryRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddRule", userId, categoryId, kind, pattern)
+	ret0, _ := ret[0].(model.CategoryRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddRule indicates an expected call of AddRule.
+func (mr *MockCategorizerIMockRecorder) AddRule(userId, categoryId, kind, pattern interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRule", reflect.TypeOf((*MockCategorizerI)(nil).AddRule), userId, categoryId, kind, pattern)
+}
+
+// DeleteRule mocks base method.
+func (m *MockCategorizerI) DeleteRule(userId, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRule", userId, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRule indicates an expected call of DeleteRule.
+func (mr *MockCategorizerIMockRecorder) DeleteRule(userId, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRule", reflect.TypeOf((*MockCategorizerI)(nil).DeleteRule), userId, id)
+}
+
+// GetRules mocks base method.
+func (m *MockCategorizerI) GetRules(userId int64) ([]model.CategoryRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRules", userId)
+	ret0, _ := ret[0].([]model.CategoryRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRules indicates an expected call of GetRules.
+func (mr *MockCategorizerIMockRecorder) GetRules(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRules", reflect.TypeOf((*MockCategorizerI)(nil).GetRules), userId)
+}
+
+// Guess mocks base method.
+func (m *MockCategorizerI) Guess(ctx context.Context, userId int64, text string) (model.CategoryGuess, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Guess", ctx, userId, text)
+	ret0, _ := ret[0].(model.CategoryGuess)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Guess indicates an expected call of Guess.
+func (mr *MockCategorizerIMockRecorder) Guess(ctx, userId, text interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Guess", reflect.TypeOf((*MockCategorizerI)(nil).Guess), ctx, userId, text)
+}
+
+// MockLanguageServiceI is a mock of LanguageServiceI interface.
+type MockLanguageServiceI struct {
+	ctrl     *gomock.Controller
+	recorder *MockLanguageServiceIMockRecorder
+}
+
+// MockLanguageServiceIMockRecorder is the mock recorder for MockLanguageServiceI.
+type MockLanguageServiceIMockRecorder struct {
+	mock *MockLanguageServiceI
+}
+
+// NewMockLanguageServiceI creates a new mock instance.
+func NewMockLanguageServiceI(ctrl *gomock.Controller) *MockLanguageServiceI {
+	mock := &MockLanguageServiceI{ctrl: ctrl}
+	mock.recorder = &MockLanguageServiceIMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLanguageServiceI) EXPECT() *MockLanguageServiceIMockRecorder {
+	return m.recorder
+}
+
+// GetLanguage mocks base method.
+func (m *MockLanguageServiceI) GetLanguage(userId int64) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLanguage", userId)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLanguage indicates an expected call of GetLanguage.
+func (mr *MockLanguageServiceIMockRecorder) GetLanguage(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLanguage", reflect.TypeOf((*MockLanguageServiceI)(nil).GetLanguage), userId)
+}
+
+// ResolveLanguage mocks base method.
+func (m *MockLanguageServiceI) ResolveLanguage(userId int64, detected string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveLanguage", userId, detected)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResolveLanguage indicates an expected call of ResolveLanguage.
+func (mr *MockLanguageServiceIMockRecorder) ResolveLanguage(userId, detected interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveLanguage", reflect.TypeOf((*MockLanguageServiceI)(nil).ResolveLanguage), userId, detected)
+}
+
+// SetLanguage mocks base method.
+func (m *MockLanguageServiceI) SetLanguage(userId int64, lang string, fixed bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetLanguage", userId, lang, fixed)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetLanguage indicates an expected call of SetLanguage.
+func (mr *MockLanguageServiceIMockRecorder) SetLanguage(userId, lang, fixed interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLanguage", reflect.TypeOf((*MockLanguageServiceI)(nil).SetLanguage), userId, lang, fixed)
+}
+
+// MockDialogServiceI is a mock of DialogServiceI interface.
+type MockDialogServiceI struct {
+	ctrl     *gomock.Controller
+	recorder *MockDialogServiceIMockRecorder
+}
+
+// MockDialogServiceIMockRecorder is the mock recorder for MockDialogServiceI.
+type MockDialogServiceIMockRecorder struct {
+	mock *MockDialogServiceI
+}
+
+// NewMockDialogServiceI creates a new mock instance.
+func NewMockDialogServiceI(ctrl *gomock.Controller) *MockDialogServiceI {
+	mock := &MockDialogServiceI{ctrl: ctrl}
+	mock.recorder = &MockDialogServiceIMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDialogServiceI) EXPECT() *MockDialogServiceIMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockDialogServiceI) Delete(ctx context.Context, userId int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockDialogServiceIMockRecorder) Delete(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockDialogServiceI)(nil).Delete), ctx, userId)
+}
+
+// Get mocks base method.
+func (m *MockDialogServiceI) Get(ctx context.Context, userId int64) (model.Dialog, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, userId)
+	ret0, _ := ret[0].(model.Dialog)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockDialogServiceIMockRecorder) Get(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockDialogServiceI)(nil).Get), ctx, userId)
+}
+
+// Save mocks base method.
+func (m *MockDialogServiceI) Save(ctx context.Context, dialog model.Dialog) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, dialog)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockDialogServiceIMockRecorder) Save(ctx, dialog interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalance", reflect.TypeOf((*MockStateService)(nil).GetBalance))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockDialogServiceI)(nil).Save), ctx, dialog)
 }
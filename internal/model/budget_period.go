@@ -0,0 +1,60 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var ErrWrongPeriod = errors.New("wrong budget period")
+
+type BudgetPeriod string
+
+const (
+	WeekPeriod     BudgetPeriod = "week"
+	TwoWeeksPeriod BudgetPeriod = "2weeks"
+	MonthPeriod    BudgetPeriod = "month"
+)
+
+func ParseBudgetPeriod(s string) (BudgetPeriod, error) {
+	switch p := BudgetPeriod(s); p {
+	case WeekPeriod, TwoWeeksPeriod, MonthPeriod:
+		return p, nil
+	default:
+		return "", ErrWrongPeriod
+	}
+}
+
+// Next returns the end of the period that starts at from.
+// Monthly periods end on the given day of month, or on the last day for shorter months.
+func (p BudgetPeriod) Next(from time.Time, day int) time.Time {
+	from = from.Truncate(24 * time.Hour)
+	switch p {
+	case WeekPeriod:
+		return from.AddDate(0, 0, 7)
+	case TwoWeeksPeriod:
+		return from.AddDate(0, 0, 14)
+	default:
+		next := dayOfMonth(from.Year(), from.Month(), day, from.Location())
+		if !next.After(from) {
+			next = dayOfMonth(from.Year(), from.Month()+1, day, from.Location())
+		}
+		return next
+	}
+}
+
+func dayOfMonth(year int, month time.Month, day int, loc *time.Location) time.Time {
+	lastDay := time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+type ClosedPeriod struct {
+	StartedIn     time.Time       `db:"started_in"`
+	ExpiresIn     time.Time       `db:"expires_in"`
+	BudgetValue   decimal.Decimal `db:"budget_value"`
+	BudgetBalance decimal.Decimal `db:"budget_balance"`
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(d string) time.Time {
+	t, _ := time.Parse("02-01-2006", d)
+	return t
+}
+
+func TestBudgetPeriod_Next(t *testing.T) {
+	tests := []struct {
+		name   string
+		period BudgetPeriod
+		from   time.Time
+		day    int
+		next   time.Time
+	}{
+		{name: "week", period: WeekPeriod, from: date("01-03-2026"), next: date("08-03-2026")},
+		{name: "two weeks", period: TwoWeeksPeriod, from: date("25-12-2025"), next: date("08-01-2026")},
+		{name: "month later this month", period: MonthPeriod, from: date("10-03-2026"), day: 25, next: date("25-03-2026")},
+		{name: "month on the same day", period: MonthPeriod, from: date("25-03-2026"), day: 25, next: date("25-04-2026")},
+		{name: "month next year", period: MonthPeriod, from: date("26-12-2025"), day: 25, next: date("25-01-2026")},
+		{name: "month shorter than day", period: MonthPeriod, from: date("31-01-2026"), day: 31, next: date("28-02-2026")},
+		{name: "month after shorter month", period: MonthPeriod, from: date("28-02-2026"), day: 31, next: date("31-03-2026")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.next, tt.period.Next(tt.from, tt.day))
+		})
+	}
+}
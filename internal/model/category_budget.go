@@ -0,0 +1,11 @@
+package model
+
+import "github.com/shopspring/decimal"
+
+// CategoryBudget is a spending limit of the category and the amount spent in the current budget period.
+type CategoryBudget struct {
+	CategoryId   int             `db:"category_id"`
+	CategoryName string          `db:"name"`
+	Limit        decimal.Decimal `db:"limit_value"`
+	Spent        decimal.Decimal `db:"spent"`
+}
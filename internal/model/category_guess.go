@@ -0,0 +1,58 @@
+package model
+
+import (
+	"strings"
+	"unicode"
+)
+
+// GuessSource tells where the guessed category comes from.
+type GuessSource string
+
+const (
+	RuleGuess    GuessSource = "rule"
+	HistoryGuess GuessSource = "history"
+)
+
+// CategoryGuess is the category suggested for a note or a merchant.
+type CategoryGuess struct {
+	CategoryId   int
+	CategoryName string
+	// вероятность категории, у правил всегда 1
+	Confidence float64
+	Source     GuessSource
+}
+
+// CategoryNotes is how many notes and words of them the category has in the history of the user.
+type CategoryNotes struct {
+	CategoryId   int    `db:"category_id"`
+	CategoryName string `db:"category_name"`
+	Notes        int    `db:"notes"`
+	Words        int    `db:"words"`
+}
+
+// WordCount is how many times the word was met in notes of the category.
+type WordCount struct {
+	CategoryId int    `db:"category_id"`
+	Word       string `db:"word"`
+	Count      int    `db:"count"`
+}
+
+// длина колонки word в category_words
+const maxWordLen = 100
+
+// NoteWords splits the note into lower case words for the category suggester, numbers and single letters are dropped.
+// The migration 000011 splits notes of the history the same way.
+func NoteWords(note string) []string {
+	fields := strings.FieldsFunc(strings.ReplaceAll(strings.ToLower(note), "ё", "е"), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	r := make([]string, 0, len(fields))
+	for _, f := range fields {
+		runes := []rune(f)
+		if len(runes) < 2 || len(runes) > maxWordLen || strings.IndexFunc(f, unicode.IsLetter) < 0 {
+			continue
+		}
+		r = append(r, f)
+	}
+	return r
+}
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/i18n"
+)
+
+var (
+	ErrCategoryRuleNotFound = errors.New("category rule not found")
+	ErrWrongRuleKind        = errors.New("wrong rule kind, use exact, substring or regex")
+)
+
+// RuleKind is how the pattern of a rule is compared with a note or a merchant.
+type RuleKind string
+
+const (
+	ExactRule     RuleKind = "exact"
+	SubstringRule RuleKind = "substring"
+	RegexRule     RuleKind = "regex"
+)
+
+func ParseRuleKind(s string) (RuleKind, error) {
+	switch k := RuleKind(strings.ToLower(s)); k {
+	case ExactRule, SubstringRule, RegexRule:
+		return k, nil
+	}
+	return "", ErrWrongRuleKind
+}
+
+// CategoryRule assigns the category to spendings whose note or merchant matches the pattern.
+type CategoryRule struct {
+	Id           int64    `db:"id"`
+	UserId       int64    `db:"user_id"`
+	Kind         RuleKind `db:"kind"`
+	Pattern      string   `db:"pattern"`
+	CategoryId   int      `db:"category_id"`
+	CategoryName string   `db:"category_name"`
+}
+
+// Validate checks that the pattern of a regex rule compiles.
+func (r CategoryRule) Validate() error {
+	if _, err := ParseRuleKind(string(r.Kind)); err != nil {
+		return err
+	}
+	if r.Kind == RegexRule {
+		if _, err := regexp.Compile("(?i)" + r.Pattern); err != nil {
+			return i18n.Errorf("wrong regex: %v", err)
+		}
+	}
+	return nil
+}
+
+// Matches compares the text with the pattern ignoring case.
+func (r CategoryRule) Matches(text string) bool {
+	switch r.Kind {
+	case ExactRule:
+		return strings.EqualFold(strings.TrimSpace(text), strings.TrimSpace(r.Pattern))
+	case SubstringRule:
+		return strings.Contains(strings.ToLower(text), strings.ToLower(r.Pattern))
+	case RegexRule:
+		re, err := regexp.Compile("(?i)" + r.Pattern)
+		return err == nil && re.MatchString(text)
+	}
+	return false
+}
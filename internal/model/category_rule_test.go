@@ -0,0 +1,34 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCategoryRule_Matches(t *testing.T) {
+	tests := []struct {
+		rule CategoryRule
+		text string
+		want bool
+	}{
+		{rule: CategoryRule{Kind: ExactRule, Pattern: "Пятёрочка"}, text: "пятёрочка", want: true},
+		{rule: CategoryRule{Kind: ExactRule, Pattern: "Пятёрочка"}, text: "Пятёрочка 123", want: false},
+		{rule: CategoryRule{Kind: SubstringRule, Pattern: "yandex"}, text: "YANDEX.TAXI", want: true},
+		{rule: CategoryRule{Kind: RegexRule, Pattern: `^ozon\b`}, text: "OZON ru", want: true},
+		{rule: CategoryRule{Kind: RegexRule, Pattern: `^ozon\b`}, text: "my ozon", want: false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.rule.Matches(tt.text), "%v %v", tt.rule.Pattern, tt.text)
+	}
+}
+
+func TestCategoryRule_Validate(t *testing.T) {
+	assert.NoError(t, CategoryRule{Kind: RegexRule, Pattern: "taxi|такси"}.Validate())
+	assert.Error(t, CategoryRule{Kind: RegexRule, Pattern: "taxi("}.Validate())
+	assert.ErrorIs(t, CategoryRule{Kind: "like", Pattern: "taxi"}.Validate(), ErrWrongRuleKind)
+}
+
+func TestNoteWords(t *testing.T) {
+	assert.Equal(t, []string{"пятерочка", "на", "ленина", "x5"}, NoteWords("Пятёрочка на Ленина, 12 X5 a"))
+}
@@ -2,17 +2,33 @@ package model
 
 import (
 	"errors"
+	"time"
 
 	"github.com/shopspring/decimal"
 )
 
+// BaseCurrency is the currency all sums are stored in, rates of other currencies are loaded to it.
+// It is set from the config at startup and can't be changed for a database which already has sums.
+var BaseCurrency = "rub"
+
 var ErrWrongCurrency = errors.New("wrong currency type")
+var ErrStaleRate = errors.New("the rate is outdated")
+var ErrNoRate = errors.New("rates of the currency are not loaded yet")
 
 type Currency struct {
-	Code  string          `db:"code"`
-	Ratio decimal.Decimal `db:"ratio"`
+	Code  string          `json:"code" db:"code"`
+	Ratio decimal.Decimal `json:"ratio" db:"ratio"`
+	// когда загружен текущий курс, нулевое время - курса ещё нет
+	UpdatedAt time.Time `json:"updatedAt,omitempty" db:"updated_at"`
 }
 
 func NewCurrency(code string, ratio decimal.Decimal) *Currency {
-	return &Currency{code, ratio}
+	return &Currency{Code: code, Ratio: ratio}
+}
+
+// CurrencyRate is the rate of the currency to the base currency on the date.
+type CurrencyRate struct {
+	Code  string          `db:"code"`
+	Date  time.Time       `db:"date"`
+	Ratio decimal.Decimal `db:"ratio"`
 }
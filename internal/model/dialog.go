@@ -0,0 +1,33 @@
+package model
+
+// шаги пошагового добавления траты
+const (
+	StepSum      = "sum"
+	StepCategory = "category"
+	StepDate     = "date"
+)
+
+// Dialog is a spending being added step by step: the bot asks for the missing arguments one at a time.
+type Dialog struct {
+	UserId   int64    `json:"user_id"`
+	Spending Spending `json:"spending"`
+	// дату спрашиваем только в /add без аргументов, в остальных случаях она известна
+	AskDate bool `json:"ask_date"`
+	// категория выбрана: по id её не понять, у food id 0
+	HasCategory bool `json:"has_category"`
+	// категория угадана по заметке и ждёт подтверждения
+	Guessed bool `json:"guessed"`
+}
+
+// Step returns the argument the dialog waits for, empty when the spending is complete.
+func (d Dialog) Step() string {
+	switch {
+	case !d.Spending.Value.IsPositive():
+		return StepSum
+	case !d.HasCategory || d.Guessed:
+		return StepCategory
+	case d.AskDate:
+		return StepDate
+	}
+	return ""
+}
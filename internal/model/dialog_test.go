@@ -0,0 +1,28 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDialog_Step(t *testing.T) {
+	sum := decimal.NewFromInt(350)
+	tests := []struct {
+		name   string
+		dialog Dialog
+		want   string
+	}{
+		{name: "no sum", dialog: Dialog{AskDate: true}, want: StepSum},
+		{name: "no category", dialog: Dialog{Spending: Spending{Value: sum}}, want: StepCategory},
+		{name: "guessed category", dialog: Dialog{Spending: Spending{Value: sum, CategoryId: 1}, HasCategory: true, Guessed: true}, want: StepCategory},
+		{name: "category with id 0", dialog: Dialog{Spending: Spending{Value: sum}, HasCategory: true, AskDate: true}, want: StepDate},
+		{name: "complete", dialog: Dialog{Spending: Spending{Value: sum}, HasCategory: true}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.dialog.Step())
+		})
+	}
+}
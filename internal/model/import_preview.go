@@ -0,0 +1,30 @@
+package model
+
+// ImportItem is a spending of a bank statement ready to be saved.
+type ImportItem struct {
+	Spending     Spending
+	CategoryName string
+	// категория угадана по истории, а не по правилу
+	Guessed bool
+}
+
+// ImportPreview is what will be saved from a bank statement after the confirmation of the user.
+type ImportPreview struct {
+	Items []ImportItem
+	// уже внесённые траты с той же датой и суммой
+	Duplicates int
+	// траты без уверенно угаданной категории сохраняются, только если пользователь согласился с догадками,
+	// в CategoryName и Spending.CategoryId - неуверенная догадка, если есть
+	Uncategorized []ImportItem
+}
+
+// Guesses returns the uncategorized spendings with an uncertain guess of the category.
+func (p ImportPreview) Guesses() []ImportItem {
+	r := make([]ImportItem, 0, len(p.Uncategorized))
+	for i := 0; i < len(p.Uncategorized); i++ {
+		if p.Uncategorized[i].CategoryName != "" {
+			r = append(r, p.Uncategorized[i])
+		}
+	}
+	return r
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type Income struct {
+	Id         int64           `db:"id"`
+	UserId     int64           `db:"user_id"`
+	Value      decimal.Decimal `db:"value"`
+	CategoryId int             `db:"category_id"`
+	Date       time.Time       `db:"date"`
+}
+
+func NewIncome(userId int64, val decimal.Decimal, categoryId int, dt time.Time) Income {
+	return Income{UserId: userId, Value: val, CategoryId: categoryId, Date: dt}
+}
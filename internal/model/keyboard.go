@@ -0,0 +1,13 @@
+package model
+
+// MaxButtonData is the limit of telegram for the data of a button.
+const MaxButtonData = 64
+
+// Button is an inline button under a message, Data is handled as a command when the button is pressed.
+type Button struct {
+	Text string
+	Data string
+}
+
+// Keyboard is rows of inline buttons.
+type Keyboard [][]Button
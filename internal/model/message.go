@@ -3,4 +3,11 @@ package model
 type Message struct {
 	Text   string
 	UserID int64
+	// язык интерфейса telegram пользователя, например "ru" или "en-US"
+	LanguageCode string
+	// присланный файл, например банковская выписка
+	Document     []byte
+	DocumentName string
+	// присланное фото, например чека с QR кодом
+	Photo []byte
 }
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrNotReceipt    = errors.New("it is not a qr code of a fiscal receipt")
+	ErrRefundReceipt = errors.New("it is a refund receipt, spendings are added only from purchase receipts")
+)
+
+// Receipt is the fiscal data from the QR code printed on a receipt: t=...&s=...&fn=...&i=...&fp=...&n=1.
+type Receipt struct {
+	// время покупки, как напечатано в чеке
+	Time time.Time
+	Sum  decimal.Decimal
+	// номер фискального накопителя, номер документа и фискальный признак
+	FN string
+	I  string
+	FP string
+}
+
+var receiptTimeLayouts = []string{"20060102T150405", "20060102T1504"}
+
+// ParseReceipt parses the text of the receipt QR code.
+func ParseReceipt(text string) (Receipt, error) {
+	values, err := url.ParseQuery(strings.TrimSpace(text))
+	if err != nil {
+		return Receipt{}, ErrNotReceipt
+	}
+	for _, key := range []string{"t", "s", "fn", "i", "fp"} {
+		if values.Get(key) == "" {
+			return Receipt{}, ErrNotReceipt
+		}
+	}
+	// 1 - приход, 2 - возврат прихода, 3 и 4 - расход и его возврат
+	if n := values.Get("n"); n != "" && n != "1" {
+		return Receipt{}, ErrRefundReceipt
+	}
+
+	r := Receipt{FN: values.Get("fn"), I: values.Get("i"), FP: values.Get("fp")}
+	for _, layout := range receiptTimeLayouts {
+		if r.Time, err = time.Parse(layout, values.Get("t")); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return Receipt{}, ErrNotReceipt
+	}
+	if r.Sum, err = decimal.NewFromString(values.Get("s")); err != nil || !r.Sum.IsPositive() {
+		return Receipt{}, ErrNotReceipt
+	}
+	return r, nil
+}
+
+// Date is the day of the purchase in the form dates of spendings are stored.
+func (r Receipt) Date() time.Time {
+	return time.Date(r.Time.Year(), r.Time.Month(), r.Time.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReceipt(t *testing.T) {
+	r, err := ParseReceipt("t=20260301T1230&s=350.00&fn=9960440300123456&i=12345&fp=1234567890&n=1")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 3, 1, 12, 30, 0, 0, time.UTC), r.Time)
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), r.Date())
+	assert.Equal(t, "350", r.Sum.String())
+	assert.Equal(t, "9960440300123456", r.FN)
+
+	r, err = ParseReceipt("t=20251231T235959&s=1234.5&fn=1&i=2&fp=3")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 12, 31, 23, 59, 59, 0, time.UTC), r.Time)
+
+	_, err = ParseReceipt("t=20260301T1230&s=350.00&fn=1&i=2&fp=3&n=2")
+	assert.ErrorIs(t, err, ErrRefundReceipt)
+	for _, text := range []string{"https://example.com", "t=2026&s=350&fn=1&i=2&fp=3", "t=20260301T1230&s=-1&fn=1&i=2&fp=3", "t=20260301T1230&s=350"} {
+		_, err = ParseReceipt(text)
+		assert.ErrorIs(t, err, ErrNotReceipt, text)
+	}
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var ErrRecurringSpendingNotFound = errors.New("recurring spending not found")
+
+type RecurringSpending struct {
+	Id           int64           `db:"id"`
+	UserId       int64           `db:"user_id"`
+	Value        decimal.Decimal `db:"value"`
+	CurrencyCode string          `db:"currency_code"`
+	CategoryId   int             `db:"category_id"`
+	CategoryName string          `db:"category_name"`
+	Schedule     string          `db:"schedule"`
+	NextRunAt    time.Time       `db:"next_run_at"`
+}
+
+// RecurringRun moves the next run of the recurring spending from Prev to Next together with saving the spending of the run.
+type RecurringRun struct {
+	Id   int64
+	Prev time.Time
+	Next time.Time
+}
@@ -2,21 +2,82 @@ package model
 
 import (
 	"encoding/json"
-	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/time_util"
+	"errors"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 )
 
+var ErrWrongReportMode = errors.New("wrong report mode")
+
+// ReportMode is a kind of report, the default one is the sum per category.
+type ReportMode string
+
+const (
+	CategoryReport ReportMode = ""
+	DayReport      ReportMode = "day"
+	WeekReport     ReportMode = "week"
+	MonthReport    ReportMode = "month"
+	CompareReport  ReportMode = "compare"
+	TopReport      ReportMode = "top"
+)
+
+// IsBreakdown tells whether the report is grouped by days, weeks or months.
+func (m ReportMode) IsBreakdown() bool {
+	return m == DayReport || m == WeekReport || m == MonthReport
+}
+
+// ReportBucket is the sum per category for the day, week or month starting at Start.
+type ReportBucket struct {
+	Start time.Time                  `json:"start"`
+	Data  map[string]decimal.Decimal `json:"data"`
+}
+
+// ReportEntry is a single spending in the top of a report.
+type ReportEntry struct {
+	Date     time.Time       `json:"date" db:"date"`
+	Category string          `json:"category" db:"category"`
+	Value    decimal.Decimal `json:"value" db:"value"`
+	Note     string          `json:"note,omitempty" db:"note"`
+	// сумма и валюта, в которых трата внесена
+	OriginalValue decimal.Decimal `json:"originalValue" db:"original_value"`
+	CurrencyCode  string          `json:"currencyCode,omitempty" db:"currency_code"`
+	// курс валюты выгрузки на дату траты
+	Rate decimal.Decimal `json:"rate" db:"rate"`
+}
+
 type Report struct {
 	UserId int64                      `json:"userId"`
 	Start  time.Time                  `json:"start"`
 	End    time.Time                  `json:"end"`
 	Data   map[string]decimal.Decimal `json:"data,omitempty"`
+	Income map[string]decimal.Decimal `json:"income,omitempty"`
+	Mode   ReportMode                 `json:"mode,omitempty"`
+	// валюта сумм отчёта
+	CurrencyCode string `json:"currencyCode,omitempty"`
+	// разбивка по дням, неделям или месяцам
+	Buckets []ReportBucket `json:"buckets,omitempty"`
+	// траты по категориям за прошлый период для сравнения
+	Previous      map[string]decimal.Decimal `json:"previous,omitempty"`
+	PreviousStart time.Time                  `json:"previousStart,omitempty"`
+	PreviousEnd   time.Time                  `json:"previousEnd,omitempty"`
+	Top           []ReportEntry              `json:"top,omitempty"`
+	// к отчёту нужны графики, для них в Buckets лежит разбивка по дням
+	Chart bool `json:"chart,omitempty"`
+	// выгрузка трат вместо отчёта
+	Document     []byte `json:"document,omitempty"`
+	DocumentName string `json:"documentName,omitempty"`
+	// id асинхронного запроса, по которому построен отчёт
+	RequestId string `json:"requestId,omitempty"`
+	// сервис отчётов не смог построить отчёт
+	Error string `json:"error,omitempty"`
+	// заголовки трейса запроса, ответ пользователю продолжает его
+	Trace map[string]string `json:"-"`
 }
 
-func NewReport(userId int64, start time.Time, end time.Time, data map[string]decimal.Decimal) *Report {
-	return &Report{UserId: userId, Start: start, End: end, Data: data}
+func NewReport(userId int64, start time.Time, end time.Time, data map[string]decimal.Decimal, income map[string]decimal.Decimal) *Report {
+	return &Report{UserId: userId, Start: start, End: end, Data: data, Income: income}
 }
 func FromJSON(data string) ([]Report, error) {
 	result := make([]Report, 0)
@@ -35,12 +96,39 @@ func ToJSON(arr []Report) (string, error) {
 	return string(marshal), nil
 }
 
+// ReportRequestVersion is the version of the format of ReportRequest sent to the report service.
+const ReportRequestVersion = 1
+
+// ReportRequest asks the report service to build the report, it is sent as api.ReportRequest through kafka.
 type ReportRequest struct {
-	UserId int64  `json:"userId"`
-	Start  string `json:"start"`
-	End    string `json:"end"`
+	Version uint32
+	// id запроса, по нему связываются логи бота и сервиса отчётов
+	RequestId string
+	UserId    int64
+	Start     time.Time
+	End       time.Time
+	Mode      ReportMode
+	// количество трат для TopReport
+	Top   int
+	Chart bool
+	// формат выгрузки трат, пустой для отчёта
+	Export string
+	// валюта пользователя: в ней считаются суммы отчёта и вторая колонка выгрузки
+	Currency *Currency
 }
 
 func NewReportRequest(userId int64, start, end time.Time) *ReportRequest {
-	return &ReportRequest{UserId: userId, Start: time_util.TimeToDate(start), End: time_util.TimeToDate(end)}
+	return &ReportRequest{Version: ReportRequestVersion, RequestId: uuid.NewString(), UserId: userId, Start: start, End: end}
+}
+
+// PreviousRange returns the period of the same length right before start.
+// Whole calendar months are compared with the same number of previous months.
+func PreviousRange(start, end time.Time) (time.Time, time.Time) {
+	prevEnd := start.AddDate(0, 0, -1)
+	if start.Day() == 1 && end.AddDate(0, 0, 1).Day() == 1 {
+		months := (end.Year()-start.Year())*12 + int(end.Month()-start.Month()) + 1
+		return start.AddDate(0, -months, 0), prevEnd
+	}
+	days := int(end.Sub(start).Hours()/24) + 1
+	return start.AddDate(0, 0, -days), prevEnd
 }
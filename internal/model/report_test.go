@@ -0,0 +1,31 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreviousRange(t *testing.T) {
+	day := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name               string
+		start, end         time.Time
+		prevStart, prevEnd time.Time
+	}{
+		{name: "month", start: day(2026, 3, 1), end: day(2026, 3, 31), prevStart: day(2026, 2, 1), prevEnd: day(2026, 2, 28)},
+		{name: "quarter", start: day(2026, 4, 1), end: day(2026, 6, 30), prevStart: day(2026, 1, 1), prevEnd: day(2026, 3, 31)},
+		{name: "week", start: day(2026, 3, 9), end: day(2026, 3, 15), prevStart: day(2026, 3, 2), prevEnd: day(2026, 3, 8)},
+		{name: "single day", start: day(2026, 3, 1), end: day(2026, 3, 1), prevStart: day(2026, 2, 28), prevEnd: day(2026, 2, 28)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prevStart, prevEnd := PreviousRange(tt.start, tt.end)
+			assert.Equal(t, tt.prevStart, prevStart)
+			assert.Equal(t, tt.prevEnd, prevEnd)
+		})
+	}
+}
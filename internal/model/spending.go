@@ -1,17 +1,54 @@
 package model
 
 import (
+	"errors"
+	"strings"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/shopspring/decimal"
 )
 
+var ErrSpendingNotFound = errors.New("spending not found")
+
 type Spending struct {
+	Id         int64           `db:"id"`
+	UserId     int64           `db:"user_id"`
 	Value      decimal.Decimal `db:"value"`
 	CategoryId int             `db:"category_id"`
 	Date       time.Time       `db:"date"`
+	Note       string          `db:"note"`
+	Tags       pq.StringArray  `db:"tags"`
+	// валюта, в которой указана сумма, если не задана - текущая валюта пользователя
+	CurrencyCode string `db:"currency_code"`
+	// сумма в валюте траты и курс этой валюты к базовой на дату траты, Value хранится в базовой валюте
+	OriginalValue decimal.Decimal `db:"original_value"`
+	Rate          decimal.Decimal `db:"rate"`
+}
+
+func NewSpending(userId int64, val decimal.Decimal, categoryId int, dt time.Time) Spending {
+	return Spending{UserId: userId, Value: val, CategoryId: categoryId, Date: dt}
+}
+
+// ParseTag returns the tag without '#' if the word is a tag.
+func ParseTag(word string) (string, bool) {
+	if len(word) < 2 || !strings.HasPrefix(word, "#") {
+		return "", false
+	}
+	return strings.ToLower(word[1:]), true
 }
 
-func NewSpending(val decimal.Decimal, categoryId int, dt time.Time) Spending {
-	return Spending{Value: val, CategoryId: categoryId, Date: dt}
+// SetNote splits the words into the note text and the tags of the spending.
+func (s *Spending) SetNote(words []string) {
+	note := make([]string, 0, len(words))
+	var tags pq.StringArray
+	for i := 0; i < len(words); i++ {
+		if tag, ok := ParseTag(words[i]); ok {
+			tags = append(tags, tag)
+		} else if words[i] != "" {
+			note = append(note, words[i])
+		}
+	}
+	s.Note = strings.Join(note, " ")
+	s.Tags = tags
 }
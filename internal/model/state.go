@@ -7,8 +7,15 @@ import (
 )
 
 type State struct {
+	UserId              int64           `db:"user_id"`
 	CurrentCurrencyCode string          `db:"current_currency_code"`
 	BudgetValue         decimal.Decimal `db:"budget_value"`
 	BudgetBalance       decimal.Decimal `db:"budget_balance"`
 	BudgetExpiresIn     time.Time       `db:"budget_expires_in"`
+	BudgetStartedIn     time.Time       `db:"budget_started_in"`
+	BudgetPeriod        BudgetPeriod    `db:"budget_period"`
+	BudgetPeriodDay     int             `db:"budget_period_day"`
+	BudgetRollover      bool            `db:"budget_rollover"`
+	Language            string          `db:"language"`
+	LanguageFixed       bool            `db:"language_fixed"`
 }
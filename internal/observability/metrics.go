@@ -38,6 +38,15 @@ var (
 		},
 		[]string{"code"},
 	)
+	// сколько секунд назад загружен текущий курс валюты
+	RateAge = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "tgbot",
+			Subsystem: "currency",
+			Name:      "rate_age_seconds",
+		},
+		[]string{"code"},
+	)
 )
 
 func LogRequest(f func() error) {
@@ -1,6 +1,9 @@
 package observability
 
 import (
+	"context"
+
+	"github.com/opentracing/opentracing-go"
 	"github.com/uber/jaeger-client-go/config"
 	"go.uber.org/zap"
 )
@@ -18,3 +21,26 @@ func InitTracing(logger *zap.Logger, serviceName string) {
 		logger.Fatal("Cannot init tracing", zap.Error(err))
 	}
 }
+
+// Inject returns the headers passing the span of the context to another service, they are empty if there is no span.
+func Inject(ctx context.Context) map[string]string {
+	headers := map[string]string{}
+	span := opentracing.SpanFromContext(ctx)
+	if span == nil {
+		return headers
+	}
+	// без заголовков сервис просто начнёт новый трейс, поэтому ошибку не возвращаем
+	_ = opentracing.GlobalTracer().Inject(span.Context(), opentracing.TextMap, opentracing.TextMapCarrier(headers))
+	return headers
+}
+
+// StartSpanFromHeaders starts the span continuing the trace passed in the headers by Inject,
+// a new trace is started if there is none.
+func StartSpanFromHeaders(ctx context.Context, headers map[string]string, operationName string) (opentracing.Span, context.Context) {
+	var opts []opentracing.StartSpanOption
+	if parent, err := opentracing.GlobalTracer().Extract(opentracing.TextMap, opentracing.TextMapCarrier(headers)); err == nil {
+		opts = append(opts, opentracing.ChildOf(parent))
+	}
+	span := opentracing.GlobalTracer().StartSpan(operationName, opts...)
+	return span, opentracing.ContextWithSpan(ctx, span)
+}
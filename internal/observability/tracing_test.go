@@ -0,0 +1,38 @@
+package observability
+
+import (
+	"context"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_StartSpanFromHeaders_shouldContinueInjectedTrace(t *testing.T) {
+	tracer := mocktracer.New()
+	opentracing.SetGlobalTracer(tracer)
+	defer opentracing.SetGlobalTracer(opentracing.NoopTracer{})
+
+	parent, ctx := opentracing.StartSpanFromContext(context.Background(), "msg_handler: handle cmd `/report`")
+	headers := Inject(ctx)
+	span, _ := StartSpanFromHeaders(context.Background(), headers, "report_service: build report")
+	span.Finish()
+	parent.Finish()
+
+	child := span.(*mocktracer.MockSpan)
+	assert.Equal(t, parent.(*mocktracer.MockSpan).SpanContext.TraceID, child.SpanContext.TraceID)
+	assert.Equal(t, parent.(*mocktracer.MockSpan).SpanContext.SpanID, child.ParentID)
+}
+
+func Test_StartSpanFromHeaders_shouldStartNewTraceWithoutHeaders(t *testing.T) {
+	tracer := mocktracer.New()
+	opentracing.SetGlobalTracer(tracer)
+	defer opentracing.SetGlobalTracer(opentracing.NoopTracer{})
+
+	span, ctx := StartSpanFromHeaders(context.Background(), Inject(context.Background()), "grpc: report result")
+	span.Finish()
+
+	assert.Equal(t, 0, span.(*mocktracer.MockSpan).ParentID)
+	assert.Equal(t, span, opentracing.SpanFromContext(ctx))
+}
@@ -0,0 +1,158 @@
+package qr
+
+import (
+	"errors"
+	"strings"
+)
+
+var errWrongData = errors.New("wrong data in qr code")
+
+const alphanumeric = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+type bitReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *bitReader) available() int {
+	return len(r.data)*8 - r.pos
+}
+
+func (r *bitReader) read(n int) int {
+	v := 0
+	for i := 0; i < n; i++ {
+		bit := r.data[r.pos/8] >> (7 - r.pos%8) & 1
+		v = v<<1 | int(bit)
+		r.pos++
+	}
+	return v
+}
+
+// parseSegments decodes the data codewords, numeric, alphanumeric and byte segments are supported.
+func parseSegments(data []byte, version int) (string, error) {
+	r := &bitReader{data: data}
+	var sb strings.Builder
+	for r.available() >= 4 {
+		mode := r.read(4)
+		switch mode {
+		case 0:
+			return sb.String(), nil
+		case 1:
+			if err := readNumeric(r, &sb, countBits(version, 10, 12)); err != nil {
+				return "", err
+			}
+		case 2:
+			if err := readAlphanumeric(r, &sb, countBits(version, 9, 11)); err != nil {
+				return "", err
+			}
+		case 4:
+			n := countBits(version, 8, 16)
+			if r.available() < n {
+				return "", errWrongData
+			}
+			count := r.read(n)
+			if r.available() < count*8 {
+				return "", errWrongData
+			}
+			for i := 0; i < count; i++ {
+				sb.WriteByte(byte(r.read(8)))
+			}
+		case 7:
+			// ECI: кодировку не меняем, чеки в ASCII
+			if r.available() < 8 {
+				return "", errWrongData
+			}
+			first := r.read(8)
+			switch {
+			case first&0x80 == 0:
+			case first&0xc0 == 0x80 && r.available() >= 8:
+				r.read(8)
+			case first&0xe0 == 0xc0 && r.available() >= 16:
+				r.read(16)
+			default:
+				return "", errWrongData
+			}
+		case 3:
+			// structured append, берём только свою часть
+			if r.available() < 16 {
+				return "", errWrongData
+			}
+			r.read(16)
+		case 5:
+		case 9:
+			if r.available() < 8 {
+				return "", errWrongData
+			}
+			r.read(8)
+		default:
+			return "", errWrongData
+		}
+	}
+	return sb.String(), nil
+}
+
+func countBits(version, small, large int) int {
+	if version < 10 {
+		return small
+	}
+	return large
+}
+
+func readNumeric(r *bitReader, sb *strings.Builder, n int) error {
+	if r.available() < n {
+		return errWrongData
+	}
+	count := r.read(n)
+	for count > 0 {
+		digits, bits := 3, 10
+		if count == 2 {
+			digits, bits = 2, 7
+		} else if count == 1 {
+			digits, bits = 1, 4
+		}
+		if r.available() < bits {
+			return errWrongData
+		}
+		v := r.read(bits)
+		s := make([]byte, digits)
+		for i := digits - 1; i >= 0; i-- {
+			s[i] = byte('0' + v%10)
+			v /= 10
+		}
+		if v != 0 {
+			return errWrongData
+		}
+		sb.Write(s)
+		count -= digits
+	}
+	return nil
+}
+
+func readAlphanumeric(r *bitReader, sb *strings.Builder, n int) error {
+	if r.available() < n {
+		return errWrongData
+	}
+	count := r.read(n)
+	for ; count >= 2; count -= 2 {
+		if r.available() < 11 {
+			return errWrongData
+		}
+		v := r.read(11)
+		if v >= 45*45 {
+			return errWrongData
+		}
+		sb.WriteByte(alphanumeric[v/45])
+		sb.WriteByte(alphanumeric[v%45])
+	}
+	if count == 1 {
+		if r.available() < 6 {
+			return errWrongData
+		}
+		v := r.read(6)
+		if v >= 45 {
+			return errWrongData
+		}
+		sb.WriteByte(alphanumeric[v])
+	}
+	return nil
+}
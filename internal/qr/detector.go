@@ -0,0 +1,492 @@
+package qr
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+)
+
+type bitmap struct {
+	w, h int
+	dark []bool
+}
+
+func (b *bitmap) get(x, y int) bool {
+	if x < 0 || y < 0 || x >= b.w || y >= b.h {
+		return false
+	}
+	return b.dark[y*b.w+x]
+}
+
+const (
+	blockSize = 8
+	// блоки с меньшим разбросом яркости считаем однотонными
+	minDynamicRange = 24
+)
+
+// binarize splits the image into dark and light pixels with a threshold local to 8x8 blocks,
+// so shadows and uneven lighting of photos do not break the code.
+func binarize(img image.Image) *bitmap {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	lum := make([]uint8, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			lum[y*w+x] = color.GrayModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.Gray).Y
+		}
+	}
+
+	bw, bh := (w+blockSize-1)/blockSize, (h+blockSize-1)/blockSize
+	avgs := make([]int, bw*bh)
+	for by := 0; by < bh; by++ {
+		for bx := 0; bx < bw; bx++ {
+			sum, n, min, max := 0, 0, 255, 0
+			for y := by * blockSize; y < (by+1)*blockSize && y < h; y++ {
+				for x := bx * blockSize; x < (bx+1)*blockSize && x < w; x++ {
+					v := int(lum[y*w+x])
+					sum += v
+					n++
+					if v < min {
+						min = v
+					}
+					if v > max {
+						max = v
+					}
+				}
+			}
+			avg := sum / n
+			if max-min <= minDynamicRange {
+				// однотонный блок: светлый, если соседи не говорят, что он тёмный
+				avg = min / 2
+				if by > 0 && bx > 0 {
+					neighbours := (avgs[(by-1)*bw+bx] + 2*avgs[by*bw+bx-1] + avgs[(by-1)*bw+bx-1]) / 4
+					if min < neighbours {
+						avg = neighbours
+					}
+				}
+			}
+			avgs[by*bw+bx] = avg
+		}
+	}
+
+	b := &bitmap{w: w, h: h, dark: make([]bool, w*h)}
+	for by := 0; by < bh; by++ {
+		for bx := 0; bx < bw; bx++ {
+			sum, n := 0, 0
+			for ny := by - 2; ny <= by+2; ny++ {
+				for nx := bx - 2; nx <= bx+2; nx++ {
+					if nx < 0 || ny < 0 || nx >= bw || ny >= bh {
+						continue
+					}
+					sum += avgs[ny*bw+nx]
+					n++
+				}
+			}
+			threshold := sum / n
+			for y := by * blockSize; y < (by+1)*blockSize && y < h; y++ {
+				for x := bx * blockSize; x < (bx+1)*blockSize && x < w; x++ {
+					b.dark[y*w+x] = int(lum[y*w+x]) <= threshold
+				}
+			}
+		}
+	}
+	return b
+}
+
+type point struct {
+	x, y float64
+}
+
+func distance(a, b point) float64 {
+	return math.Hypot(a.x-b.x, a.y-b.y)
+}
+
+type finderPattern struct {
+	point
+	moduleSize float64
+	count      int
+}
+
+// isFinderRatio checks runs of the finder pattern: dark, light, dark, light, dark as 1:1:3:1:1.
+func isFinderRatio(state [5]int) bool {
+	total := 0
+	for _, c := range state {
+		if c == 0 {
+			return false
+		}
+		total += c
+	}
+	if total < 7 {
+		return false
+	}
+	module := float64(total) / 7
+	variance := module / 2
+	return math.Abs(module-float64(state[0])) < variance &&
+		math.Abs(module-float64(state[1])) < variance &&
+		math.Abs(3*module-float64(state[2])) < 3*variance &&
+		math.Abs(module-float64(state[3])) < variance &&
+		math.Abs(module-float64(state[4])) < variance
+}
+
+// crossCheck looks for the finder pattern along (dx, dy) through the pixel (x, y) and returns
+// the offset of its center from the pixel edge and the size of the pattern.
+func (b *bitmap) crossCheck(x, y, dx, dy, maxCount, expectedTotal int) (float64, int, bool) {
+	var state [5]int
+	inside := func(i int) bool {
+		px, py := x+i*dx, y+i*dy
+		return px >= 0 && py >= 0 && px < b.w && py < b.h
+	}
+	dark := func(i int) bool {
+		return b.get(x+i*dx, y+i*dy)
+	}
+
+	i := 0
+	for ; inside(i) && dark(i); i-- {
+		state[2]++
+	}
+	for ; inside(i) && !dark(i) && state[1] <= maxCount; i-- {
+		state[1]++
+	}
+	for ; inside(i) && dark(i) && state[0] <= maxCount; i-- {
+		state[0]++
+	}
+	i = 1
+	for ; inside(i) && dark(i); i++ {
+		state[2]++
+	}
+	for ; inside(i) && !dark(i) && state[3] <= maxCount; i++ {
+		state[3]++
+	}
+	for ; inside(i) && dark(i) && state[4] <= maxCount; i++ {
+		state[4]++
+	}
+	for _, c := range []int{state[0], state[1], state[3], state[4]} {
+		if c > maxCount {
+			return 0, 0, false
+		}
+	}
+
+	total := state[0] + state[1] + state[2] + state[3] + state[4]
+	if 5*abs(total-expectedTotal) >= 2*expectedTotal || !isFinderRatio(state) {
+		return 0, 0, false
+	}
+	center := float64(i-state[4]-state[3]) - float64(state[2])/2
+	return center, total, true
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// findFinderPatterns scans rows for the 1:1:3:1:1 pattern and confirms candidates by columns.
+func (b *bitmap) findFinderPatterns() []finderPattern {
+	patterns := make([]finderPattern, 0)
+	check := func(state [5]int, y, end int) {
+		total := state[0] + state[1] + state[2] + state[3] + state[4]
+		cx := int(float64(end-state[4]-state[3]) - float64(state[2])/2)
+		offY, totalY, ok := b.crossCheck(cx, y, 0, 1, state[2], total)
+		if !ok {
+			return
+		}
+		cy := float64(y) + offY
+		offX, totalX, ok := b.crossCheck(cx, int(cy), 1, 0, state[2], total)
+		if !ok {
+			return
+		}
+		c := point{x: float64(cx) + offX, y: cy}
+		size := float64(totalX+totalY) / 14
+		for i := range patterns {
+			p := &patterns[i]
+			if math.Abs(p.x-c.x) <= size && math.Abs(p.y-c.y) <= size && math.Abs(p.moduleSize-size) <= size/2 {
+				n := float64(p.count)
+				p.x = (p.x*n + c.x) / (n + 1)
+				p.y = (p.y*n + c.y) / (n + 1)
+				p.moduleSize = (p.moduleSize*n + size) / (n + 1)
+				p.count++
+				return
+			}
+		}
+		patterns = append(patterns, finderPattern{point: c, moduleSize: size, count: 1})
+	}
+
+	for y := 0; y < b.h; y++ {
+		var state [5]int
+		cur := 0
+		for x := 0; x < b.w; x++ {
+			if b.get(x, y) {
+				if cur&1 == 1 {
+					cur++
+				}
+				state[cur]++
+				continue
+			}
+			if cur&1 == 1 {
+				state[cur]++
+				continue
+			}
+			if cur < 4 {
+				cur++
+				state[cur]++
+				continue
+			}
+			if isFinderRatio(state) {
+				check(state, y, x)
+			}
+			// следующий узор может начинаться с третьего отрезка
+			state = [5]int{state[2], state[3], state[4], 1, 0}
+			cur = 3
+		}
+		if cur == 4 && isFinderRatio(state) {
+			check(state, y, b.w)
+		}
+	}
+	return patterns
+}
+
+// selectFinderPatterns picks three patterns most alike to the corners of a code and returns
+// them as top left, top right and bottom left.
+func selectFinderPatterns(patterns []finderPattern) ([3]finderPattern, bool) {
+	// случайные совпадения обычно видны лишь в одной строке
+	sort.Slice(patterns, func(i, j int) bool { return patterns[i].count > patterns[j].count })
+	if len(patterns) > 12 {
+		patterns = patterns[:12]
+	}
+
+	var best [3]finderPattern
+	bestScore := math.Inf(1)
+	for i := 0; i < len(patterns); i++ {
+		for j := i + 1; j < len(patterns); j++ {
+			for k := j + 1; k < len(patterns); k++ {
+				t := [3]finderPattern{patterns[i], patterns[j], patterns[k]}
+				if score, ok := triangleScore(t); ok && score < bestScore {
+					best, bestScore = t, score
+				}
+			}
+		}
+	}
+	if math.IsInf(bestScore, 1) {
+		return best, false
+	}
+
+	// верхний левый - вершина прямого угла, напротив самой длинной стороны
+	d01, d02, d12 := distance(best[0].point, best[1].point), distance(best[0].point, best[2].point), distance(best[1].point, best[2].point)
+	switch {
+	case d01 >= d02 && d01 >= d12:
+		best[0], best[2] = best[2], best[0]
+	case d02 >= d01 && d02 >= d12:
+		best[0], best[1] = best[1], best[0]
+	}
+	tl, a, c := best[0], best[1], best[2]
+	// по часовой стрелке от верхнего левого идёт верхний правый
+	if (a.x-tl.x)*(c.y-tl.y)-(a.y-tl.y)*(c.x-tl.x) < 0 {
+		a, c = c, a
+	}
+	return [3]finderPattern{tl, a, c}, true
+}
+
+// triangleScore is how far the patterns are from a right isosceles triangle of equal modules.
+func triangleScore(t [3]finderPattern) (float64, bool) {
+	minSize, maxSize := t[0].moduleSize, t[0].moduleSize
+	for _, p := range t[1:] {
+		minSize = math.Min(minSize, p.moduleSize)
+		maxSize = math.Max(maxSize, p.moduleSize)
+	}
+	if maxSize > minSize*1.5 {
+		return 0, false
+	}
+	sides := []float64{distance(t[0].point, t[1].point), distance(t[0].point, t[2].point), distance(t[1].point, t[2].point)}
+	sort.Float64s(sides)
+	// между центрами хотя бы 7 модулей в самой маленькой версии
+	if sides[0] < 7*minSize {
+		return 0, false
+	}
+	legs := math.Abs(sides[0]-sides[1]) / sides[1]
+	hypotenuse := math.Abs(sides[2]-math.Hypot(sides[0], sides[1])) / sides[2]
+	return legs + hypotenuse + (maxSize-minSize)/maxSize, true
+}
+
+// findAlignmentPattern looks for the center of the alignment pattern near the estimated point:
+// a dark module in a light ring.
+func (b *bitmap) findAlignmentPattern(estimate point, moduleSize float64) (point, bool) {
+	radius := int(moduleSize * 5)
+	ex, ey := int(estimate.x), int(estimate.y)
+	best, bestDist := point{}, math.Inf(1)
+	near := func(c int) bool {
+		return math.Abs(float64(c)-moduleSize) < moduleSize/2
+	}
+	for y := ey - radius; y <= ey+radius; y++ {
+		// отрезки строки: чётные тёмные, нечётные светлые
+		runs := make([]int, 0)
+		starts := make([]int, 0)
+		prevDark := !b.get(ex-radius, y)
+		for x := ex - radius; x <= ex+radius; x++ {
+			d := b.get(x, y)
+			if d != prevDark || len(runs) == 0 {
+				runs = append(runs, 0)
+				starts = append(starts, x)
+				prevDark = d
+			}
+			runs[len(runs)-1]++
+		}
+		firstDark := b.get(ex-radius, y)
+		for i := 1; i+1 < len(runs); i++ {
+			// центр - тёмный отрезок между двумя светлыми, вокруг которых снова тёмные
+			centerDark := (i%2 == 0) == firstDark
+			if !centerDark || i < 2 || i+2 >= len(runs) {
+				continue
+			}
+			if !near(runs[i-1]) || !near(runs[i]) || !near(runs[i+1]) {
+				continue
+			}
+			cx := float64(starts[i]) + float64(runs[i])/2
+			offY, ok := b.alignmentCrossCheck(int(cx), y, moduleSize)
+			if !ok {
+				continue
+			}
+			c := point{x: cx, y: float64(y) + offY}
+			if d := distance(c, estimate); d < bestDist {
+				best, bestDist = c, d
+			}
+		}
+	}
+	return best, !math.IsInf(bestDist, 1)
+}
+
+// alignmentCrossCheck checks light, dark, light runs of a module size along the column.
+func (b *bitmap) alignmentCrossCheck(x, y int, moduleSize float64) (float64, bool) {
+	limit := int(moduleSize*1.5) + 1
+	up, down := 0, 1
+	for ; b.get(x, y-up) && up <= limit; up++ {
+	}
+	for ; b.get(x, y+down) && down <= limit; down++ {
+	}
+	center := up + down - 1
+	lightUp, lightDown := 0, 0
+	for ; !b.get(x, y-up-lightUp) && lightUp <= limit && y-up-lightUp >= 0; lightUp++ {
+	}
+	for ; !b.get(x, y+down+lightDown) && lightDown <= limit && y+down+lightDown < b.h; lightDown++ {
+	}
+	for _, c := range []int{center, lightUp, lightDown} {
+		if math.Abs(float64(c)-moduleSize) >= moduleSize/2 {
+			return 0, false
+		}
+	}
+	return float64(down-up+1) / 2, true
+}
+
+// transform is a perspective transform of points as a 3x3 matrix of homogeneous coordinates.
+type transform [3][3]float64
+
+func (t transform) apply(p point) point {
+	d := t[2][0]*p.x + t[2][1]*p.y + t[2][2]
+	return point{
+		x: (t[0][0]*p.x + t[0][1]*p.y + t[0][2]) / d,
+		y: (t[1][0]*p.x + t[1][1]*p.y + t[1][2]) / d,
+	}
+}
+
+func (t transform) times(o transform) transform {
+	var r transform
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 3; k++ {
+				r[i][j] += t[i][k] * o[k][j]
+			}
+		}
+	}
+	return r
+}
+
+// adjugate is the inverse matrix up to a factor, which is enough for homogeneous coordinates.
+func (t transform) adjugate() transform {
+	var r transform
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			a, b := (j+1)%3, (j+2)%3
+			c, d := (i+1)%3, (i+2)%3
+			r[i][j] = t[a][c]*t[b][d] - t[a][d]*t[b][c]
+		}
+	}
+	return r
+}
+
+// squareToQuad maps the unit square (0,0), (1,0), (1,1), (0,1) to the points.
+func squareToQuad(q [4]point) transform {
+	dx3 := q[0].x - q[1].x + q[2].x - q[3].x
+	dy3 := q[0].y - q[1].y + q[2].y - q[3].y
+	if dx3 == 0 && dy3 == 0 {
+		return transform{
+			{q[1].x - q[0].x, q[2].x - q[1].x, q[0].x},
+			{q[1].y - q[0].y, q[2].y - q[1].y, q[0].y},
+			{0, 0, 1},
+		}
+	}
+	dx1, dx2 := q[1].x-q[2].x, q[3].x-q[2].x
+	dy1, dy2 := q[1].y-q[2].y, q[3].y-q[2].y
+	den := dx1*dy2 - dx2*dy1
+	a13 := (dx3*dy2 - dx2*dy3) / den
+	a23 := (dx1*dy3 - dx3*dy1) / den
+	return transform{
+		{q[1].x - q[0].x + a13*q[1].x, q[3].x - q[0].x + a23*q[3].x, q[0].x},
+		{q[1].y - q[0].y + a13*q[1].y, q[3].y - q[0].y + a23*q[3].y, q[0].y},
+		{a13, a23, 1},
+	}
+}
+
+func quadToQuad(from, to [4]point) transform {
+	return squareToQuad(to).times(squareToQuad(from).adjugate())
+}
+
+// sample reads the modules of a code of the dimension located by the finder patterns.
+func (b *bitmap) sample(f [3]finderPattern, dim int) [][]bool {
+	tl, tr, bl := f[0].point, f[1].point, f[2].point
+	moduleSize := (f[0].moduleSize + f[1].moduleSize + f[2].moduleSize) / 3
+	br := point{x: tr.x - tl.x + bl.x, y: tr.y - tl.y + bl.y}
+	last := float64(dim) - 3.5
+	corner := point{x: last, y: last}
+
+	if dim > dimension(1) {
+		// выравнивающий узор в правом нижнем углу на 3 модуля ближе к центру, чем поисковые
+		k := 1 - 3/(last-3.5)
+		estimate := point{x: tl.x + k*(br.x-tl.x), y: tl.y + k*(br.y-tl.y)}
+		if p, ok := b.findAlignmentPattern(estimate, moduleSize); ok {
+			br = p
+			corner = point{x: last - 3, y: last - 3}
+		}
+	}
+
+	t := quadToQuad(
+		[4]point{{3.5, 3.5}, {last, 3.5}, corner, {3.5, last}},
+		[4]point{tl, tr, br, bl},
+	)
+	grid := make([][]bool, dim)
+	for r := 0; r < dim; r++ {
+		grid[r] = make([]bool, dim)
+		for c := 0; c < dim; c++ {
+			p := t.apply(point{x: float64(c) + 0.5, y: float64(r) + 0.5})
+			grid[r][c] = b.get(int(math.Floor(p.x)), int(math.Floor(p.y)))
+		}
+	}
+	return grid
+}
+
+// estimateDimension counts modules between the finder patterns.
+func estimateDimension(f [3]finderPattern) int {
+	moduleSize := (f[0].moduleSize + f[1].moduleSize + f[2].moduleSize) / 3
+	d := (distance(f[0].point, f[1].point) + distance(f[0].point, f[2].point)) / 2
+	dim := int(math.Round(d/moduleSize)) + 7
+	// размер всегда 4k+1
+	switch dim % 4 {
+	case 0:
+		dim++
+	case 2:
+		dim--
+	case 3:
+		dim -= 2
+	}
+	return dim
+}
@@ -0,0 +1,138 @@
+// Package qr finds and decodes QR codes in images using only the standard library.
+package qr
+
+import (
+	"errors"
+	"image"
+	"math/bits"
+)
+
+var (
+	ErrNotFound = errors.New("qr code not found")
+	ErrDamaged  = errors.New("qr code is damaged or unsupported")
+)
+
+// Decode finds a QR code of version up to 10 in the image and returns its text.
+func Decode(img image.Image) (string, error) {
+	b := binarize(img)
+	f, ok := selectFinderPatterns(b.findFinderPatterns())
+	if !ok {
+		return "", ErrNotFound
+	}
+	dim := estimateDimension(f)
+	// размер по фото может ошибиться на версию в любую сторону
+	for _, d := range []int{dim, dim + 4, dim - 4} {
+		if d < dimension(1) || d > dimension(maxVersion) {
+			continue
+		}
+		if text, err := decodeGrid(b.sample(f, d)); err == nil {
+			return text, nil
+		}
+	}
+	return "", ErrDamaged
+}
+
+// decodeGrid decodes modules of the code, true is a dark module.
+func decodeGrid(grid [][]bool) (string, error) {
+	dim := len(grid)
+	version := (dim - 17) / 4
+	if version < 1 || version > maxVersion || dimension(version) != dim {
+		return "", ErrDamaged
+	}
+	level, mask, err := readFormat(grid)
+	if err != nil {
+		return "", err
+	}
+
+	positions := dataPositions(version)
+	raw := make([]byte, len(positions)/8)
+	for i := range raw {
+		var v byte
+		for j := 0; j < 8; j++ {
+			p := positions[i*8+j]
+			bit := grid[p[0]][p[1]] != masked(mask, p[0], p[1])
+			v <<= 1
+			if bit {
+				v |= 1
+			}
+		}
+		raw[i] = v
+	}
+
+	data, err := correct(raw, versions[version][level])
+	if err != nil {
+		return "", err
+	}
+	return parseSegments(data, version)
+}
+
+// readFormat returns the correction level and the mask from the closest valid format info.
+func readFormat(grid [][]bool) (int, int, error) {
+	first, second := formatPositions(len(grid))
+	read := func(positions [15][2]int) int {
+		v := 0
+		for i, p := range positions {
+			if grid[p[0]][p[1]] {
+				v |= 1 << i
+			}
+		}
+		return v
+	}
+	a, b := read(first), read(second)
+
+	bestDist, bestData := 16, 0
+	for data := 0; data < 32; data++ {
+		valid := formatBits(data>>3, data&7)
+		for _, v := range []int{a, b} {
+			if d := bits.OnesCount(uint(v ^ valid)); d < bestDist {
+				bestDist, bestData = d, data
+			}
+		}
+	}
+	// код формата исправляет до 3 ошибок
+	if bestDist > 3 {
+		return 0, 0, ErrDamaged
+	}
+	return formatLevels[bestData>>3], bestData & 7, nil
+}
+
+// correct deinterleaves the codewords into blocks, fixes them and returns the data codewords.
+func correct(raw []byte, level ecLevel) ([]byte, error) {
+	blocks := make([][]byte, 0)
+	dataLens := make([]int, 0)
+	maxData := 0
+	for _, g := range level.groups {
+		for i := 0; i < g.count; i++ {
+			blocks = append(blocks, make([]byte, 0, g.dataLen+level.ecLen))
+			dataLens = append(dataLens, g.dataLen)
+		}
+		if g.dataLen > maxData {
+			maxData = g.dataLen
+		}
+	}
+
+	pos := 0
+	for i := 0; i < maxData; i++ {
+		for j := range blocks {
+			if i < dataLens[j] {
+				blocks[j] = append(blocks[j], raw[pos])
+				pos++
+			}
+		}
+	}
+	for i := 0; i < level.ecLen; i++ {
+		for j := range blocks {
+			blocks[j] = append(blocks[j], raw[pos])
+			pos++
+		}
+	}
+
+	data := make([]byte, 0, pos)
+	for j, block := range blocks {
+		if err := rsCorrect(block, level.ecLen); err != nil {
+			return nil, ErrDamaged
+		}
+		data = append(data, block[:dataLens[j]]...)
+	}
+	return data, nil
+}
@@ -0,0 +1,321 @@
+package qr
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const receipt = "t=20260301T1230&s=350.00&fn=9960440300123456&i=12345&fp=1234567890&n=1"
+
+func Test_rsEncodeKnownVector(t *testing.T) {
+	// HELLO WORLD в версии 1-M
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	assert.Equal(t, []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}, rsEncode(data, 10))
+}
+
+func Test_rsCorrect(t *testing.T) {
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	block := append(append([]byte(nil), data...), rsEncode(data, 10)...)
+
+	broken := append([]byte(nil), block...)
+	for _, i := range []int{0, 3, 9, 17, 25} {
+		broken[i] ^= 0x5a
+	}
+	require.NoError(t, rsCorrect(broken, 10))
+	assert.Equal(t, block, broken)
+
+	for _, i := range []int{1, 5, 7, 11, 13, 20} {
+		broken[i] ^= 0xff
+	}
+	assert.Error(t, rsCorrect(broken, 10))
+}
+
+func Test_versions(t *testing.T) {
+	for v := 1; v <= maxVersion; v++ {
+		// модулей данных по формуле из стандарта
+		raw := (16*v+128)*v + 64
+		if v >= 2 {
+			n := v/7 + 2
+			raw -= (25*n-10)*n - 55
+		}
+		if v >= 7 {
+			raw -= 36
+		}
+		assert.Equal(t, raw, len(dataPositions(v)), "version %v", v)
+		for level, l := range versions[v] {
+			total := 0
+			for _, g := range l.groups {
+				total += g.count * (g.dataLen + l.ecLen)
+			}
+			assert.Equal(t, raw/8, total, "version %v level %v", v, level)
+		}
+	}
+}
+
+func Test_formatBits(t *testing.T) {
+	// M и маска 0 из примера стандарта
+	assert.Equal(t, 0b101010000010010, formatBits(0, 0))
+}
+
+func Test_transform(t *testing.T) {
+	from := [4]point{{3.5, 3.5}, {21.5, 3.5}, {18.5, 18.5}, {3.5, 21.5}}
+	to := [4]point{{100, 120}, {400, 90}, {380, 410}, {80, 430}}
+	tr := quadToQuad(from, to)
+	for i := range from {
+		p := tr.apply(from[i])
+		assert.InDelta(t, to[i].x, p.x, 1e-6)
+		assert.InDelta(t, to[i].y, p.y, 1e-6)
+	}
+}
+
+func Test_decodeGrid(t *testing.T) {
+	grid := encode(receipt, 6, levelM, 3)
+	// пара испорченных модулей исправляется кодом коррекции
+	grid[20][30] = !grid[20][30]
+	grid[35][12] = !grid[35][12]
+	text, err := decodeGrid(grid)
+	require.NoError(t, err)
+	assert.Equal(t, receipt, text)
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		version int
+		level   int
+		mask    int
+		corners [4]point
+		jpeg    bool
+	}{
+		{name: "version 1", text: "HELLO WORLD", version: 1, level: levelQ, mask: 0, corners: square(200, 120, 0)},
+		{name: "receipt", text: receipt, version: 5, level: levelL, mask: 2, corners: square(300, 180, 0)},
+		{name: "small modules", text: receipt, version: 6, level: levelM, mask: 5, corners: square(300, 150, 0)},
+		{name: "upside down", text: receipt, version: 6, level: levelM, mask: 1, corners: square(400, 250, 180)},
+		{name: "rotated", text: receipt, version: 7, level: levelQ, mask: 6, corners: square(500, 300, 17)},
+		{name: "perspective", text: receipt, version: 6, level: levelM, mask: 4, corners: [4]point{{60, 40}, {330, 70}, {350, 360}, {40, 330}}},
+		{name: "jpeg", text: receipt, version: 10, level: levelH, mask: 7, corners: square(500, 300, 90), jpeg: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			size := 0
+			for _, c := range tt.corners {
+				size = max(size, int(math.Max(c.x, c.y))+40)
+			}
+			img := render(encode(tt.text, tt.version, tt.level, tt.mask), size, tt.corners)
+			if tt.jpeg {
+				var buf bytes.Buffer
+				require.NoError(t, jpeg.Encode(&buf, img, &jpeg.Options{Quality: 70}))
+				decoded, err := jpeg.Decode(&buf)
+				require.NoError(t, err)
+				img = decoded
+			}
+			text, err := Decode(img)
+			require.NoError(t, err)
+			assert.Equal(t, tt.text, text)
+		})
+	}
+}
+
+func TestDecode_shouldFailWithoutCode(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 200, 200))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i * 7)
+	}
+	_, err := Decode(img)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func rsEncode(data []byte, ecLen int) []byte {
+	// порождающий многочлен (x - a^0)...(x - a^(ecLen-1)), старший коэффициент первый
+	gen := []byte{1}
+	for i := 0; i < ecLen; i++ {
+		next := make([]byte, len(gen)+1)
+		for j, c := range gen {
+			next[j] ^= c
+			next[j+1] ^= gfMul(c, gfExp[i])
+		}
+		gen = next
+	}
+	rem := make([]byte, ecLen)
+	for _, d := range data {
+		factor := d ^ rem[0]
+		copy(rem, rem[1:])
+		rem[ecLen-1] = 0
+		for j := 0; j < ecLen; j++ {
+			rem[j] ^= gfMul(gen[j+1], factor)
+		}
+	}
+	return rem
+}
+
+// encode builds modules of the text in byte mode, only for tests of the decoder.
+func encode(text string, version, level, mask int) [][]bool {
+	l := versions[version][level]
+	capacity := 0
+	for _, g := range l.groups {
+		capacity += g.count * g.dataLen
+	}
+	bitsBuf := make([]bool, 0, capacity*8)
+	put := func(v, n int) {
+		for i := n - 1; i >= 0; i-- {
+			bitsBuf = append(bitsBuf, v>>i&1 == 1)
+		}
+	}
+	put(4, 4)
+	put(len(text), countBits(version, 8, 16))
+	for i := 0; i < len(text); i++ {
+		put(int(text[i]), 8)
+	}
+	put(0, 4)
+	for len(bitsBuf)%8 != 0 {
+		bitsBuf = append(bitsBuf, false)
+	}
+	data := make([]byte, 0, capacity)
+	for i := 0; i < len(bitsBuf); i += 8 {
+		var b byte
+		for j := 0; j < 8; j++ {
+			b <<= 1
+			if bitsBuf[i+j] {
+				b |= 1
+			}
+		}
+		data = append(data, b)
+	}
+	for pad := byte(0xec); len(data) < capacity; pad ^= 0xec ^ 0x11 {
+		data = append(data, pad)
+	}
+	if len(data) > capacity {
+		panic("text is too long for the version")
+	}
+
+	// блоки и чередование
+	blocks, ecs := make([][]byte, 0), make([][]byte, 0)
+	pos := 0
+	for _, g := range l.groups {
+		for i := 0; i < g.count; i++ {
+			blocks = append(blocks, data[pos:pos+g.dataLen])
+			ecs = append(ecs, rsEncode(data[pos:pos+g.dataLen], l.ecLen))
+			pos += g.dataLen
+		}
+	}
+	codewords := make([]byte, 0)
+	for i := 0; i < len(blocks[len(blocks)-1]); i++ {
+		for _, b := range blocks {
+			if i < len(b) {
+				codewords = append(codewords, b[i])
+			}
+		}
+	}
+	for i := 0; i < l.ecLen; i++ {
+		for _, ec := range ecs {
+			codewords = append(codewords, ec[i])
+		}
+	}
+
+	dim := dimension(version)
+	grid := make([][]bool, dim)
+	for i := range grid {
+		grid[i] = make([]bool, dim)
+	}
+	finder := func(row, col int) {
+		for r := 0; r < 7; r++ {
+			for c := 0; c < 7; c++ {
+				grid[row+r][col+c] = r == 0 || r == 6 || c == 0 || c == 6 || (r >= 2 && r <= 4 && c >= 2 && c <= 4)
+			}
+		}
+	}
+	finder(0, 0)
+	finder(0, dim-7)
+	finder(dim-7, 0)
+	for i := 8; i < dim-8; i++ {
+		grid[6][i] = i%2 == 0
+		grid[i][6] = i%2 == 0
+	}
+	align := alignmentPositions[version]
+	for i, r := range align {
+		for j, c := range align {
+			last := len(align) - 1
+			if i == 0 && j == 0 || i == 0 && j == last || i == last && j == 0 {
+				continue
+			}
+			for dr := -2; dr <= 2; dr++ {
+				for dc := -2; dc <= 2; dc++ {
+					grid[r+dr][c+dc] = max(abs(dr), abs(dc)) != 1
+				}
+			}
+		}
+	}
+	if version >= 7 {
+		rem := version
+		for i := 0; i < 12; i++ {
+			rem = (rem << 1) ^ ((rem >> 11) * 0x1f25)
+		}
+		v := version<<12 | rem
+		for i := 0; i < 18; i++ {
+			bit := v>>i&1 == 1
+			grid[i/3][dim-11+i%3] = bit
+			grid[dim-11+i%3][i/3] = bit
+		}
+	}
+	levelBits := map[int]int{levelL: 1, levelM: 0, levelQ: 3, levelH: 2}[level]
+	format := formatBits(levelBits, mask)
+	first, second := formatPositions(dim)
+	for i := 0; i < 15; i++ {
+		bit := format>>i&1 == 1
+		grid[first[i][0]][first[i][1]] = bit
+		grid[second[i][0]][second[i][1]] = bit
+	}
+	grid[dim-8][8] = true
+
+	for i, p := range dataPositions(version) {
+		bit := false
+		if i/8 < len(codewords) {
+			bit = codewords[i/8]>>(7-i%8)&1 == 1
+		}
+		grid[p[0]][p[1]] = bit != masked(mask, p[0], p[1])
+	}
+	return grid
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// render draws the modules with a quiet zone of 4 modules into the corners of the image of the size.
+func render(grid [][]bool, size int, corners [4]point) image.Image {
+	dim := float64(len(grid))
+	// из пикселя изображения в координаты кода
+	t := quadToQuad(corners, [4]point{{-4, -4}, {dim + 4, -4}, {dim + 4, dim + 4}, {-4, dim + 4}})
+	img := image.NewGray(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			p := t.apply(point{x: float64(x) + 0.5, y: float64(y) + 0.5})
+			c, r := int(math.Floor(p.x)), int(math.Floor(p.y))
+			dark := r >= 0 && c >= 0 && r < len(grid) && c < len(grid) && grid[r][c]
+			img.SetGray(x, y, color.Gray{Y: map[bool]uint8{true: 20, false: 235}[dark]})
+		}
+	}
+	return img
+}
+
+// square returns corners of a code of the side rotated by the angle in degrees around the center of the image.
+func square(size, side int, angle float64) [4]point {
+	sin, cos := math.Sincos(angle * math.Pi / 180)
+	c, h := float64(size)/2, float64(side)/2
+	r := [4]point{}
+	for i, p := range [4]point{{-h, -h}, {h, -h}, {h, h}, {-h, h}} {
+		r[i] = point{x: c + p.x*cos - p.y*sin, y: c + p.x*sin + p.y*cos}
+	}
+	return r
+}
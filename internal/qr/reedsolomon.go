@@ -0,0 +1,144 @@
+package qr
+
+import "errors"
+
+var errTooManyErrors = errors.New("too many errors to correct")
+
+// таблицы GF(256) с порождающим многочленом x^8+x^4+x^3+x^2+1
+var (
+	gfExp [512]byte
+	gfLog [256]int
+)
+
+func init() {
+	x := 1
+	for i := 0; i < 255; i++ {
+		gfExp[i] = byte(x)
+		gfLog[x] = i
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11d
+		}
+	}
+	for i := 255; i < len(gfExp); i++ {
+		gfExp[i] = gfExp[i-255]
+	}
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[gfLog[a]+gfLog[b]]
+}
+
+func gfDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gfExp[gfLog[a]+255-gfLog[b]]
+}
+
+// gfPow returns a^n for a = alpha^k given as the power k, n may be negative.
+func gfPow(k, n int) byte {
+	p := (k * n) % 255
+	if p < 0 {
+		p += 255
+	}
+	return gfExp[p]
+}
+
+// polyEval evaluates a polynomial with coefficients from the lowest power at x.
+func polyEval(p []byte, x byte) byte {
+	var r byte
+	for i := len(p) - 1; i >= 0; i-- {
+		r = gfMul(r, x) ^ p[i]
+	}
+	return r
+}
+
+// rsCorrect fixes errors of the block in place, the block is data followed by ecLen correction bytes.
+func rsCorrect(block []byte, ecLen int) error {
+	n := len(block)
+	// синдромы S_j = C(alpha^j), старший коэффициент в начале блока
+	syndromes := make([]byte, ecLen)
+	clean := true
+	for j := 0; j < ecLen; j++ {
+		var s byte
+		x := gfExp[j]
+		for i := 0; i < n; i++ {
+			s = gfMul(s, x) ^ block[i]
+		}
+		syndromes[j] = s
+		if s != 0 {
+			clean = false
+		}
+	}
+	if clean {
+		return nil
+	}
+
+	// многочлен локаторов ошибок по Берлекэмпу-Мэсси
+	locator := []byte{1}
+	prev := []byte{1}
+	l, m, b := 0, 1, byte(1)
+	for k := 0; k < ecLen; k++ {
+		d := syndromes[k]
+		for i := 1; i <= l && i < len(locator); i++ {
+			d ^= gfMul(locator[i], syndromes[k-i])
+		}
+		if d == 0 {
+			m++
+			continue
+		}
+		coef := gfDiv(d, b)
+		t := append([]byte(nil), locator...)
+		for len(locator) < len(prev)+m {
+			locator = append(locator, 0)
+		}
+		for i := range prev {
+			locator[i+m] ^= gfMul(coef, prev[i])
+		}
+		if 2*l <= k {
+			l, prev, b, m = k+1-l, t, d, 1
+		} else {
+			m++
+		}
+	}
+	if 2*l > ecLen {
+		return errTooManyErrors
+	}
+
+	// корни локатора - обратные к позициям ошибок (поиск Ченя)
+	positions := make([]int, 0, l)
+	for p := 0; p < n; p++ {
+		if polyEval(locator, gfPow(1, -p)) == 0 {
+			positions = append(positions, p)
+		}
+	}
+	if len(positions) != l {
+		return errTooManyErrors
+	}
+
+	// значения ошибок по Форни: e = X * Omega(X^-1) / Locator'(X^-1)
+	evaluator := make([]byte, ecLen)
+	for i := 0; i < ecLen; i++ {
+		for j := 0; j <= i && j < len(locator); j++ {
+			evaluator[i] ^= gfMul(syndromes[i-j], locator[j])
+		}
+	}
+	derivative := make([]byte, len(locator))
+	for i := 1; i < len(locator); i += 2 {
+		derivative[i-1] = locator[i]
+	}
+	for _, p := range positions {
+		xInv := gfPow(1, -p)
+		denom := polyEval(derivative, xInv)
+		if denom == 0 {
+			return errTooManyErrors
+		}
+		e := gfMul(gfPow(1, p), gfDiv(polyEval(evaluator, xInv), denom))
+		block[n-1-p] ^= e
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package qr
+
+// уровни коррекции в порядке L, M, Q, H
+const (
+	levelL = iota
+	levelM
+	levelQ
+	levelH
+)
+
+// formatLevels переводит два бита уровня из формата в индекс уровня
+var formatLevels = [4]int{1: levelL, 0: levelM, 3: levelQ, 2: levelH}
+
+type blockGroup struct {
+	count   int
+	dataLen int
+}
+
+type ecLevel struct {
+	// байтов коррекции в каждом блоке
+	ecLen  int
+	groups []blockGroup
+}
+
+// versions поддерживаются до 10-й, чеки обычно умещаются в 4-6 версию
+var versions = [...][4]ecLevel{
+	1:  {{7, []blockGroup{{1, 19}}}, {10, []blockGroup{{1, 16}}}, {13, []blockGroup{{1, 13}}}, {17, []blockGroup{{1, 9}}}},
+	2:  {{10, []blockGroup{{1, 34}}}, {16, []blockGroup{{1, 28}}}, {22, []blockGroup{{1, 22}}}, {28, []blockGroup{{1, 16}}}},
+	3:  {{15, []blockGroup{{1, 55}}}, {26, []blockGroup{{1, 44}}}, {18, []blockGroup{{2, 17}}}, {22, []blockGroup{{2, 13}}}},
+	4:  {{20, []blockGroup{{1, 80}}}, {18, []blockGroup{{2, 32}}}, {26, []blockGroup{{2, 24}}}, {16, []blockGroup{{4, 9}}}},
+	5:  {{26, []blockGroup{{1, 108}}}, {24, []blockGroup{{2, 43}}}, {18, []blockGroup{{2, 15}, {2, 16}}}, {22, []blockGroup{{2, 11}, {2, 12}}}},
+	6:  {{18, []blockGroup{{2, 68}}}, {16, []blockGroup{{4, 27}}}, {24, []blockGroup{{4, 19}}}, {28, []blockGroup{{4, 15}}}},
+	7:  {{20, []blockGroup{{2, 78}}}, {18, []blockGroup{{4, 31}}}, {18, []blockGroup{{2, 14}, {4, 15}}}, {26, []blockGroup{{4, 13}, {1, 14}}}},
+	8:  {{24, []blockGroup{{2, 97}}}, {22, []blockGroup{{2, 38}, {2, 39}}}, {22, []blockGroup{{4, 18}, {2, 19}}}, {26, []blockGroup{{4, 14}, {2, 15}}}},
+	9:  {{30, []blockGroup{{2, 116}}}, {22, []blockGroup{{3, 36}, {2, 37}}}, {20, []blockGroup{{4, 16}, {4, 17}}}, {24, []blockGroup{{4, 12}, {4, 13}}}},
+	10: {{18, []blockGroup{{2, 68}, {2, 69}}}, {26, []blockGroup{{4, 43}, {1, 44}}}, {24, []blockGroup{{6, 19}, {2, 20}}}, {28, []blockGroup{{6, 15}, {2, 16}}}},
+}
+
+const maxVersion = len(versions) - 1
+
+// alignmentPositions - координаты центров выравнивающих узоров по каждой оси
+var alignmentPositions = [...][]int{
+	2:  {6, 18},
+	3:  {6, 22},
+	4:  {6, 26},
+	5:  {6, 30},
+	6:  {6, 34},
+	7:  {6, 22, 38},
+	8:  {6, 24, 42},
+	9:  {6, 26, 46},
+	10: {6, 28, 50},
+}
+
+func dimension(version int) int {
+	return 17 + 4*version
+}
+
+// functionModules отмечает модули служебных узоров, в которых нет данных.
+func functionModules(version int) [][]bool {
+	dim := dimension(version)
+	m := make([][]bool, dim)
+	for i := range m {
+		m[i] = make([]bool, dim)
+	}
+	fill := func(row, col, h, w int) {
+		for r := row; r < row+h; r++ {
+			for c := col; c < col+w; c++ {
+				m[r][c] = true
+			}
+		}
+	}
+	// поисковые узоры с разделителями и форматом
+	fill(0, 0, 9, 9)
+	fill(0, dim-8, 9, 8)
+	fill(dim-8, 0, 8, 9)
+	// синхронизация
+	fill(6, 0, 1, dim)
+	fill(0, 6, dim, 1)
+
+	pos := alignmentPositions[version]
+	last := len(pos) - 1
+	for i, r := range pos {
+		for j, c := range pos {
+			// углы заняты поисковыми узорами
+			if i == 0 && j == 0 || i == 0 && j == last || i == last && j == 0 {
+				continue
+			}
+			fill(r-2, c-2, 5, 5)
+		}
+	}
+	if version >= 7 {
+		// информация о версии
+		fill(0, dim-11, 6, 3)
+		fill(dim-11, 0, 3, 6)
+	}
+	return m
+}
+
+// dataPositions returns coordinates (row, col) of data modules in the order of bits.
+func dataPositions(version int) [][2]int {
+	function := functionModules(version)
+	dim := len(function)
+	r := make([][2]int, 0, dim*dim)
+	for right := dim - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < dim; vert++ {
+			row := vert
+			if upward {
+				row = dim - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				col := right - j
+				if !function[row][col] {
+					r = append(r, [2]int{row, col})
+				}
+			}
+		}
+	}
+	return r
+}
+
+func masked(mask, row, col int) bool {
+	switch mask {
+	case 0:
+		return (row+col)%2 == 0
+	case 1:
+		return row%2 == 0
+	case 2:
+		return col%3 == 0
+	case 3:
+		return (row+col)%3 == 0
+	case 4:
+		return (row/2+col/3)%2 == 0
+	case 5:
+		return row*col%2+row*col%3 == 0
+	case 6:
+		return (row*col%2+row*col%3)%2 == 0
+	default:
+		return ((row+col)%2+row*col%3)%2 == 0
+	}
+}
+
+// formatBits returns 15 bits of the format info with BCH code for the level bits and mask.
+func formatBits(levelBits, mask int) int {
+	data := levelBits<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// formatPositions returns coordinates (row, col) of both copies of the format info, bit 0 first.
+func formatPositions(dim int) (first, second [15][2]int) {
+	for i := 0; i < 6; i++ {
+		first[i] = [2]int{i, 8}
+	}
+	first[6] = [2]int{7, 8}
+	first[7] = [2]int{8, 8}
+	first[8] = [2]int{8, 7}
+	for i := 9; i < 15; i++ {
+		first[i] = [2]int{8, 14 - i}
+	}
+	for i := 0; i < 8; i++ {
+		second[i] = [2]int{8, dim - 1 - i}
+	}
+	for i := 8; i < 15; i++ {
+		second[i] = [2]int{dim - 15 + i, 8}
+	}
+	return first, second
+}
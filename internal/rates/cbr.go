@@ -0,0 +1,100 @@
+package rates
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/charset"
+)
+
+const cbrURL = "https://www.cbr.ru/scripts/XML_daily.asp"
+
+// CBRMirrorURL serves the same daily xml of the cbr in utf-8.
+const CBRMirrorURL = "https://www.cbr-xml-daily.ru/daily_utf8.xml"
+
+// CBRProvider reads the daily rates of the Central Bank of Russia, they are quoted in rub.
+type CBRProvider struct {
+	client *http.Client
+	url    string
+}
+
+func NewCBRProvider(client *http.Client, url string) *CBRProvider {
+	if url == "" {
+		url = cbrURL
+	}
+	return &CBRProvider{client: client, url: url}
+}
+
+func (p *CBRProvider) Name() string {
+	return CBR
+}
+
+type cbrDaily struct {
+	Valutes []struct {
+		CharCode string `xml:"CharCode"`
+		Nominal  string `xml:"Nominal"`
+		Value    string `xml:"Value"`
+	} `xml:"Valute"`
+}
+
+func (p *CBRProvider) Rates(ctx context.Context, base string, codes []string) (map[string]decimal.Decimal, error) {
+	resp, err := get(ctx, p.client, p.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	q, err := parseCBR(body)
+	if err != nil {
+		return nil, err
+	}
+	return q.cross(base, codes)
+}
+
+func parseCBR(body []byte) (quotes, error) {
+	// ЦБ отдаёт xml в windows-1251
+	if bytes.Contains(bytes.ToLower(body[:min(len(body), 100)]), []byte("windows-1251")) {
+		body = charset.DecodeWindows1251(body)
+	}
+	d := xml.NewDecoder(bytes.NewReader(body))
+	d.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	daily := cbrDaily{}
+	if err := d.Decode(&daily); err != nil {
+		return nil, err
+	}
+
+	q := quotes{"rub": decimal.NewFromInt(1)}
+	for _, v := range daily.Valutes {
+		// курс за номинал в рублях с запятой: 10 CNY = 128,2937
+		value, err := decimal.NewFromString(strings.Replace(strings.TrimSpace(v.Value), ",", ".", 1))
+		if err != nil {
+			return nil, err
+		}
+		nominal, err := decimal.NewFromString(strings.TrimSpace(v.Nominal))
+		if err != nil {
+			return nil, err
+		}
+		if value.IsZero() {
+			continue
+		}
+		q[strings.ToLower(v.CharCode)] = nominal.Div(value)
+	}
+	return q, nil
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
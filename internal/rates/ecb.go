@@ -0,0 +1,58 @@
+package rates
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+const ecbURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+
+// ECBProvider reads the euro reference rates of the European Central Bank.
+type ECBProvider struct {
+	client *http.Client
+	url    string
+}
+
+func NewECBProvider(client *http.Client, url string) *ECBProvider {
+	if url == "" {
+		url = ecbURL
+	}
+	return &ECBProvider{client: client, url: url}
+}
+
+func (p *ECBProvider) Name() string {
+	return ECB
+}
+
+type ecbEnvelope struct {
+	Rates []struct {
+		Currency string `xml:"currency,attr"`
+		Rate     string `xml:"rate,attr"`
+	} `xml:"Cube>Cube>Cube"`
+}
+
+func (p *ECBProvider) Rates(ctx context.Context, base string, codes []string) (map[string]decimal.Decimal, error) {
+	resp, err := get(ctx, p.client, p.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	envelope := ecbEnvelope{}
+	if err := xml.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return nil, err
+	}
+	q := quotes{"eur": decimal.NewFromInt(1)}
+	for _, r := range envelope.Rates {
+		v, err := decimal.NewFromString(r.Rate)
+		if err != nil {
+			return nil, err
+		}
+		q[strings.ToLower(r.Currency)] = v
+	}
+	return q.cross(base, codes)
+}
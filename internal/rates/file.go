@@ -0,0 +1,53 @@
+package rates
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"gopkg.in/yaml.v3"
+)
+
+// FileProvider reads rates from a yaml file for offline use and tests:
+//
+//	base: rub
+//	rates:
+//	  usd: 0.0125
+type FileProvider struct {
+	path string
+}
+
+func NewFileProvider(path string) *FileProvider {
+	return &FileProvider{path: path}
+}
+
+func (p *FileProvider) Name() string {
+	return File
+}
+
+type ratesFile struct {
+	Base  string            `yaml:"base"`
+	Rates map[string]string `yaml:"rates"`
+}
+
+func (p *FileProvider) Rates(_ context.Context, base string, codes []string) (map[string]decimal.Decimal, error) {
+	raw, err := os.ReadFile(p.path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading rates file")
+	}
+	f := ratesFile{}
+	if err := yaml.Unmarshal(raw, &f); err != nil {
+		return nil, errors.Wrap(err, "parsing rates file")
+	}
+	q := quotes{strings.ToLower(f.Base): decimal.NewFromInt(1)}
+	for code, rate := range f.Rates {
+		v, err := decimal.NewFromString(rate)
+		if err != nil {
+			return nil, errors.Wrapf(err, "rate of %v", code)
+		}
+		q[strings.ToLower(code)] = v
+	}
+	return q.cross(base, codes)
+}
@@ -0,0 +1,132 @@
+// Package rates fetches exchange rates from the Central Bank of Russia, the ECB or a local file.
+package rates
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	CBR  = "cbr"
+	ECB  = "ecb"
+	File = "file"
+)
+
+var ErrUnknownProvider = errors.New("unknown rate provider, cbr, ecb and file are supported")
+
+// RateProvider returns how many units of every currency are given for one unit of the base currency.
+type RateProvider interface {
+	Name() string
+	Rates(ctx context.Context, base string, codes []string) (map[string]decimal.Decimal, error)
+}
+
+// ProviderConfig describes a source of rates, url and path are optional for cbr and ecb.
+type ProviderConfig struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+	// файл с курсами для провайдера file
+	Path string `yaml:"path"`
+}
+
+// DefaultProviders are used when providers are not set in the config.
+// The ecb does not quote rub, so rates to rub are loaded from the cbr and its mirror with the same xml,
+// rates to other base currencies are loaded from the ecb, and from the cbr crossed through rub if the ecb fails.
+func DefaultProviders(base string) []ProviderConfig {
+	if base == "rub" {
+		return []ProviderConfig{{Name: CBR}, {Name: CBR, URL: CBRMirrorURL}}
+	}
+	return []ProviderConfig{{Name: ECB}, {Name: CBR}}
+}
+
+// New builds providers from the config, they are asked in the same order until one of them answers.
+func New(configs []ProviderConfig, timeout time.Duration) (RateProvider, error) {
+	client := &http.Client{Timeout: timeout}
+	providers := make(Fallback, 0, len(configs))
+	for _, c := range configs {
+		switch strings.ToLower(c.Name) {
+		case CBR:
+			providers = append(providers, NewCBRProvider(client, c.URL))
+		case ECB:
+			providers = append(providers, NewECBProvider(client, c.URL))
+		case File:
+			providers = append(providers, NewFileProvider(c.Path))
+		default:
+			return nil, errors.Wrap(ErrUnknownProvider, c.Name)
+		}
+	}
+	if len(providers) == 1 {
+		return providers[0], nil
+	}
+	return providers, nil
+}
+
+// Fallback asks providers in order and returns the rates of the first one that succeeds.
+type Fallback []RateProvider
+
+func (f Fallback) Name() string {
+	names := make([]string, len(f))
+	for i := range f {
+		names[i] = f[i].Name()
+	}
+	return strings.Join(names, ",")
+}
+
+func (f Fallback) Rates(ctx context.Context, base string, codes []string) (map[string]decimal.Decimal, error) {
+	var errs []string
+	for _, p := range f {
+		r, err := p.Rates(ctx, base, codes)
+		if err == nil {
+			return r, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		errs = append(errs, fmt.Sprintf("%v: %v", p.Name(), err))
+	}
+	return nil, fmt.Errorf("no rate provider answered: %v", strings.Join(errs, "; "))
+}
+
+// quotes are rates of a provider to its own base currency, like rub for the cbr and eur for the ecb.
+type quotes map[string]decimal.Decimal
+
+// cross converts quotes to the requested base, the base itself is always 1.
+func (q quotes) cross(base string, codes []string) (map[string]decimal.Decimal, error) {
+	b, ok := q[base]
+	if !ok || b.IsZero() {
+		return nil, fmt.Errorf("%v is not quoted", base)
+	}
+	r := map[string]decimal.Decimal{base: decimal.NewFromInt(1)}
+	for _, code := range codes {
+		if code == base {
+			continue
+		}
+		v, ok := q[code]
+		if !ok {
+			return nil, fmt.Errorf("%v is not quoted", code)
+		}
+		r[code] = v.Div(b).Round(6)
+	}
+	return r, nil
+}
+
+func get(ctx context.Context, client *http.Client, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status %v", resp.Status)
+	}
+	return resp, nil
+}
@@ -0,0 +1,122 @@
+package rates
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var codes = []string{"usd", "eur", "cny"}
+
+func serve(t *testing.T, file string) *httptest.Server {
+	body, err := os.ReadFile(file)
+	require.NoError(t, err)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// assertRates compares rates by value, 0.0125 and 0.012500 are the same.
+func assertRates(t *testing.T, want map[string]string, got map[string]decimal.Decimal) {
+	assert.Len(t, got, len(want))
+	for code, v := range want {
+		assert.True(t, decimal.RequireFromString(v).Equal(got[code]), "%v: %v", code, got[code])
+	}
+}
+
+func TestCBRProvider(t *testing.T) {
+	s := serve(t, "testdata/cbr_daily.xml")
+	p := NewCBRProvider(s.Client(), s.URL)
+
+	r, err := p.Rates(context.Background(), "rub", codes)
+	require.NoError(t, err)
+	assertRates(t, map[string]string{"rub": "1", "usd": "0.0125", "eur": "0.01", "cny": "0.08"}, r)
+
+	cross, err := p.Rates(context.Background(), "usd", []string{"rub", "eur"})
+	require.NoError(t, err)
+	assertRates(t, map[string]string{"usd": "1", "rub": "80", "eur": "0.8"}, cross)
+}
+
+func TestCBRProvider_shouldParseMirrorInUTF8(t *testing.T) {
+	s := serve(t, "testdata/cbr_daily_utf8.xml")
+	p := NewCBRProvider(s.Client(), s.URL)
+
+	r, err := p.Rates(context.Background(), "rub", codes)
+	require.NoError(t, err)
+	assertRates(t, map[string]string{"rub": "1", "usd": "0.0125", "eur": "0.01", "cny": "0.08"}, r)
+}
+
+func TestECBProvider(t *testing.T) {
+	s := serve(t, "testdata/ecb_daily.xml")
+	p := NewECBProvider(s.Client(), s.URL)
+
+	r, err := p.Rates(context.Background(), "usd", []string{"eur", "cny"})
+	require.NoError(t, err)
+	assertRates(t, map[string]string{"usd": "1", "eur": "0.8", "cny": "6.4"}, r)
+
+	// базовой валютой бота может быть и евро
+	r, err = p.Rates(context.Background(), "eur", []string{"usd", "cny"})
+	require.NoError(t, err)
+	assertRates(t, map[string]string{"eur": "1", "usd": "1.25", "cny": "8"}, r)
+
+	// рубля в курсах ЕЦБ нет
+	_, err = p.Rates(context.Background(), "rub", codes)
+	assert.Error(t, err)
+}
+
+func TestFileProvider(t *testing.T) {
+	r, err := NewFileProvider("testdata/rates.yaml").Rates(context.Background(), "rub", codes)
+	require.NoError(t, err)
+	assertRates(t, map[string]string{"rub": "1", "usd": "0.0125", "eur": "0.01", "cny": "0.08"}, r)
+
+	_, err = NewFileProvider("testdata/missing.yaml").Rates(context.Background(), "rub", codes)
+	assert.Error(t, err)
+}
+
+type failingProvider struct{}
+
+func (failingProvider) Name() string {
+	return "failing"
+}
+
+func (failingProvider) Rates(context.Context, string, []string) (map[string]decimal.Decimal, error) {
+	return nil, errors.New("unavailable")
+}
+
+func TestFallback_shouldAskNextProviderOnError(t *testing.T) {
+	f := Fallback{failingProvider{}, NewFileProvider("testdata/rates.yaml")}
+	r, err := f.Rates(context.Background(), "rub", []string{"usd"})
+	require.NoError(t, err)
+	assertRates(t, map[string]string{"rub": "1", "usd": "0.0125"}, r)
+
+	_, err = Fallback{failingProvider{}, failingProvider{}}.Rates(context.Background(), "rub", codes)
+	assert.ErrorContains(t, err, "failing: unavailable")
+}
+
+func TestNew(t *testing.T) {
+	p, err := New([]ProviderConfig{{Name: "cbr"}, {Name: "file", Path: "testdata/rates.yaml"}}, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "cbr,file", p.Name())
+
+	// для рубля по умолчанию только источники, которые его знают
+	p, err = New(DefaultProviders("rub"), time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "cbr,cbr", p.Name())
+
+	p, err = New(DefaultProviders("eur"), time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "ecb,cbr", p.Name())
+
+	_, err = New([]ProviderConfig{{Name: "currencyapi"}}, time.Second)
+	assert.ErrorIs(t, err, ErrUnknownProvider)
+}
@@ -2,12 +2,17 @@ package report_service
 
 import (
 	"context"
-	"encoding/json"
+	"fmt"
 	"github.com/Shopify/sarama"
+	"github.com/opentracing/opentracing-go/ext"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/api"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/export"
 	. "gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/logger"
 	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/observability"
 	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/time_util"
 	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
 )
 
 var (
@@ -65,25 +70,104 @@ func (consumer *Consumer) ConsumeClaim(session sarama.ConsumerGroupSession, clai
 }
 
 func (consumer *Consumer) handleMessage(m *sarama.ConsumerMessage) {
-	request := model.ReportRequest{}
-	err := json.Unmarshal(m.Value, &request)
-	if err != nil {
-		Log.Error("faield to parse msg", zap.Error(err))
+	// трейс продолжается с того места, где бот отправил запрос
+	headers := make(map[string]string, len(m.Headers))
+	for _, h := range m.Headers {
+		headers[string(h.Key)] = string(h.Value)
+	}
+	span, ctx := observability.StartSpanFromHeaders(context.Background(), headers, "report_service: build report")
+	defer span.Finish()
+
+	msg := &api.ReportRequest{}
+	if err := proto.Unmarshal(m.Value, msg); err != nil {
+		Log.Error("failed to parse msg", zap.Error(err))
 		return
 	}
-	start, err := time_util.DateToTime(request.Start)
+	request, err := msg.ToModel()
 	if err != nil {
-		Log.Error("faield to parse start field", zap.Error(err))
+		Log.Error("failed to parse msg", zap.Error(err), zap.String("requestId", msg.GetRequestId()))
+		ext.Error.Set(span, true)
+		// бот ждёт ответа на запрос, без него пользователь не узнает, что отчёта не будет
+		consumer.reportResultService.Send(ctx, &api.ReportResult{UserId: msg.GetUserId(), RequestId: msg.GetRequestId(), Error: err.Error()})
 		return
 	}
-	end, err := time_util.DateToTime(request.End)
+	span.SetTag("request_id", request.RequestId)
+	var result *api.ReportResult
+	if request.Export != "" {
+		result, err = consumer.buildExport(ctx, request)
+	} else {
+		result, err = consumer.buildReport(ctx, request)
+	}
 	if err != nil {
-		Log.Error("failed to parse end field", zap.Error(err))
-		return
+		Log.Error("failed to get stat from db", zap.Error(err), zap.String("requestId", request.RequestId))
+		ext.Error.Set(span, true)
+		result = &api.ReportResult{UserId: request.UserId, Error: err.Error()}
+	}
+	result.RequestId = request.RequestId
+	consumer.reportResultService.Send(ctx, result)
+}
+
+func (consumer *Consumer) buildExport(ctx context.Context, request *model.ReportRequest) (*api.ReportResult, error) {
+	start, end := request.Start, request.End
+	result := &api.ReportResult{UserId: request.UserId, Start: time_util.TimeToDate(start), End: time_util.TimeToDate(end)}
+	format, err := export.ParseFormat(request.Export)
+	if err != nil {
+		return result, err
+	}
+	currency, err := requestCurrency(request)
+	if err != nil {
+		return result, err
 	}
-	result, err := consumer.reportStorage.getStatsBy(context.Background(), start, end)
+	entries, err := consumer.reportStorage.getEntries(ctx, request.UserId, start, end, currency.Code)
 	if err != nil {
-		Log.Error("failed to get stat from db")
+		return result, err
+	}
+	if result.Document, err = export.Write(format, entries, currency); err != nil {
+		return result, err
+	}
+	result.DocumentName = export.FileName(format, start, end)
+	return result, nil
+}
+
+func (consumer *Consumer) buildReport(ctx context.Context, request *model.ReportRequest) (*api.ReportResult, error) {
+	start, end := request.Start, request.End
+	result := &api.ReportResult{UserId: request.UserId, Start: time_util.TimeToDate(start), End: time_util.TimeToDate(end), Mode: string(request.Mode), Chart: request.Chart}
+	currency, err := requestCurrency(request)
+	if err != nil {
+		return result, err
+	}
+	code := currency.Code
+	result.CurrencyCode = code
+	if request.Chart && !request.Mode.IsBreakdown() {
+		if result.Buckets, err = consumer.reportStorage.getBreakdown(ctx, request.UserId, start, end, string(model.DayReport), code); err != nil {
+			return result, err
+		}
+	}
+	switch {
+	case request.Mode.IsBreakdown():
+		result.Buckets, err = consumer.reportStorage.getBreakdown(ctx, request.UserId, start, end, string(request.Mode), code)
+	case request.Mode == model.CompareReport:
+		prevStart, prevEnd := model.PreviousRange(start, end)
+		result.PreviousStart, result.PreviousEnd = time_util.TimeToDate(prevStart), time_util.TimeToDate(prevEnd)
+		if result.Data, err = consumer.reportStorage.getStatsBy(ctx, request.UserId, start, end, code); err != nil {
+			return result, err
+		}
+		result.Previous, err = consumer.reportStorage.getStatsBy(ctx, request.UserId, prevStart, prevEnd, code)
+	case request.Mode == model.TopReport:
+		result.Top, err = consumer.reportStorage.getTop(ctx, request.UserId, start, end, request.Top, code)
+	default:
+		if result.Data, err = consumer.reportStorage.getStatsBy(ctx, request.UserId, start, end, code); err != nil {
+			return result, err
+		}
+		result.Income, err = consumer.reportStorage.getIncomeStatsBy(ctx, request.UserId, start, end, code)
+	}
+	return result, err
+}
+
+// requestCurrency returns the currency of the user, the service does not know the base currency of the bot to count in it.
+func requestCurrency(request *model.ReportRequest) (model.Currency, error) {
+	if request.Currency == nil {
+		return model.Currency{}, fmt.Errorf("report request %v has no currency", request.RequestId)
 	}
-	consumer.reportResultService.Send(context.Background(), request.UserId, request.Start, request.End, result)
+	return *request.Currency, nil
 }
@@ -4,9 +4,11 @@ import (
 	"context"
 	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/api"
 	. "gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/logger"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/observability"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 	"log"
 )
 
@@ -30,11 +32,13 @@ func NewReportResultSender(ctx context.Context) *ReportResultSender {
 	return &ReportResultSender{c}
 }
 
-func (s *ReportResultSender) Send(ctx context.Context, userId int64, start string, end string, data map[string]float64) {
-	_, err := s.client.Send(ctx, &api.ReportResult{UserId: userId, Start: start, End: end, Data: data})
+// Send returns the result to the bot, the trace of the context is passed in grpc metadata.
+func (s *ReportResultSender) Send(ctx context.Context, result *api.ReportResult) {
+	ctx = metadata.NewOutgoingContext(ctx, metadata.New(observability.Inject(ctx)))
+	_, err := s.client.Send(ctx, result)
 	if err != nil {
-		Log.Error("failed on send request", zap.Error(err))
+		Log.Error("failed on send request", zap.Error(err), zap.String("requestId", result.RequestId))
 		return
 	}
-	Log.Info("request sent successfully")
+	Log.Info("request sent successfully", zap.String("requestId", result.RequestId))
 }
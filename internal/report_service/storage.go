@@ -5,6 +5,10 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/api"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/storage/pgdatabase"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/time_util"
 	"time"
 )
 
@@ -12,17 +16,35 @@ type ReportStorage struct {
 	DB *sqlx.DB
 }
 
-func (s *ReportStorage) getStatsBy(ctx context.Context, startAt, endAt time.Time) (map[string]float64, error) {
+// getStatsBy returns sums per category in the currency, every spending is converted at the rate of its date like in the bot.
+func (s *ReportStorage) getStatsBy(ctx context.Context, userId int64, startAt, endAt time.Time, currencyCode string) (map[string]float64, error) {
 	span, _ := opentracing.StartSpanFromContext(ctx, "storage: getting report")
 	defer span.Finish()
 
+	q := "select categories.name as name, sum(" + pgdatabase.ValueIn(4) + ") as value from spendings inner join categories on spendings.category_id = categories.id where user_id = $1 and date between $2 and $3 group by categories.name"
+	return s.selectStats(ctx, span, q, userId, startAt, endAt, currencyCode)
+}
+
+func (s *ReportStorage) getIncomeStatsBy(ctx context.Context, userId int64, startAt, endAt time.Time, currencyCode string) (map[string]float64, error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "storage: getting income report")
+	defer span.Finish()
+
+	q := "select income_categories.name as name, sum(" + pgdatabase.IncomeValueIn(4) + ") as value from incomes inner join income_categories on incomes.category_id = income_categories.id where user_id = $1 and date between $2 and $3 group by income_categories.name"
+	return s.selectStats(ctx, span, q, userId, startAt, endAt, currencyCode)
+}
+
+func (s *ReportStorage) selectStats(ctx context.Context, span opentracing.Span, q string, userId int64, startAt, endAt time.Time, currencyCode string) (map[string]float64, error) {
+	if err := pgdatabase.CheckRate(ctx, s.DB, currencyCode); err != nil {
+		ext.Error.Set(span, true)
+		return nil, err
+	}
+
 	results := []struct {
 		Name  string  `db:"name"`
 		Value float64 `db:"value"`
 	}{}
 
-	q := "select categories.name as name, sum(spendings.value) as value from spendings inner join categories on spendings.category_id = categories.id where date between $1 and $2 group by categories.name"
-	if err := s.DB.Select(&results, q, startAt, endAt); err != nil {
+	if err := s.DB.Select(&results, q, userId, startAt, endAt, currencyCode); err != nil {
 		ext.Error.Set(span, true)
 		return nil, err
 	}
@@ -34,3 +56,86 @@ func (s *ReportStorage) getStatsBy(ctx context.Context, startAt, endAt time.Time
 
 	return r, nil
 }
+
+func (s *ReportStorage) getBreakdown(ctx context.Context, userId int64, startAt, endAt time.Time, unit string, currencyCode string) ([]*api.ReportBucket, error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "storage: getting report breakdown")
+	defer span.Finish()
+
+	if err := pgdatabase.CheckRate(ctx, s.DB, currencyCode); err != nil {
+		ext.Error.Set(span, true)
+		return nil, err
+	}
+
+	results := []struct {
+		Start time.Time `db:"start"`
+		Name  string    `db:"name"`
+		Value float64   `db:"value"`
+	}{}
+
+	// неделя или месяц, с которых начинается период, считаются с его начала, а не с понедельника или первого числа
+	q := "select greatest(date_trunc($4, spendings.date)::date, $2::date) as start, categories.name as name, sum(" + pgdatabase.ValueIn(5) + ") as value from spendings inner join categories on spendings.category_id = categories.id where user_id = $1 and date between $2 and $3 group by 1, 2 order by 1"
+	if err := s.DB.Select(&results, q, userId, startAt, endAt, unit, currencyCode); err != nil {
+		ext.Error.Set(span, true)
+		return nil, err
+	}
+
+	r := make([]*api.ReportBucket, 0)
+	for i := 0; i < len(results); i++ {
+		start := time_util.TimeToDate(results[i].Start)
+		if len(r) == 0 || r[len(r)-1].Start != start {
+			r = append(r, &api.ReportBucket{Start: start, Data: make(map[string]float64)})
+		}
+		r[len(r)-1].Data[results[i].Name] = results[i].Value
+	}
+	return r, nil
+}
+
+// getEntries returns spendings of the period with the rate of the currency on their dates.
+func (s *ReportStorage) getEntries(ctx context.Context, userId int64, startAt, endAt time.Time, currencyCode string) ([]model.ReportEntry, error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "storage: getting spendings for export")
+	defer span.Finish()
+
+	if err := pgdatabase.CheckRate(ctx, s.DB, currencyCode); err != nil {
+		ext.Error.Set(span, true)
+		return nil, err
+	}
+
+	r := []model.ReportEntry{}
+	q := `select spendings.date as date, categories.name as category, spendings.value as value, spendings.note as note,
+spendings.original_value as original_value, spendings.currency_code as currency_code, currency_rate($4, spendings.date) as rate
+from spendings inner join categories on spendings.category_id = categories.id where user_id = $1 and date between $2 and $3 order by spendings.date, spendings.id`
+	if err := s.DB.Select(&r, q, userId, startAt, endAt, currencyCode); err != nil {
+		ext.Error.Set(span, true)
+		return nil, err
+	}
+	return r, nil
+}
+
+func (s *ReportStorage) getTop(ctx context.Context, userId int64, startAt, endAt time.Time, count int, currencyCode string) ([]*api.ReportEntry, error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "storage: getting top spendings")
+	defer span.Finish()
+
+	if err := pgdatabase.CheckRate(ctx, s.DB, currencyCode); err != nil {
+		ext.Error.Set(span, true)
+		return nil, err
+	}
+
+	results := []struct {
+		Date     time.Time `db:"date"`
+		Category string    `db:"category"`
+		Value    float64   `db:"value"`
+		Note     string    `db:"note"`
+	}{}
+
+	q := "select spendings.date as date, categories.name as category, " + pgdatabase.ValueIn(5) + " as value, spendings.note as note from spendings inner join categories on spendings.category_id = categories.id where user_id = $1 and date between $2 and $3 order by spendings.value desc, spendings.date desc limit $4"
+	if err := s.DB.Select(&results, q, userId, startAt, endAt, count, currencyCode); err != nil {
+		ext.Error.Set(span, true)
+		return nil, err
+	}
+
+	r := make([]*api.ReportEntry, len(results))
+	for i := 0; i < len(results); i++ {
+		r[i] = &api.ReportEntry{Date: time_util.TimeToDate(results[i].Date), Category: results[i].Category, Value: results[i].Value, Note: results[i].Note}
+	}
+	return r, nil
+}
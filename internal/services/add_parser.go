@@ -0,0 +1,276 @@
+package services
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/i18n"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
+)
+
+// addArgs is the parsed input of /add.
+type addArgs struct {
+	sum          decimal.Decimal
+	currencyCode string
+	category     model.Category
+	date         time.Time
+	// дата указана явно, а не взята сегодняшняя
+	dateSet bool
+	// заметка вместе с тегами
+	words []string
+	// всё разобрано, кроме категории: её можно угадать по заметке
+	needCategory bool
+}
+
+var (
+	amountRe    = regexp.MustCompile(`^([$€¥₽]?)(\d+(?:[.,]\d{1,2})?)([$€¥₽]|[a-zа-яё]+)?$`)
+	fullDateRe  = regexp.MustCompile(`^(\d{1,2})[./-](\d{1,2})[./-](\d{4}|\d{2})$`)
+	shortDateRe = regexp.MustCompile(`^(\d{1,2})([./-])(\d{1,2})$`)
+)
+
+var currencyAliases = map[string]string{
+	"rub": "rub", "руб": "rub", "р": "rub", "₽": "rub",
+	"usd": "usd", "$": "usd",
+	"eur": "eur", "€": "eur", "евро": "eur",
+	"cny": "cny", "¥": "cny",
+}
+
+var dayAliases = map[string]int{
+	"today": 0, "сегодня": 0,
+	"yesterday": 1, "вчера": 1,
+	"позавчера": 2,
+}
+
+var weekdayAliases = map[string]time.Weekday{
+	"monday": time.Monday, "mon": time.Monday, "понедельник": time.Monday, "пн": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "вторник": time.Tuesday, "вт": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday, "среда": time.Wednesday, "среду": time.Wednesday, "ср": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "четверг": time.Thursday, "чт": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday, "пятница": time.Friday, "пятницу": time.Friday, "пт": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday, "суббота": time.Saturday, "субботу": time.Saturday, "сб": time.Saturday,
+	"sunday": time.Sunday, "sun": time.Sunday, "воскресенье": time.Sunday, "вс": time.Sunday,
+}
+
+// addNumber is a number from /add which is a sum, an id of a category or a short date like 12.03.
+type addNumber struct {
+	token string
+	value decimal.Decimal
+	// число вида 12.03 может быть и суммой, и датой
+	date    time.Time
+	maybeDt bool
+}
+
+// parseAdd parses words of /add in any order, the second result is a question to the user if the input is ambiguous.
+func parseAdd(l i18n.Lang, text string, now time.Time, findCategory func(string) (model.Category, bool)) (addArgs, string) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	args := addArgs{words: []string{}}
+	var (
+		found      bool
+		dates      []string
+		dateValues []time.Time
+		currencies []string
+		sums       []addNumber
+		numbers    []addNumber
+		unknown    []string
+	)
+	firstCategory := func(token string) (model.Category, bool) {
+		if found {
+			return model.Category{}, false
+		}
+		return findCategory(token)
+	}
+	addDate := func(token string, dt time.Time) {
+		dates = append(dates, token)
+		dateValues = append(dateValues, dt)
+	}
+
+	tokens := strings.Fields(text)
+	for i := 0; i < len(tokens); i++ {
+		token, lower := tokens[i], strings.ToLower(tokens[i])
+		if _, ok := model.ParseTag(token); ok {
+			args.words = append(args.words, token)
+		} else if days, ok := dayAliases[lower]; ok {
+			addDate(token, today.AddDate(0, 0, -days))
+		} else if wd, ok := weekdayAliases[lower]; ok {
+			addDate(token, lastWeekday(today, wd))
+		} else if dt, ok := parseFullDate(lower); ok {
+			addDate(token, dt)
+		} else if code, ok := currencyAliases[lower]; ok {
+			currencies = append(currencies, code)
+		} else if dt, sep, ok := parseShortDate(lower, today); ok && sep != "." {
+			addDate(token, dt)
+		} else if m := amountRe.FindStringSubmatch(lower); m != nil && currencyAffix(m[1], m[3]) {
+			n := addNumber{token: token, value: decimal.RequireFromString(strings.Replace(m[2], ",", ".", 1))}
+			if m[1] == "" && m[3] == "" {
+				n.date, _, n.maybeDt = parseShortDate(lower, today)
+				numbers = append(numbers, n)
+			} else {
+				sums = append(sums, n)
+				currencies = append(currencies, currencyAliases[m[1]+m[3]])
+			}
+		} else if cat, ok := firstCategory(token); ok {
+			args.category, found = cat, true
+		} else {
+			unknown = append(unknown, token)
+			args.words = append(args.words, token)
+		}
+	}
+
+	currencies = uniqueStrings(currencies)
+	if len(currencies) > 1 {
+		return addArgs{}, l.T("which currency: %v?", strings.Join(currencies, l.T(" or ")))
+	} else if len(currencies) == 1 {
+		args.currencyCode = currencies[0]
+	}
+	if len(sums) > 1 {
+		return addArgs{}, l.T("which one is the sum: %v?", joinNumbers(l, sums))
+	}
+
+	// старый формат /add [id категории] [сумма]: первое целое число - id категории
+	if !found && (len(numbers) > 1 || (len(numbers) == 1 && len(sums) == 1)) && !strings.ContainsAny(numbers[0].token, ".,") {
+		if cat, ok := findCategory(numbers[0].token); ok {
+			args.category, found = cat, true
+			numbers = numbers[1:]
+		}
+	}
+
+	if len(sums) == 0 {
+		switch {
+		case len(numbers) == 0:
+			return addArgs{}, l.T("how much? e.g. /add 350 food")
+		case len(numbers) == 1:
+			sums, numbers = numbers, nil
+		default:
+			// из нескольких чисел датой может быть только одно, и только если другой даты нет
+			plain := make([]addNumber, 0, len(numbers))
+			maybeDates := make([]addNumber, 0, len(numbers))
+			for i := 0; i < len(numbers); i++ {
+				if numbers[i].maybeDt {
+					maybeDates = append(maybeDates, numbers[i])
+				} else {
+					plain = append(plain, numbers[i])
+				}
+			}
+			if len(plain) != 1 || len(maybeDates) != 1 || len(dates) > 0 {
+				return addArgs{}, l.T("which one is the sum: %v?", joinNumbers(l, numbers))
+			}
+			sums, numbers = plain, maybeDates
+		}
+	}
+	args.sum = sums[0].value
+	for i := 0; i < len(numbers); i++ {
+		if numbers[i].maybeDt && len(dates) == 0 {
+			addDate(numbers[i].token, numbers[i].date)
+		} else {
+			unknown = append(unknown, numbers[i].token)
+			args.words = append(args.words, numbers[i].token)
+		}
+	}
+
+	if len(dates) > 1 {
+		return addArgs{}, l.T("which date: %v?", strings.Join(dates, l.T(" or ")))
+	} else if len(dates) == 1 {
+		args.date, args.dateSet = dateValues[0], true
+	} else {
+		args.date = today
+	}
+
+	if !args.sum.IsPositive() {
+		return addArgs{}, l.T("sum must be greater than zero")
+	}
+	if !found {
+		args.needCategory = true
+		if len(unknown) > 0 {
+			return args, l.T("which category is it? %q is unknown, see /categories", unknown[0])
+		}
+		return args, l.T("which category is it? e.g. /add 350 food, see /categories")
+	}
+	return args, ""
+}
+
+// parseDate parses a single date as /add does, the short date may have any separator here.
+func parseDate(text string, now time.Time) (time.Time, bool) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	lower := strings.ToLower(strings.TrimSpace(text))
+	if days, ok := dayAliases[lower]; ok {
+		return today.AddDate(0, 0, -days), true
+	} else if wd, ok := weekdayAliases[lower]; ok {
+		return lastWeekday(today, wd), true
+	} else if dt, ok := parseFullDate(lower); ok {
+		return dt, true
+	}
+	dt, _, ok := parseShortDate(lower, today)
+	return dt, ok
+}
+
+// lastWeekday returns the latest day of the week not after today.
+func lastWeekday(today time.Time, wd time.Weekday) time.Time {
+	return today.AddDate(0, 0, -((int(today.Weekday()) - int(wd) + 7) % 7))
+}
+
+func currencyAffix(prefix, suffix string) bool {
+	if prefix != "" && suffix != "" {
+		return false
+	}
+	_, ok := currencyAliases[prefix+suffix]
+	return prefix+suffix == "" || ok
+}
+
+func parseFullDate(s string) (time.Time, bool) {
+	m := fullDateRe.FindStringSubmatch(s)
+	if m == nil {
+		return time.Time{}, false
+	}
+	year, _ := strconv.Atoi(m[3])
+	if len(m[3]) == 2 {
+		year += 2000
+	}
+	return validDate(m[1], m[2], year)
+}
+
+// parseShortDate parses a date without a year, the date is in the past year if it is not come yet.
+func parseShortDate(s string, today time.Time) (time.Time, string, bool) {
+	m := shortDateRe.FindStringSubmatch(s)
+	if m == nil {
+		return time.Time{}, "", false
+	}
+	dt, ok := validDate(m[1], m[3], today.Year())
+	if ok && dt.After(today) {
+		dt, ok = validDate(m[1], m[3], today.Year()-1)
+	}
+	return dt, m[2], ok
+}
+
+func validDate(dayStr, monthStr string, year int) (time.Time, bool) {
+	day, _ := strconv.Atoi(dayStr)
+	month, _ := strconv.Atoi(monthStr)
+	dt := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	// time.Date нормализует 31.02 в 03.03, такие даты не принимаем
+	if dt.Day() != day || int(dt.Month()) != month {
+		return time.Time{}, false
+	}
+	return dt, true
+}
+
+func joinNumbers(l i18n.Lang, numbers []addNumber) string {
+	tokens := make([]string, len(numbers))
+	for i := 0; i < len(numbers); i++ {
+		tokens[i] = numbers[i].token
+	}
+	return strings.Join(tokens, l.T(" or "))
+}
+
+func uniqueStrings(strs []string) []string {
+	r := make([]string, 0, len(strs))
+	seen := make(map[string]bool)
+	for i := 0; i < len(strs); i++ {
+		if !seen[strs[i]] {
+			seen[strs[i]] = true
+			r = append(r, strs[i])
+		}
+	}
+	return r
+}
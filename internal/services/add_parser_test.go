@@ -0,0 +1,73 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/i18n"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
+)
+
+func Test_parseAdd(t *testing.T) {
+	// среда
+	now := time.Date(2026, 3, 18, 15, 0, 0, 0, time.Local)
+	categories := map[string]model.Category{
+		"1":     {Id: 1, Name: "food"},
+		"food":  {Id: 1, Name: "food"},
+		"такси": {Id: 2, Name: "такси"},
+	}
+	find := func(ref string) (model.Category, bool) {
+		c, ok := categories[ref]
+		return c, ok
+	}
+	day := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name     string
+		text     string
+		sum      string
+		currency string
+		category int
+		date     time.Time
+		words    []string
+		question string
+	}{
+		{name: "sum then category", text: "350 food", sum: "350", category: 1, date: day(2026, 3, 18)},
+		{name: "comma and yesterday", text: "food 350,50 вчера", sum: "350.5", category: 1, date: day(2026, 3, 17)},
+		{name: "short date", text: "1200 такси 12.03", sum: "1200", category: 2, date: day(2026, 3, 12)},
+		{name: "short date of the last year", text: "1200 такси 20.12", sum: "1200", category: 2, date: day(2025, 12, 20)},
+		{name: "old format", text: "1 1 01-01-2000", sum: "1", category: 1, date: day(2000, 1, 1)},
+		{name: "double space", text: "food  350", sum: "350", category: 1, date: day(2026, 3, 18)},
+		{name: "weekday", text: "food 100 monday", sum: "100", category: 1, date: day(2026, 3, 16)},
+		{name: "same weekday is today", text: "food 100 среда", sum: "100", category: 1, date: day(2026, 3, 18)},
+		{name: "dollar suffix", text: "20$ такси", sum: "20", currency: "usd", category: 2, date: day(2026, 3, 18)},
+		{name: "currency word", text: "food 15 eur", sum: "15", currency: "eur", category: 1, date: day(2026, 3, 18)},
+		{name: "note and tags", text: "food 300 кофе с собой #work", sum: "300", category: 1, date: day(2026, 3, 18), words: []string{"кофе", "с", "собой", "#work"}},
+		{name: "dot decimal without date", text: "food 12.50", sum: "12.5", category: 1, date: day(2026, 3, 18)},
+		{name: "no sum", text: "food", question: "how much? e.g. /add 350 food"},
+		{name: "two sums", text: "food 350 200", question: "which one is the sum: 350 or 200?"},
+		{name: "two currencies", text: "food 20$ eur", question: "which currency: usd or eur?"},
+		{name: "two dates", text: "food 20 вчера 12-03", question: "which date: вчера or 12-03?"},
+		{name: "unknown category", text: "350 coffee", question: `which category is it? "coffee" is unknown, see /categories`},
+		{name: "zero sum", text: "food 0", question: "sum must be greater than zero"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, question := parseAdd(i18n.English, tt.text, now, find)
+			assert.Equal(t, tt.question, question)
+			if tt.question != "" {
+				return
+			}
+			assert.Equal(t, tt.sum, args.sum.String())
+			assert.Equal(t, tt.currency, args.currencyCode)
+			assert.Equal(t, tt.category, args.category.Id)
+			assert.Equal(t, tt.date, args.date)
+			if tt.words == nil {
+				tt.words = []string{}
+			}
+			assert.Equal(t, tt.words, args.words)
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"math"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
+)
+
+// угадывания с меньшей вероятностью пользователь подтверждает сам
+var minGuessConfidence = 0.8
+
+type categoryRuleStorageI interface {
+	Add(r model.CategoryRule) (int64, error)
+	GetAll(userId int64) ([]model.CategoryRule, error)
+	Delete(userId int64, id int64) error
+}
+
+type categoryModelStorageI interface {
+	GetCategoryNotes(ctx context.Context, userId int64) ([]model.CategoryNotes, error)
+	GetWordCounts(ctx context.Context, userId int64, words []string) ([]model.WordCount, error)
+	GetVocabularySize(ctx context.Context, userId int64) (int, error)
+}
+
+// Categorizer guesses the category of a note or a merchant by the rules of the user,
+// and if none matches - by naive Bayes over the notes of the previous spendings.
+type Categorizer struct {
+	ruleStorage  categoryRuleStorageI
+	modelStorage categoryModelStorageI
+}
+
+func NewCategorizer(ruleStorage categoryRuleStorageI, modelStorage categoryModelStorageI) *Categorizer {
+	return &Categorizer{ruleStorage: ruleStorage, modelStorage: modelStorage}
+}
+
+// Guess returns false if neither a rule matches nor any word of the text was met before.
+func (s *Categorizer) Guess(ctx context.Context, userId int64, text string) (model.CategoryGuess, bool, error) {
+	span, childCtx := opentracing.StartSpanFromContext(ctx, "categorizer: guessing category")
+	defer span.Finish()
+
+	rules, err := s.ruleStorage.GetAll(userId)
+	if err != nil {
+		ext.Error.Set(span, true)
+		return model.CategoryGuess{}, false, err
+	}
+	for i := 0; i < len(rules); i++ {
+		if rules[i].Matches(text) {
+			return model.CategoryGuess{
+				CategoryId: rules[i].CategoryId, CategoryName: rules[i].CategoryName, Confidence: 1, Source: model.RuleGuess,
+			}, true, nil
+		}
+	}
+
+	guess, ok, err := s.guessByHistory(childCtx, userId, model.NoteWords(text))
+	if err != nil {
+		ext.Error.Set(span, true)
+	}
+	return guess, ok, err
+}
+
+func (s *Categorizer) guessByHistory(ctx context.Context, userId int64, words []string) (model.CategoryGuess, bool, error) {
+	if len(words) == 0 {
+		return model.CategoryGuess{}, false, nil
+	}
+	counts, err := s.modelStorage.GetWordCounts(ctx, userId, words)
+	if err != nil || len(counts) == 0 {
+		return model.CategoryGuess{}, false, err
+	}
+	categories, err := s.modelStorage.GetCategoryNotes(ctx, userId)
+	if err != nil || len(categories) == 0 {
+		return model.CategoryGuess{}, false, err
+	}
+	vocabulary, err := s.modelStorage.GetVocabularySize(ctx, userId)
+	if err != nil {
+		return model.CategoryGuess{}, false, err
+	}
+	return classify(words, counts, categories, vocabulary)
+}
+
+// classify is multinomial naive Bayes with Laplace smoothing, the confidence is the posterior of the best category.
+func classify(words []string, counts []model.WordCount, categories []model.CategoryNotes, vocabulary int) (model.CategoryGuess, bool, error) {
+	wordCounts := make(map[int]map[string]int, len(categories))
+	for _, c := range counts {
+		if wordCounts[c.CategoryId] == nil {
+			wordCounts[c.CategoryId] = make(map[string]int)
+		}
+		wordCounts[c.CategoryId][c.Word] = c.Count
+	}
+	totalNotes := 0
+	for _, c := range categories {
+		totalNotes += c.Notes
+	}
+	if totalNotes == 0 {
+		return model.CategoryGuess{}, false, nil
+	}
+
+	scores := make([]float64, len(categories))
+	best := 0
+	for i, c := range categories {
+		scores[i] = math.Log(float64(c.Notes) / float64(totalNotes))
+		for _, w := range words {
+			scores[i] += math.Log(float64(wordCounts[c.CategoryId][w]+1) / float64(c.Words+vocabulary))
+		}
+		if scores[i] > scores[best] {
+			best = i
+		}
+	}
+	// softmax в логарифмах, чтобы не уйти в ноль на длинных заметках
+	sum := 0.0
+	for i := 0; i < len(scores); i++ {
+		sum += math.Exp(scores[i] - scores[best])
+	}
+	return model.CategoryGuess{
+		CategoryId:   categories[best].CategoryId,
+		CategoryName: categories[best].CategoryName,
+		Confidence:   1 / sum,
+		Source:       model.HistoryGuess,
+	}, true, nil
+}
+
+// IsCertain tells whether the guess can be used without asking the user.
+func IsCertain(guess model.CategoryGuess) bool {
+	return guess.Source == model.RuleGuess || guess.Confidence >= minGuessConfidence
+}
+
+func (s *Categorizer) AddRule(userId int64, categoryId int, kind model.RuleKind, pattern string) (model.CategoryRule, error) {
+	r := model.CategoryRule{UserId: userId, Kind: kind, Pattern: pattern, CategoryId: categoryId}
+	if err := r.Validate(); err != nil {
+		return model.CategoryRule{}, err
+	}
+	var err error
+	if r.Id, err = s.ruleStorage.Add(r); err != nil {
+		return model.CategoryRule{}, err
+	}
+	return r, nil
+}
+
+func (s *Categorizer) GetRules(userId int64) ([]model.CategoryRule, error) {
+	return s.ruleStorage.GetAll(userId)
+}
+
+func (s *Categorizer) DeleteRule(userId int64, id int64) error {
+	return s.ruleStorage.Delete(userId, id)
+}
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
+)
+
+func Test_classify(t *testing.T) {
+	categories := []model.CategoryNotes{
+		{CategoryId: 1, CategoryName: "food", Notes: 10, Words: 20},
+		{CategoryId: 2, CategoryName: "taxi", Notes: 5, Words: 10},
+	}
+	counts := []model.WordCount{
+		{CategoryId: 1, Word: "пятерочка", Count: 8},
+		{CategoryId: 2, Word: "яндекс", Count: 5},
+		{CategoryId: 1, Word: "яндекс", Count: 1},
+	}
+
+	guess, ok, err := classify([]string{"пятерочка"}, counts, categories, 12)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "food", guess.CategoryName)
+	assert.True(t, IsCertain(guess))
+
+	guess, ok, err = classify([]string{"яндекс"}, counts, categories, 12)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "taxi", guess.CategoryName)
+	assert.False(t, IsCertain(guess))
+}
@@ -1,25 +1,160 @@
 package services
 
-import "gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
+)
+
+var (
+	ErrUnknownCategory = errors.New("unknown category")
+	ErrCategoryExists  = errors.New("category already exists")
+	ErrSameCategory    = errors.New("can't merge category into itself")
+	ErrNotOwner        = errors.New("categories are shared by all users, only the owner of the bot can change them")
+)
 
 type categoryStorage interface {
 	GetAll() ([]model.Category, error)
+	Add(name string) (model.Category, error)
+	Rename(id int, name string) error
+	Archive(id int) error
+	Merge(fromId int, toId int) error
 }
 
 type categoryService struct {
 	categoryStorage categoryStorage
 	categories      []model.Category
+	categoriesM     sync.RWMutex
+	// категории общие, поэтому менять их может только владелец бота
+	ownerUserId int64
 }
 
-func NewCategoryService(s categoryStorage) (*categoryService, error) {
+func NewCategoryService(s categoryStorage, ownerUserId int64) (*categoryService, error) {
 	cts, err := s.GetAll()
 	if err != nil {
 		return nil, err
 	}
 
-	return &categoryService{s, cts}, nil
+	return &categoryService{categoryStorage: s, categories: cts, ownerUserId: ownerUserId}, nil
 }
 
 func (s *categoryService) GetAll() []model.Category {
+	s.categoriesM.RLock()
+	defer s.categoriesM.RUnlock()
 	return s.categories
 }
+
+// Find looks up an active category by its id or by its name (case insensitive).
+func (s *categoryService) Find(ref string) (model.Category, bool) {
+	s.categoriesM.RLock()
+	defer s.categoriesM.RUnlock()
+	return s.find(ref)
+}
+
+func (s *categoryService) find(ref string) (model.Category, bool) {
+	id, err := strconv.Atoi(ref)
+	for i := 0; i < len(s.categories); i++ {
+		if (err == nil && s.categories[i].Id == id) || strings.EqualFold(s.categories[i].Name, ref) {
+			return s.categories[i], true
+		}
+	}
+	return model.Category{}, false
+}
+
+func (s *categoryService) Add(userId int64, name string) (model.Category, error) {
+	if userId != s.ownerUserId {
+		return model.Category{}, ErrNotOwner
+	}
+	s.categoriesM.Lock()
+	defer s.categoriesM.Unlock()
+	if _, ok := s.find(name); ok {
+		return model.Category{}, ErrCategoryExists
+	}
+	if _, err := strconv.Atoi(name); err == nil {
+		return model.Category{}, errors.New("category name can't be a number")
+	}
+
+	c, err := s.categoryStorage.Add(name)
+	if err != nil {
+		return model.Category{}, err
+	}
+	return c, s.refresh()
+}
+
+func (s *categoryService) Rename(userId int64, ref string, name string) error {
+	if userId != s.ownerUserId {
+		return ErrNotOwner
+	}
+	s.categoriesM.Lock()
+	defer s.categoriesM.Unlock()
+	c, ok := s.find(ref)
+	if !ok {
+		return ErrUnknownCategory
+	}
+	if other, ok := s.find(name); ok && other.Id != c.Id {
+		return ErrCategoryExists
+	}
+	if _, err := strconv.Atoi(name); err == nil {
+		return errors.New("category name can't be a number")
+	}
+
+	if err := s.categoryStorage.Rename(c.Id, name); err != nil {
+		return err
+	}
+	return s.refresh()
+}
+
+func (s *categoryService) Archive(userId int64, ref string) error {
+	if userId != s.ownerUserId {
+		return ErrNotOwner
+	}
+	s.categoriesM.Lock()
+	defer s.categoriesM.Unlock()
+	c, ok := s.find(ref)
+	if !ok {
+		return ErrUnknownCategory
+	}
+
+	if err := s.categoryStorage.Archive(c.Id); err != nil {
+		return err
+	}
+	return s.refresh()
+}
+
+// Merge moves the spendings of all users from one category to another and archives the first one.
+func (s *categoryService) Merge(userId int64, fromRef string, toRef string) error {
+	if userId != s.ownerUserId {
+		return ErrNotOwner
+	}
+	s.categoriesM.Lock()
+	defer s.categoriesM.Unlock()
+	from, ok := s.find(fromRef)
+	if !ok {
+		return ErrUnknownCategory
+	}
+	to, ok := s.find(toRef)
+	if !ok {
+		return ErrUnknownCategory
+	}
+	if from.Id == to.Id {
+		return ErrSameCategory
+	}
+
+	if err := s.categoryStorage.Merge(from.Id, to.Id); err != nil {
+		return err
+	}
+	return s.refresh()
+}
+
+// refresh must be called under the write lock
+func (s *categoryService) refresh() error {
+	cts, err := s.categoryStorage.GetAll()
+	if err != nil {
+		return err
+	}
+	s.categories = cts
+	return nil
+}
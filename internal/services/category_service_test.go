@@ -0,0 +1,42 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
+)
+
+type fakeCategoryStorage struct {
+	categories []model.Category
+	merged     [][2]int
+}
+
+func (s *fakeCategoryStorage) GetAll() ([]model.Category, error) { return s.categories, nil }
+func (s *fakeCategoryStorage) Add(name string) (model.Category, error) {
+	c := model.Category{Id: len(s.categories), Name: name}
+	s.categories = append(s.categories, c)
+	return c, nil
+}
+func (s *fakeCategoryStorage) Rename(id int, name string) error { return nil }
+func (s *fakeCategoryStorage) Archive(id int) error             { return nil }
+func (s *fakeCategoryStorage) Merge(fromId int, toId int) error {
+	s.merged = append(s.merged, [2]int{fromId, toId})
+	return nil
+}
+
+func Test_categoryService_shouldLetOnlyOwnerChangeCategories(t *testing.T) {
+	storage := &fakeCategoryStorage{categories: []model.Category{{Id: 0, Name: "food"}, {Id: 1, Name: "other"}}}
+	s, err := NewCategoryService(storage, 1)
+	assert.NoError(t, err)
+
+	_, err = s.Add(2, "taxi")
+	assert.ErrorIs(t, err, ErrNotOwner)
+	assert.ErrorIs(t, s.Rename(2, "food", "meal"), ErrNotOwner)
+	assert.ErrorIs(t, s.Archive(2, "food"), ErrNotOwner)
+	assert.ErrorIs(t, s.Merge(2, "other", "food"), ErrNotOwner)
+	assert.Empty(t, storage.merged)
+
+	assert.NoError(t, s.Merge(1, "other", "food"))
+	assert.Equal(t, [][2]int{{1, 0}}, storage.merged)
+}
@@ -2,28 +2,41 @@ package services
 
 import (
 	"context"
-	"encoding/json"
-	"io"
-	"net/http"
+	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/shopspring/decimal"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/i18n"
 	. "gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/logger"
 	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/observability"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/rates"
 	"go.uber.org/zap"
 )
 
-var url = "https://api.currencyapi.com/v3/latest?apikey=dO62Nn8Y3f18mpvbN6ypoaBrzEtKF8Fkd8bdavYy&currencies=EUR%2CUSD%2CCNY&base_currency=RUB"
-
 type currencyService struct {
 	jobMutex          sync.Once
 	currencies        map[string]model.Currency
 	currenciesM       sync.RWMutex
 	currenciesStorage currenciesStorage
+	provider          rates.RateProvider
+	// отслеживаемые валюты, их курсы загружаются к model.BaseCurrency
+	codes []string
+	// курс старше maxRateAge считается устаревшим, 0 - не проверять;
+	// траты по устаревшему курсу отклоняются, если refuseStaleRates, иначе только предупреждаем
+	maxRateAge       time.Duration
+	refuseStaleRates bool
 }
 
-func NewCurrencyService(currenciesStorage currenciesStorage) (*currencyService, error) {
+// как часто обновлять метрику возраста курсов
+const rateAgeReportInterval = time.Minute
+
+// NewCurrencyService loads known currencies, rates are fetched from the provider at once if there are none yet.
+func NewCurrencyService(currenciesStorage currenciesStorage, provider rates.RateProvider, codes []string,
+	maxRateAge time.Duration, refuseStaleRates bool) (*currencyService, error) {
 	currencies, err := currenciesStorage.GetCurrencies()
 	if err != nil {
 		return nil, err
@@ -34,14 +47,26 @@ func NewCurrencyService(currenciesStorage currenciesStorage) (*currencyService,
 		for i := 0; i < len(currencies); i++ {
 			mcurrencies[currencies[i].Code] = currencies[i]
 		}
+		// курсы в базе загружены к той валюте, в которой хранятся суммы, её курс к себе - 1
+		if base, ok := mcurrencies[model.BaseCurrency]; !ok || !base.Ratio.Equal(decimal.NewFromInt(1)) {
+			return nil, fmt.Errorf("sums are stored in another currency than the base one %v, it can't be changed for existing data", model.BaseCurrency)
+		}
 		return &currencyService{
 			currencies:        mcurrencies,
 			currenciesStorage: currenciesStorage,
+			provider:          provider,
+			codes:             lowerCodes(codes),
+			maxRateAge:        maxRateAge,
+			refuseStaleRates:  refuseStaleRates,
 		}, nil
 	} else {
 		cs := &currencyService{
 			currencies:        map[string]model.Currency{},
 			currenciesStorage: currenciesStorage,
+			provider:          provider,
+			codes:             lowerCodes(codes),
+			maxRateAge:        maxRateAge,
+			refuseStaleRates:  refuseStaleRates,
 		}
 		if err := cs.updateCurrencies(context.Background()); err != nil {
 			return nil, err
@@ -51,10 +76,12 @@ func NewCurrencyService(currenciesStorage currenciesStorage) (*currencyService,
 }
 
 type currenciesStorage interface {
-	GetCurrentCurrency(ctx context.Context) (model.Currency, error)
+	GetCurrentCurrency(ctx context.Context, userId int64) (model.Currency, error)
 	GetCurrencies() ([]model.Currency, error)
 	UpdateCurrencies([]model.Currency) error
-	UpdateCurrentCurrency(name string) error
+	UpdateCurrentCurrency(userId int64, name string) error
+	GetRate(ctx context.Context, code string, date time.Time) (decimal.Decimal, error)
+	GetRates(ctx context.Context, code string, startAt, endAt time.Time) ([]model.CurrencyRate, error)
 }
 
 func (cs *currencyService) GetAll() []model.Currency {
@@ -64,6 +91,7 @@ func (cs *currencyService) GetAll() []model.Currency {
 	for _, v := range cs.currencies {
 		result = append(result, v)
 	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Code < result[j].Code })
 	return result
 }
 
@@ -74,14 +102,98 @@ func (cs *currencyService) CheckCurrencyCode(code string) bool {
 	return ok
 }
 
-func (cs *currencyService) GetCurrentCurrency(ctx context.Context) (model.Currency, error) {
-	currentCurrency, err := cs.currenciesStorage.GetCurrentCurrency(ctx)
+func (cs *currencyService) GetCurrency(code string) (model.Currency, error) {
+	cs.currenciesM.RLock()
+	defer cs.currenciesM.RUnlock()
+	currency, ok := cs.currencies[code]
+	if !ok {
+		return model.Currency{}, model.ErrWrongCurrency
+	}
+	return currency, nil
+}
+
+// GetRate returns the rate of the currency to the base currency on the date.
+func (cs *currencyService) GetRate(ctx context.Context, code string, date time.Time) (decimal.Decimal, error) {
+	if !cs.CheckCurrencyCode(code) {
+		return decimal.Decimal{}, model.ErrWrongCurrency
+	}
+	return cs.currenciesStorage.GetRate(ctx, code, date)
+}
+
+// GetHistory returns the rates of the currency to the base currency in the period.
+func (cs *currencyService) GetHistory(ctx context.Context, code string, start, end time.Time) ([]model.CurrencyRate, error) {
+	if !cs.CheckCurrencyCode(code) {
+		return nil, model.ErrWrongCurrency
+	}
+	return cs.currenciesStorage.GetRates(ctx, code, start, end)
+}
+
+// Convert converts the value from one currency to another at the current rates.
+func (cs *currencyService) Convert(value decimal.Decimal, from, to string) (decimal.Decimal, error) {
+	f, err := cs.GetCurrency(from)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	t, err := cs.GetCurrency(to)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	if f.Ratio.IsZero() {
+		return decimal.Decimal{}, model.ErrWrongCurrency
+	}
+	return value.Div(f.Ratio).Mul(t.Ratio), nil
+}
+
+// CheckRate reports whether a spending of the user in the currency on the date would be converted at a rate
+// older than the max age, an empty code means the current currency of the user. If stale rates are refused,
+// the error wrapping model.ErrStaleRate is returned instead.
+func (cs *currencyService) CheckRate(ctx context.Context, userId int64, code string, date time.Time) (model.Currency, bool, error) {
+	var (
+		cur model.Currency
+		err error
+	)
+	if code == "" {
+		cur, err = cs.GetCurrentCurrency(ctx, userId)
+	} else {
+		cur, err = cs.GetCurrency(code)
+	}
+	if err != nil {
+		return model.Currency{}, false, err
+	}
+	if !cs.isStale(cur, date, time.Now()) {
+		return cur, false, nil
+	}
+	if cs.refuseStaleRates {
+		return cur, true, i18n.Errorf("%w: the %v rate was loaded %v hours ago, try again later",
+			model.ErrStaleRate, cur.Code, int64(time.Since(cur.UpdatedAt).Hours()))
+	}
+	return cur, true, nil
+}
+
+// isStale reports whether the spending on the date is converted at the last loaded rate and it is older than the max age,
+// spendings before the day of the last load have their own historical rates.
+func (cs *currencyService) isStale(cur model.Currency, date, now time.Time) bool {
+	if cs.maxRateAge == 0 || cur.Code == model.BaseCurrency {
+		return false
+	}
+	if cur.UpdatedAt.IsZero() {
+		return true
+	}
+	y, m, d := cur.UpdatedAt.In(date.Location()).Date()
+	if date.Before(time.Date(y, m, d, 0, 0, 0, 0, date.Location())) {
+		return false
+	}
+	return now.Sub(cur.UpdatedAt) > cs.maxRateAge
+}
+
+func (cs *currencyService) GetCurrentCurrency(ctx context.Context, userId int64) (model.Currency, error) {
+	currentCurrency, err := cs.currenciesStorage.GetCurrentCurrency(ctx, userId)
 	if err != nil {
 		return model.Currency{}, err
 	}
 	return currentCurrency, nil
 }
-func (cs *currencyService) UpdateCurrentCurrency(newCur string) error {
+func (cs *currencyService) UpdateCurrentCurrency(userId int64, newCur string) error {
 	cs.currenciesM.RLock()
 	currency, ok := cs.currencies[newCur]
 	cs.currenciesM.RUnlock()
@@ -89,12 +201,15 @@ func (cs *currencyService) UpdateCurrentCurrency(newCur string) error {
 		return model.ErrWrongCurrency
 	}
 
-	return cs.currenciesStorage.UpdateCurrentCurrency(currency.Code)
+	return cs.currenciesStorage.UpdateCurrentCurrency(userId, currency.Code)
 }
 
 func (s *currencyService) RunUpdateCurrenciesDaemon(ctx context.Context, updateInterval time.Duration) {
 	go s.jobMutex.Do(func() {
 		ticker := time.NewTicker(updateInterval)
+		ageTicker := time.NewTicker(rateAgeReportInterval)
+		defer ageTicker.Stop()
+		s.reportRateAge(time.Now())
 
 		for {
 			select {
@@ -102,6 +217,9 @@ func (s *currencyService) RunUpdateCurrenciesDaemon(ctx context.Context, updateI
 				if err := s.updateCurrencies(ctx); err != nil {
 					Log.Error("error on update currencies", zap.Error(err))
 				}
+				s.reportRateAge(time.Now())
+			case now := <-ageTicker.C:
+				s.reportRateAge(now)
 			case <-ctx.Done():
 				Log.Info("cancel update currencies job")
 				return
@@ -111,35 +229,16 @@ func (s *currencyService) RunUpdateCurrenciesDaemon(ctx context.Context, updateI
 }
 
 func (s *currencyService) updateCurrencies(ctx context.Context) error {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-
+	rs, err := s.provider.Rates(ctx, model.BaseCurrency, s.codes)
 	if err != nil {
 		return err
 	}
-	req.Header.Add("accept", "application/json")
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return err
-	}
-
-	rs := &dataResponse{}
-	if err := json.Unmarshal(body, &rs); err != nil {
-		return err
-	}
-	if len(rs.Data) == 0 {
-		return nil
-	}
-	arr := make([]model.Currency, 0, len(rs.Data))
-	mapCt := make(map[string]model.Currency)
-	for code, v := range rs.Data {
-		m := *model.NewCurrency(code, v.Value)
-		arr = append(arr, m)
-		mapCt[m.Code] = m
+	arr := make([]model.Currency, 0, len(rs))
+	now := time.Now()
+	for code, v := range rs {
+		c := model.NewCurrency(code, v)
+		c.UpdatedAt = now
+		arr = append(arr, *c)
 	}
 
 	s.currenciesM.Lock()
@@ -147,14 +246,30 @@ func (s *currencyService) updateCurrencies(ctx context.Context) error {
 	if err := s.currenciesStorage.UpdateCurrencies(arr); err != nil {
 		return err
 	}
-	s.currencies = mapCt
+	// отслеживаемые валюты могли поменяться в конфиге, поэтому обновляем курсы, а не заменяем весь список
+	for i := 0; i < len(arr); i++ {
+		s.currencies[arr[i].Code] = arr[i]
+	}
 
 	return nil
 }
 
-type dataResponse struct {
-	Data map[string]currencyResponse `json:"data"`
+// reportRateAge sets the age of the loaded rates, so that a failing update job is seen before the rates are too old.
+func (s *currencyService) reportRateAge(now time.Time) {
+	s.currenciesM.RLock()
+	defer s.currenciesM.RUnlock()
+	for code, c := range s.currencies {
+		if code == model.BaseCurrency || c.UpdatedAt.IsZero() {
+			continue
+		}
+		observability.RateAge.WithLabelValues(code).Set(now.Sub(c.UpdatedAt).Seconds())
+	}
 }
-type currencyResponse struct {
-	Value decimal.Decimal `json:"value"`
+
+func lowerCodes(codes []string) []string {
+	r := make([]string, len(codes))
+	for i := range codes {
+		r[i] = strings.ToLower(codes[i])
+	}
+	return r
 }
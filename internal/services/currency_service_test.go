@@ -0,0 +1,36 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
+)
+
+// fakeCurrenciesStorage keeps the loaded rates only
+type fakeCurrenciesStorage struct {
+	currenciesStorage
+	currencies []model.Currency
+}
+
+func (s *fakeCurrenciesStorage) GetCurrencies() ([]model.Currency, error) {
+	return s.currencies, nil
+}
+
+func Test_NewCurrencyService_shouldRefuseAnotherBaseCurrency(t *testing.T) {
+	defer func(base string) { model.BaseCurrency = base }(model.BaseCurrency)
+	storage := &fakeCurrenciesStorage{currencies: []model.Currency{
+		{Code: "rub", Ratio: decimal.NewFromInt(1)},
+		{Code: "eur", Ratio: decimal.RequireFromString("0.01")},
+	}}
+
+	model.BaseCurrency = "rub"
+	_, err := NewCurrencyService(storage, nil, []string{"eur"}, 0, false)
+	assert.NoError(t, err)
+
+	// суммы в базе хранятся в рублях, сменить базовую валюту на евро нельзя
+	model.BaseCurrency = "eur"
+	_, err = NewCurrencyService(storage, nil, []string{"rub"}, 0, false)
+	assert.Error(t, err)
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/i18n"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
+)
+
+// dateKeyboard sends the english words, parseDate understands them in any language.
+func dateKeyboard(l i18n.Lang) model.Keyboard {
+	return model.Keyboard{
+		{{Text: l.T("today"), Data: "today"}, {Text: l.T("yesterday"), Data: "yesterday"}},
+	}
+}
+
+// startDialog asks for arguments of /add sent without them one at a time.
+func (s *MessageHandlerService) startDialog(ctx context.Context, userId int64) (reply, error) {
+	return s.askNext(ctx, model.Dialog{UserId: userId, Spending: model.Spending{UserId: userId}, AskDate: true})
+}
+
+// askNext saves the dialog and asks for the next argument, the complete spending is saved and the dialog is closed.
+func (s *MessageHandlerService) askNext(ctx context.Context, dialog model.Dialog) (reply, error) {
+	l := i18n.FromContext(ctx)
+	var r reply
+	switch dialog.Step() {
+	case model.StepSum:
+		r = reply{text: l.T("how much? e.g. 350 or 20$, /cancel to stop")}
+	case model.StepCategory:
+		r = reply{text: l.T("which category is it?"), keyboard: s.dialogCategoryKeyboard()}
+	case model.StepDate:
+		r = reply{text: l.T("which date? e.g. 12.03, today if not set"), keyboard: dateKeyboard(l)}
+	default:
+		// диалог закрываем до сохранения, чтобы повторный ответ не добавил трату дважды
+		if err := s.dialogs.Delete(ctx, dialog.UserId); err != nil {
+			return reply{}, err
+		}
+		return s.saveSpending(ctx, dialog.Spending, l.T("added"))
+	}
+	if err := s.dialogs.Save(ctx, dialog); err != nil {
+		return reply{}, err
+	}
+	return r, nil
+}
+
+// handleDialogAnswer treats a message which is not a command as the answer to the question of the dialog.
+func (s *MessageHandlerService) handleDialogAnswer(ctx context.Context, userId int64, tokens []string) (reply, error) {
+	dialog, ok, err := s.dialogs.Get(ctx, userId)
+	if err != nil {
+		return reply{}, err
+	}
+	if !ok {
+		return reply{text: i18n.FromContext(ctx).T("unknown command, see /help")}, nil
+	}
+	return s.answerDialog(ctx, dialog, tokens[0])
+}
+
+func (s *MessageHandlerService) answerDialog(ctx context.Context, dialog model.Dialog, answer string) (reply, error) {
+	l := i18n.FromContext(ctx)
+	answer = strings.TrimSpace(answer)
+	switch dialog.Step() {
+	case model.StepSum:
+		args, question := parseAdd(l, answer, time.Now(), s.categoryService.Find)
+		if question != "" && !args.needCategory {
+			return reply{text: question}, nil
+		}
+		dialog.Spending.Value, dialog.Spending.CurrencyCode = args.sum, args.currencyCode
+		dialog.Spending.CategoryId, dialog.HasCategory = args.category.Id, !args.needCategory
+		dialog.Spending.SetNote(args.words)
+		if args.dateSet {
+			dialog.Spending.Date, dialog.AskDate = args.date, false
+		}
+	case model.StepCategory:
+		cat, ok := s.categoryService.Find(answer)
+		if !ok {
+			return reply{text: l.T("%v: %v, which category is it?", l.Error(ErrUnknownCategory), answer), keyboard: s.dialogCategoryKeyboard()}, nil
+		}
+		dialog.Spending.CategoryId, dialog.HasCategory, dialog.Guessed = cat.Id, true, false
+	case model.StepDate:
+		dt, ok := parseDate(answer, time.Now())
+		if !ok {
+			return reply{text: l.T("wrong date format, which date? e.g. 12.03 or yesterday"), keyboard: dateKeyboard(l)}, nil
+		}
+		dialog.Spending.Date, dialog.AskDate = dt, false
+	}
+	return s.askNext(ctx, dialog)
+}
+
+// handleCancel drops the dialog at any step.
+func (s *MessageHandlerService) handleCancel(ctx context.Context, userId int64, tokens []string) (string, error) {
+	if err := s.dialogs.Delete(ctx, userId); err != nil {
+		return "", err
+	}
+	return i18n.FromContext(ctx).T("ok, nothing changed"), nil
+}
+
+// dialogCategoryKeyboard offers the categories as answers to the dialog, the answer is the id of the category.
+func (s *MessageHandlerService) dialogCategoryKeyboard() model.Keyboard {
+	return s.categoryKeyboard(func(c model.Category) string { return strconv.Itoa(c.Id) })
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/cache"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
+)
+
+type dialogCache interface {
+	Get(context.Context, string) (string, error)
+	Set(context.Context, string, string, time.Duration) error
+	Delete(context.Context, string) error
+}
+
+// DialogService keeps dialogs in the cache, so they survive restarts of the bot and expire when abandoned.
+type DialogService struct {
+	cache dialogCache
+	ttl   time.Duration
+}
+
+func NewDialogService(cache dialogCache, ttl time.Duration) *DialogService {
+	return &DialogService{cache: cache, ttl: ttl}
+}
+
+func dialogKey(userId int64) string {
+	return fmt.Sprintf("dialog:%d", userId)
+}
+
+func (s *DialogService) Get(ctx context.Context, userId int64) (model.Dialog, bool, error) {
+	v, err := s.cache.Get(ctx, dialogKey(userId))
+	if errors.Is(err, cache.ErrNotFound) {
+		return model.Dialog{}, false, nil
+	}
+	if err != nil {
+		return model.Dialog{}, false, err
+	}
+	var d model.Dialog
+	if err := json.Unmarshal([]byte(v), &d); err != nil {
+		return model.Dialog{}, false, err
+	}
+	return d, true, nil
+}
+
+// Save stores the dialog and restarts its ttl.
+func (s *DialogService) Save(ctx context.Context, dialog model.Dialog) error {
+	v, err := json.Marshal(dialog)
+	if err != nil {
+		return err
+	}
+	return s.cache.Set(ctx, dialogKey(dialog.UserId), string(v), s.ttl)
+}
+
+func (s *DialogService) Delete(ctx context.Context, userId int64) error {
+	return s.cache.Delete(ctx, dialogKey(userId))
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/cache"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
+)
+
+type fakeCache struct {
+	values map[string]string
+	ttls   map[string]time.Duration
+}
+
+func (c *fakeCache) Get(ctx context.Context, key string) (string, error) {
+	v, ok := c.values[key]
+	if !ok {
+		return "", cache.ErrNotFound
+	}
+	return v, nil
+}
+
+func (c *fakeCache) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	c.values[key], c.ttls[key] = value, ttl
+	return nil
+}
+
+func (c *fakeCache) Delete(ctx context.Context, key string) error {
+	delete(c.values, key)
+	return nil
+}
+
+func TestDialogService_shouldKeepDialogInCache(t *testing.T) {
+	c := &fakeCache{values: map[string]string{}, ttls: map[string]time.Duration{}}
+	s := NewDialogService(c, time.Hour)
+
+	_, ok, err := s.Get(context.TODO(), 123)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	spending := model.NewSpending(123, decimal.RequireFromString("350.5"), 0, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	spending.CurrencyCode = "usd"
+	spending.SetNote([]string{"кофе", "#trip"})
+	dialog := model.Dialog{UserId: 123, Spending: spending, AskDate: true}
+	require.NoError(t, s.Save(context.TODO(), dialog))
+	assert.Equal(t, time.Hour, c.ttls["dialog:123"])
+
+	// новый сервис читает то же, что сохранил прежний, как после перезапуска бота
+	got, ok, err := NewDialogService(c, time.Hour).Get(context.TODO(), 123)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, model.StepCategory, got.Step())
+	assert.True(t, got.Spending.Value.Equal(spending.Value))
+	assert.Equal(t, spending.Date, got.Spending.Date)
+	assert.Equal(t, spending.Note, got.Spending.Note)
+	assert.Equal(t, spending.Tags, got.Spending.Tags)
+	assert.Equal(t, "usd", got.Spending.CurrencyCode)
+
+	require.NoError(t, s.Delete(context.TODO(), 123))
+	_, ok, err = s.Get(context.TODO(), 123)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -7,9 +7,11 @@ import (
 	api "gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/api"
 	. "gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/logger"
 	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/observability"
 	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/time_util"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/protobuf/types/known/emptypb"
 	"log"
 	"net"
@@ -21,7 +23,25 @@ type server struct {
 }
 
 func (s *server) Send(ctx context.Context, result *api.ReportResult) (*emptypb.Empty, error) {
-	Log.Info("get report result")
+	// сервис отчётов передаёт трейс запроса в метаданных
+	headers := map[string]string{}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for k, v := range md {
+		if len(v) > 0 {
+			headers[k] = v[0]
+		}
+	}
+	span, spanCtx := observability.StartSpanFromHeaders(ctx, headers, "grpc: report result")
+	defer span.Finish()
+	span.SetTag("request_id", result.RequestId)
+	// ответ пользователю отправляется из другой горутины, трейс передаётся вместе с отчётом
+	trace := observability.Inject(spanCtx)
+
+	Log.Info("get report result", zap.String("requestId", result.RequestId))
+	if result.Error != "" {
+		s.resultCh <- &model.Report{UserId: result.UserId, RequestId: result.RequestId, Error: result.Error, Trace: trace}
+		return &emptypb.Empty{}, nil
+	}
 	start, err := time_util.DateToTime(result.Start)
 	if err != nil {
 		Log.Error("failed to parse start time", zap.Error(err))
@@ -32,21 +52,70 @@ func (s *server) Send(ctx context.Context, result *api.ReportResult) (*emptypb.E
 		Log.Error("failed to parse end time", zap.Error(err))
 		return nil, err
 	}
-	data := make(map[string]decimal.Decimal, len(result.Data))
-	for key, val := range result.Data {
-		data[key] = decimal.NewFromFloat(val)
+	data := toDecimals(result.Data)
+	income := toDecimals(result.Income)
+
+	report := model.NewReport(result.UserId, start, end, data, income)
+	report.RequestId = result.RequestId
+	report.CurrencyCode = result.CurrencyCode
+	report.Trace = trace
+	if err := fillReportMode(report, result); err != nil {
+		Log.Error("failed to parse report result", zap.Error(err))
+		return nil, err
 	}
 
-	s.resultCh <- model.NewReport(result.UserId, start, end, data)
+	s.resultCh <- report
 	return &emptypb.Empty{}, nil
 }
 
+func fillReportMode(report *model.Report, result *api.ReportResult) error {
+	report.Mode = model.ReportMode(result.Mode)
+	report.Chart = result.Chart
+	report.Document, report.DocumentName = result.Document, result.DocumentName
+	for i := 0; i < len(result.Buckets); i++ {
+		start, err := time_util.DateToTime(result.Buckets[i].Start)
+		if err != nil {
+			return err
+		}
+		report.Buckets = append(report.Buckets, model.ReportBucket{Start: start, Data: toDecimals(result.Buckets[i].Data)})
+	}
+	if report.Mode == model.CompareReport {
+		var err error
+		if report.PreviousStart, err = time_util.DateToTime(result.PreviousStart); err != nil {
+			return err
+		}
+		if report.PreviousEnd, err = time_util.DateToTime(result.PreviousEnd); err != nil {
+			return err
+		}
+		report.Previous = toDecimals(result.Previous)
+	}
+	for i := 0; i < len(result.Top); i++ {
+		dt, err := time_util.DateToTime(result.Top[i].Date)
+		if err != nil {
+			return err
+		}
+		report.Top = append(report.Top, model.ReportEntry{
+			Date: dt, Category: result.Top[i].Category, Value: decimal.NewFromFloat(result.Top[i].Value), Note: result.Top[i].Note,
+		})
+	}
+	return nil
+}
+
+func toDecimals(m map[string]float64) map[string]decimal.Decimal {
+	r := make(map[string]decimal.Decimal, len(m))
+	for key, val := range m {
+		r[key] = decimal.NewFromFloat(val)
+	}
+	return r
+}
+
 func RunGRPCServer(ctx context.Context, resultCh chan<- *model.Report) error {
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", 50051))
 	if err != nil {
 		return err
 	}
-	s := grpc.NewServer()
+	// выгрузки трат бывают больше стандартных 4 Мб, telegram принимает файлы до 50 Мб
+	s := grpc.NewServer(grpc.MaxRecvMsgSize(50 << 20))
 	api.RegisterReportServer(s, &server{resultCh: resultCh})
 
 	Log.Info(fmt.Sprintf("server listening at %v", lis.Addr()))
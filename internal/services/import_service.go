@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/statement"
+)
+
+var (
+	ErrNoPendingImport = errors.New("nothing to import, send a bank statement file first")
+	ErrEmptyStatement  = errors.New("no spendings in the statement")
+)
+
+// сколько ждём подтверждения загруженной выписки
+var pendingImportTTL = 30 * time.Minute
+
+type categoryGuesser interface {
+	Guess(ctx context.Context, userId int64, text string) (model.CategoryGuess, bool, error)
+}
+
+type importSpendingService interface {
+	GetEntries(context.Context, int64, time.Time, time.Time, int) ([]model.ReportEntry, model.Currency, error)
+	// SaveBatch learns the categories of the notes too, confirmed spendings help to guess the next imports
+	SaveBatch(context.Context, []model.Spending) (decimal.Decimal, error)
+}
+
+type pendingImport struct {
+	preview   model.ImportPreview
+	expiresAt time.Time
+}
+
+type ImportService struct {
+	mutex           sync.Mutex
+	pending         map[int64]pendingImport
+	categorizer     categoryGuesser
+	spendingService importSpendingService
+	currencyService currencyServiceI
+	mappings        []statement.CSVMapping
+}
+
+func NewImportService(
+	categorizer categoryGuesser,
+	spendingService importSpendingService,
+	currencyService currencyServiceI,
+	mappings []statement.CSVMapping) *ImportService {
+	return &ImportService{
+		pending:         make(map[int64]pendingImport),
+		categorizer:     categorizer,
+		spendingService: spendingService,
+		currencyService: currencyService,
+		mappings:        mappings,
+	}
+}
+
+// Preview parses the statement and keeps the result until Confirm or Cancel, the previous pending import is replaced.
+func (s *ImportService) Preview(ctx context.Context, userId int64, name string, data []byte) (model.ImportPreview, error) {
+	txs, err := statement.Parse(name, data, s.mappings)
+	if err != nil {
+		return model.ImportPreview{}, err
+	}
+	if len(txs) == 0 {
+		return model.ImportPreview{}, ErrEmptyStatement
+	}
+	existing, err := s.existingSpendings(ctx, userId, txs)
+	if err != nil {
+		return model.ImportPreview{}, err
+	}
+
+	preview := model.ImportPreview{Items: make([]model.ImportItem, 0, len(txs))}
+	for i := 0; i < len(txs); i++ {
+		code := txs[i].Currency
+		if code == "" {
+			code = model.BaseCurrency
+		}
+		if _, err := s.currencyService.GetCurrency(code); err != nil {
+			return model.ImportPreview{}, err
+		}
+		key := dedupeKey(txs[i].Date, txs[i].Amount, code)
+		if existing[key] > 0 {
+			// одна внесённая трата закрывает одну строку выписки
+			existing[key]--
+			preview.Duplicates++
+			continue
+		}
+		spending := model.NewSpending(userId, txs[i].Amount, 0, txs[i].Date)
+		spending.CurrencyCode = code
+		spending.Note = txs[i].Merchant
+		guess, ok, err := s.categorizer.Guess(ctx, userId, txs[i].Merchant)
+		if err != nil {
+			return model.ImportPreview{}, err
+		}
+		item := model.ImportItem{Spending: spending, CategoryName: guess.CategoryName, Guessed: guess.Source == model.HistoryGuess}
+		if ok {
+			item.Spending.CategoryId = guess.CategoryId
+		}
+		if !ok || !IsCertain(guess) {
+			preview.Uncategorized = append(preview.Uncategorized, item)
+			continue
+		}
+		preview.Items = append(preview.Items, item)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.pending[userId] = pendingImport{preview: preview, expiresAt: time.Now().Add(pendingImportTTL)}
+	return preview, nil
+}
+
+func (s *ImportService) existingSpendings(ctx context.Context, userId int64, txs []statement.Transaction) (map[string]int, error) {
+	start, end := txs[0].Date, txs[0].Date
+	for i := 1; i < len(txs); i++ {
+		if txs[i].Date.Before(start) {
+			start = txs[i].Date
+		}
+		if txs[i].Date.After(end) {
+			end = txs[i].Date
+		}
+	}
+	entries, _, err := s.spendingService.GetEntries(ctx, userId, start, end, math.MaxInt32)
+	if err != nil {
+		return nil, err
+	}
+	r := make(map[string]int, len(entries))
+	for i := 0; i < len(entries); i++ {
+		r[dedupeKey(entries[i].Date, entries[i].OriginalValue, entries[i].CurrencyCode)]++
+	}
+	return r, nil
+}
+
+// dedupeKey compares spendings by the date and the original sum in its currency up to kopecks.
+func dedupeKey(date time.Time, value decimal.Decimal, currencyCode string) string {
+	return date.Format(dtTemplate) + " " + value.Round(2).String() + " " + currencyCode
+}
+
+// Confirm saves the pending import in one transaction and returns the number of saved spendings,
+// with withGuesses the spendings with uncertain guesses are saved in the guessed categories too.
+func (s *ImportService) Confirm(ctx context.Context, userId int64, withGuesses bool) (int, decimal.Decimal, error) {
+	s.mutex.Lock()
+	pending, ok := s.pending[userId]
+	delete(s.pending, userId)
+	s.mutex.Unlock()
+	items := pending.preview.Items
+	if withGuesses {
+		items = append(items, pending.preview.Guesses()...)
+	}
+	if !ok || time.Now().After(pending.expiresAt) || len(items) == 0 {
+		return 0, decimal.Decimal{}, ErrNoPendingImport
+	}
+
+	spendings := make([]model.Spending, len(items))
+	for i := 0; i < len(items); i++ {
+		spendings[i] = items[i].Spending
+	}
+	balance, err := s.spendingService.SaveBatch(ctx, spendings)
+	if err != nil {
+		return 0, decimal.Decimal{}, err
+	}
+	return len(spendings), balance, nil
+}
+
+// Cancel drops the pending import, false if there was none.
+func (s *ImportService) Cancel(userId int64) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	_, ok := s.pending[userId]
+	delete(s.pending, userId)
+	return ok
+}
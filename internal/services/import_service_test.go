@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/statement"
+)
+
+// fakeCategoryGuesser knows the category of merchants containing the key
+type fakeCategoryGuesser struct {
+	guesses map[string]model.CategoryGuess
+}
+
+func (s *fakeCategoryGuesser) Guess(ctx context.Context, userId int64, text string) (model.CategoryGuess, bool, error) {
+	for k, v := range s.guesses {
+		if strings.Contains(text, k) {
+			return v, true, nil
+		}
+	}
+	return model.CategoryGuess{}, false, nil
+}
+
+type fakeImportSpendingService struct {
+	existing []model.ReportEntry
+	saved    []model.Spending
+}
+
+func (s *fakeImportSpendingService) GetEntries(ctx context.Context, userId int64, start, end time.Time, limit int) ([]model.ReportEntry, model.Currency, error) {
+	return s.existing, model.Currency{Code: "rub", Ratio: decimal.NewFromInt(1)}, nil
+}
+
+func (s *fakeImportSpendingService) SaveBatch(ctx context.Context, spendings []model.Spending) (decimal.Decimal, error) {
+	s.saved = append(s.saved, spendings...)
+	return decimal.NewFromInt(1000), nil
+}
+
+type fakeCurrencyService struct {
+	currencyServiceI
+}
+
+func (s *fakeCurrencyService) GetCurrency(code string) (model.Currency, error) {
+	return model.Currency{Code: code, Ratio: decimal.NewFromInt(1)}, nil
+}
+
+func Test_ImportService_shouldSkipDuplicatesAndUncertain(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	data := "!Type:Bank\nD01.03.2026\nT-350.00\nPPYATEROCHKA 123\n^\nD01.03.2026\nT-350.00\nPPYATEROCHKA 123\n^\n" +
+		"D01.03.2026\nT-99\nPUNKNOWN SHOP\n^\nD02.03.2026\nT-500\nPOZON\n^\n"
+	spendingService := &fakeImportSpendingService{existing: []model.ReportEntry{{Date: day, Value: decimal.NewFromInt(350), OriginalValue: decimal.NewFromInt(350), CurrencyCode: "rub"}}}
+	categorizer := &fakeCategoryGuesser{guesses: map[string]model.CategoryGuess{
+		"PYATEROCHKA": {CategoryId: 2, CategoryName: "food", Confidence: 1, Source: model.RuleGuess},
+		"OZON":        {CategoryId: 3, CategoryName: "home", Confidence: 0.5, Source: model.HistoryGuess},
+	}}
+	s := NewImportService(
+		categorizer,
+		spendingService,
+		&fakeCurrencyService{},
+		statement.DefaultMappings,
+	)
+
+	preview, err := s.Preview(context.TODO(), 123, "bank.qif", []byte(data))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, preview.Duplicates)
+	assert.Len(t, preview.Items, 1)
+	assert.Equal(t, 2, preview.Items[0].Spending.CategoryId)
+	assert.Equal(t, "PYATEROCHKA 123", preview.Items[0].Spending.Note)
+	assert.Len(t, preview.Uncategorized, 2)
+	assert.Equal(t, "home", preview.Uncategorized[1].CategoryName)
+
+	count, balance, err := s.Confirm(context.TODO(), 123, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.True(t, balance.Equal(decimal.NewFromInt(1000)))
+	assert.Len(t, spendingService.saved, 1)
+	assert.Equal(t, 2, spendingService.saved[0].CategoryId)
+
+	_, _, err = s.Confirm(context.TODO(), 123, false)
+	assert.ErrorIs(t, err, ErrNoPendingImport)
+}
+
+func Test_ImportService_shouldSaveUncertainGuessesConfirmedByUser(t *testing.T) {
+	data := "!Type:Bank\nD01.03.2026\nT-350.00\nPPYATEROCHKA 123\n^\n" +
+		"D01.03.2026\nT-99\nPUNKNOWN SHOP\n^\nD02.03.2026\nT-500\nPOZON\n^\n"
+	spendingService := &fakeImportSpendingService{}
+	categorizer := &fakeCategoryGuesser{guesses: map[string]model.CategoryGuess{
+		"PYATEROCHKA": {CategoryId: 0, CategoryName: "food", Confidence: 1, Source: model.RuleGuess},
+		"OZON":        {CategoryId: 3, CategoryName: "home", Confidence: 0.5, Source: model.HistoryGuess},
+	}}
+	s := NewImportService(categorizer, spendingService, &fakeCurrencyService{}, statement.DefaultMappings)
+
+	preview, err := s.Preview(context.TODO(), 123, "bank.qif", []byte(data))
+	assert.NoError(t, err)
+	assert.Len(t, preview.Guesses(), 1)
+
+	count, _, err := s.Confirm(context.TODO(), 123, true)
+
+	assert.NoError(t, err)
+	// трата без догадки так и не сохраняется
+	assert.Equal(t, 2, count)
+	assert.Equal(t, "OZON", spendingService.saved[1].Note)
+	assert.Equal(t, 3, spendingService.saved[1].CategoryId)
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/shopspring/decimal"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/storage/pgdatabase"
+)
+
+type incomeStorageI interface {
+	SaveTx(*sqlx.Tx, model.Income) error
+	GetStatsBy(context.Context, int64, time.Time, time.Time, string) (map[string]decimal.Decimal, error)
+}
+
+type incomeStateServiceI interface {
+	IncreaseBalanceTx(tx *sqlx.Tx, userId int64, v decimal.Decimal) (decimal.Decimal, error)
+}
+
+type IncomeService struct {
+	incomeStorage   incomeStorageI
+	currencyService currencyServiceI
+	stateService    incomeStateServiceI
+}
+
+func NewIncomeService(
+	incomeStorage incomeStorageI,
+	currencyService currencyServiceI,
+	stateService incomeStateServiceI) *IncomeService {
+	return &IncomeService{incomeStorage, currencyService, stateService}
+}
+
+func (s *IncomeService) SaveTx(ctx context.Context, income model.Income) (decimal.Decimal, error) {
+	cur, err := s.currencyService.GetCurrentCurrency(ctx, income.UserId)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	rate, err := s.currencyService.GetRate(ctx, cur.Code, income.Date)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	income.Value = income.Value.Div(rate)
+
+	var balanceAfter decimal.Decimal
+	err = pgdatabase.RunInTx(
+		func(tx *sqlx.Tx) error {
+			var err error
+			balanceAfter, err = s.stateService.IncreaseBalanceTx(tx, income.UserId, income.Value)
+			return err
+		},
+		func(tx *sqlx.Tx) error {
+			return s.incomeStorage.SaveTx(tx, income)
+		},
+	)
+	return balanceAfter, err
+}
+
+func (s *IncomeService) GetStatsBy(ctx context.Context, userId int64, start, end time.Time) (map[string]decimal.Decimal, string, error) {
+	span, childContext := opentracing.StartSpanFromContext(ctx, "income_service: getting report")
+	defer span.Finish()
+
+	ct, err := s.currencyService.GetCurrentCurrency(childContext, userId)
+	if err != nil {
+		ext.Error.Set(span, true)
+		return nil, "", err
+	}
+	data, err := s.incomeStorage.GetStatsBy(childContext, userId, start, end, ct.Code)
+	if err != nil {
+		ext.Error.Set(span, true)
+		return nil, "", err
+	}
+	return data, ct.Code, nil
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"strings"
+
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/i18n"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
+)
+
+// столько кнопок помещается в строку на экране телефона
+const buttonsPerRow = 3
+
+// gridKeyboard lays the buttons out in rows, buttons with too long data are skipped.
+func gridKeyboard(buttons []model.Button) model.Keyboard {
+	var keyboard model.Keyboard
+	for _, b := range buttons {
+		if len(b.Data) > model.MaxButtonData {
+			continue
+		}
+		if len(keyboard) == 0 || len(keyboard[len(keyboard)-1]) == buttonsPerRow {
+			keyboard = append(keyboard, []model.Button{})
+		}
+		keyboard[len(keyboard)-1] = append(keyboard[len(keyboard)-1], b)
+	}
+	return keyboard
+}
+
+// categoryKeyboard offers the categories as buttons with the command built for the category.
+func (s *MessageHandlerService) categoryKeyboard(command func(model.Category) string) model.Keyboard {
+	categories := s.categoryService.GetAll()
+	buttons := make([]model.Button, 0, len(categories))
+	for _, c := range categories {
+		buttons = append(buttons, model.Button{Text: c.Name, Data: command(c)})
+	}
+	return gridKeyboard(buttons)
+}
+
+// confirmQuestion returns the question for commands which delete data.
+func confirmQuestion(l i18n.Lang, tokens []string) (string, bool) {
+	switch {
+	case tokens[0] == "/delete" && len(tokens) == 2:
+		return l.T("delete spending %v?", tokens[1]), true
+	case tokens[0] == "/deletecategory" && len(tokens) == 2:
+		return l.T("delete category %v?", tokens[1]), true
+	case tokens[0] == "/deletecategory" && len(tokens) == 3:
+		return l.T("delete category %v and move its spendings to %v?", tokens[1], tokens[2]), true
+	case (tokens[0] == "/recurring" || tokens[0] == "/rule") && len(tokens) == 3 && tokens[1] == "delete":
+		return l.T("delete %v %v?", l.T(strings.TrimPrefix(tokens[0], "/")), tokens[2]), true
+	}
+	return "", false
+}
+
+func confirmKeyboard(l i18n.Lang, command string) model.Keyboard {
+	if len("/confirm "+command) > model.MaxButtonData {
+		return nil
+	}
+	return gridKeyboard([]model.Button{
+		{Text: l.T("yes"), Data: "/confirm " + command},
+		{Text: l.T("no"), Data: "/no"},
+	})
+}
+
+func reportPeriodsKeyboard(l i18n.Lang) model.Keyboard {
+	return model.Keyboard{
+		{{Text: l.T("week"), Data: "/report w"}, {Text: l.T("month"), Data: "/report m"}, {Text: l.T("year"), Data: "/report y"}},
+		{{Text: l.T("this month"), Data: "/report this month"}, {Text: l.T("last month"), Data: "/report last month"}},
+		{{Text: l.T("this month by day"), Data: "/report this month by day"}, {Text: l.T("month compare"), Data: "/report this month compare"}},
+	}
+}
+
+func (s *MessageHandlerService) currencyKeyboard() model.Keyboard {
+	currencies := s.currencyService.GetAll()
+	buttons := make([]model.Button, 0, len(currencies))
+	for _, c := range currencies {
+		buttons = append(buttons, model.Button{Text: c.Code, Data: "/currency " + c.Code})
+	}
+	return gridKeyboard(buttons)
+}
@@ -7,9 +7,14 @@ import (
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
 	"github.com/shopspring/decimal"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/export"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/i18n"
 	. "gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/logger"
 	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/observability"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/time_util"
 	"go.uber.org/zap"
+	"math"
 	"sort"
 	"strconv"
 	"strings"
@@ -18,59 +23,195 @@ import (
 
 type MessageSender interface {
 	SendMessage(text string, userID int64) error
+	SendPhoto(png []byte, caption string, userID int64) error
+	SendDocument(data []byte, name string, userID int64) error
+	// SendKeyboard sends the text with inline buttons, a pressed button comes back as a message with its data.
+	SendKeyboard(text string, keyboard model.Keyboard, userID int64) error
 }
 
 type SpendingServiceI interface {
-	SaveTx(context.Context, model.Spending) (decimal.Decimal, error)
-	GetStatsBy(context.Context, time.Time, time.Time) (map[string]decimal.Decimal, string, error)
+	// Save learns the category of the note too and returns the currency of the spending if it was converted at the stale rate
+	Save(context.Context, model.Spending) (decimal.Decimal, *model.Currency, error)
+	GetStatsBy(context.Context, int64, time.Time, time.Time) (map[string]decimal.Decimal, string, error)
+	GetLast(context.Context, int64, int) ([]model.Spending, string, error)
+	Find(context.Context, int64, string, time.Time, time.Time) ([]model.Spending, string, error)
+	GetBreakdown(context.Context, int64, time.Time, time.Time, model.ReportMode) ([]model.ReportBucket, string, error)
+	GetTop(context.Context, int64, time.Time, time.Time, int) ([]model.ReportEntry, string, error)
+	GetEntries(context.Context, int64, time.Time, time.Time, int) ([]model.ReportEntry, model.Currency, error)
+	Update(context.Context, model.Spending) (decimal.Decimal, error)
+	Delete(context.Context, int64, int64) (decimal.Decimal, error)
+	Undo(context.Context, int64) (model.Spending, decimal.Decimal, error)
 }
 
 type CurrencyService interface {
-	UpdateCurrentCurrency(c string) error
+	UpdateCurrentCurrency(userId int64, c string) error
+	// GetAll returns currencies with their current rates and the time the rates were loaded
 	GetAll() []model.Currency
+	Convert(value decimal.Decimal, from, to string) (decimal.Decimal, error)
+	GetRate(ctx context.Context, code string, date time.Time) (decimal.Decimal, error)
+	GetHistory(ctx context.Context, code string, start, end time.Time) ([]model.CurrencyRate, error)
+	GetCurrentCurrency(ctx context.Context, userId int64) (model.Currency, error)
+}
+
+type IncomeServiceI interface {
+	SaveTx(context.Context, model.Income) (decimal.Decimal, error)
+	GetStatsBy(context.Context, int64, time.Time, time.Time) (map[string]decimal.Decimal, string, error)
 }
 
 type CategoryService interface {
 	GetAll() []model.Category
+	Find(ref string) (model.Category, bool)
+	// категории общие для всех пользователей, менять их может только владелец бота
+	Add(userId int64, name string) (model.Category, error)
+	Rename(userId int64, ref string, name string) error
+	Archive(userId int64, ref string) error
+	Merge(userId int64, fromRef string, toRef string) error
 }
 type StateService interface {
-	GetBalance() (decimal.Decimal, error)
+	GetState(userId int64) (model.State, error)
+	GetBalance(userId int64) (decimal.Decimal, error)
+	SetPeriod(userId int64, period model.BudgetPeriod, day int) error
+	SetRollover(userId int64, rollover bool) error
+	GetClosedPeriods(userId int64, count int) ([]model.ClosedPeriod, error)
+	SetCategoryLimit(userId int64, categoryId int, v decimal.Decimal) error
+	GetCategoryBudgets(userId int64) ([]model.CategoryBudget, error)
+	GetCategoryBudget(userId int64, categoryId int) (model.CategoryBudget, bool, error)
+}
+type RecurringServiceI interface {
+	Add(ctx context.Context, userId int64, categoryId int, value decimal.Decimal, schedule string) (model.RecurringSpending, error)
+	GetAll(userId int64) ([]model.RecurringSpending, error)
+	Delete(userId int64, id int64) error
+}
+type ImportServiceI interface {
+	Preview(ctx context.Context, userId int64, name string, data []byte) (model.ImportPreview, error)
+	Confirm(ctx context.Context, userId int64, withGuesses bool) (int, decimal.Decimal, error)
+	Cancel(userId int64) bool
+}
+type CategorizerI interface {
+	Guess(ctx context.Context, userId int64, text string) (model.CategoryGuess, bool, error)
+	AddRule(userId int64, categoryId int, kind model.RuleKind, pattern string) (model.CategoryRule, error)
+	GetRules(userId int64) ([]model.CategoryRule, error)
+	DeleteRule(userId int64, id int64) error
+}
+type LanguageServiceI interface {
+	// ResolveLanguage returns the language of the user, the detected one replaces the stored one unless it is set with /lang.
+	ResolveLanguage(userId int64, detected string) (string, error)
+	GetLanguage(userId int64) (string, error)
+	SetLanguage(userId int64, lang string, fixed bool) error
+}
+type DialogServiceI interface {
+	Get(ctx context.Context, userId int64) (model.Dialog, bool, error)
+	Save(ctx context.Context, dialog model.Dialog) error
+	Delete(ctx context.Context, userId int64) error
 }
 type MessageHandlerService struct {
-	tgClient        MessageSender
-	spendingService SpendingServiceI
-	currencyService CurrencyService
-	categoryService CategoryService
-	stateService    StateService
-	reportProducer  *ReportProducer
+	tgClient              MessageSender
+	spendingService       SpendingServiceI
+	currencyService       CurrencyService
+	categoryService       CategoryService
+	stateService          StateService
+	incomeService         IncomeServiceI
+	incomeCategoryService CategoryService
+	recurringService      RecurringServiceI
+	importService         ImportServiceI
+	categorizer           CategorizerI
+	// траты, для которых бот спрашивает недостающие аргументы
+	dialogs        DialogServiceI
+	languages      LanguageServiceI
+	reportProducer *ReportProducer
 }
 
 var helpMsg = `
 /help - call this help
 /categories - show all categories
-/currencies - show all currencies
-/add [category] [sum] [date] - add spending 
-/report [type] - show report. type: w - week, m - month, y - year
+/addcategory [name] - add category
+/renamecategory [category] [name] - rename category
+/deletecategory [category] [target category] - archive category, spendings are moved to the target category if it is set
+/currencies - show all currencies with rates in the base currency and the time they were loaded
+/convert [sum] [currency] [currency] - convert the sum at the stored rates, e.g. /convert 100 usd eur
+/rates [currency] [period] - show rates in the base currency and their change over the period like in /report, last month if not set
+/rates chart [currency] [period] - show the chart of the rate, e.g. /rates chart usd 90d
+/add [category] [sum] [date] [note] - add spending in any order, e.g. /add 350 food, /add food 350,50 yesterday #trip, /add 20$ taxi 12.03, /add food 20 eur. category is an id or a name, date is today if not set, words of the note starting with # are tags, a sum in another currency is converted at its rate and the display currency stays the same
+/add without arguments asks the sum, the category and the date one by one, /cancel - stop at any step
+/find [text|#tag] [period] - find spendings by note text or tag in the period like in /report, all time if not set
+/income [category] [sum] [date] - add income, date is today if not set
+/incomecategories - show all income categories
+/addincomecategory [name] - add income category
+/limit [category] [sum] - set limit of the category for the budget period in the base currency, 0 removes the limit
+/budget - show spent vs limit for each category
+/period - show budget period settings
+/period [type] [day] - change budget period. type: week, 2weeks, month. day - day of month for the monthly period
+/rollover [on|off] - carry unspent or overspent budget into the next period
+/periods - show closed budget periods
+/history [count] - show last spendings with their ids
+/edit [id] [category] [sum] [date] - change spending
+/delete [id] - delete spending, asks to confirm like other deletions
+/confirm [command] - do the deletion without asking
+/undo - delete the last added spending
+/recurring add [category] [sum] [schedule] - add recurring spending. schedule: cron expression "minute hour day month weekday" or @daily, @weekly, @monthly, @yearly
+/recurring list - show recurring spendings
+/recurring delete [id] - delete recurring spending
+/report [period] [mode] - show report. period: w, m, y - last week, month, year; 90d, 2w, 6m - last days, weeks, months; today, yesterday; this/last week, month, year; q1..q4 [year]; 2025; 03-2026; 01-03-2026; 01-03-2026 31-03-2026
+  mode: by day|week|month|category - breakdown, compare - compare with the previous period, top [count] - largest spendings
+  add chart at the end to get charts, e.g. /report m chart
+send a bank statement file (csv, ofx, qif) to import spendings from it
+/import confirm - save the imported spendings, /import confirm all - with the uncertainly guessed categories too, /import cancel - discard them
+/rule add [category] [exact|substring|regex] [pattern] - set the category of spendings whose note or statement merchant matches the pattern, used when the category is not set in /add
+/rule list - show category rules
+/rule delete [id] - delete category rule
+/yes - save the spending with the guessed category, /no or /cancel - drop it
+send a photo of a receipt with a qr code to add its sum and date, a category in the caption saves it at once
+/receipt [category] - save the spending from the last receipt photo to the category
+/export [period] [csv|xlsx] - export spendings of the period like in /report to a file, last month in csv if not set
 /currency [type] - change currency
+/lang [en|ru|auto] - change language, auto follows the language of telegram
 `
 
 var dtTemplate = "02-01-2006"
 
+var recurringDtTemplate = "02-01-2006 15:04"
+
+var (
+	defaultHistoryCount = 10
+	maxHistoryCount     = 50
+	closedPeriodsCount  = 12
+	defaultTopCount     = 10
+	// больше трат выгружаем через report_service, чтобы не держать обработку сообщения
+	maxSyncExportRows = 1000
+)
+
+// доли лимита категории, при переходе через которые пользователь получает предупреждение
+var limitAlertThresholds = []decimal.Decimal{decimal.NewFromInt(1), decimal.NewFromFloat(0.8)}
+
 func NewMessageHandlerService(
 	tgClient MessageSender,
 	spendingService SpendingServiceI,
 	currencyService CurrencyService,
 	categoryService CategoryService,
 	stateService StateService,
+	incomeService IncomeServiceI,
+	incomeCategoryService CategoryService,
+	recurringService RecurringServiceI,
+	importService ImportServiceI,
+	categorizer CategorizerI,
+	dialogs DialogServiceI,
+	languages LanguageServiceI,
 	reportProducer *ReportProducer,
 	reportResultCh <-chan *model.Report) *MessageHandlerService {
 	s := &MessageHandlerService{
-		tgClient:        tgClient,
-		spendingService: spendingService,
-		currencyService: currencyService,
-		categoryService: categoryService,
-		stateService:    stateService,
-		reportProducer:  reportProducer,
+		tgClient:              tgClient,
+		spendingService:       spendingService,
+		currencyService:       currencyService,
+		categoryService:       categoryService,
+		stateService:          stateService,
+		incomeService:         incomeService,
+		incomeCategoryService: incomeCategoryService,
+		recurringService:      recurringService,
+		importService:         importService,
+		categorizer:           categorizer,
+		dialogs:               dialogs,
+		languages:             languages,
+		reportProducer:        reportProducer,
 	}
 	go s.reportResultListen(reportResultCh)
 	return s
@@ -81,60 +222,267 @@ func (s *MessageHandlerService) HandleMsg(msg *model.Message, ctx context.Contex
 
 	defer span.Finish()
 
+	l := s.language(msg)
+	spanCtx = i18n.WithLang(spanCtx, l)
+
 	tokens := strings.Split(msg.Text, " ")
 	if len(tokens) == 0 {
 		return nil
 	}
 	resp := ""
+	alerts := make([]string, 0)
+	var keyboard model.Keyboard
+	withReply := func(handler func(context.Context, int64, []string) (reply, error)) func(context.Context, int64, []string) (string, error) {
+		return func(ctx context.Context, userId int64, tokens []string) (string, error) {
+			r, err := handler(ctx, userId, tokens)
+			alerts, keyboard = r.alerts, r.keyboard
+			return r.text, err
+		}
+	}
+
+	if msg.DocumentName != "" {
+		resp = handleF(span, spanCtx, msg.UserID, []string{msg.DocumentName}, 1, func(ctx context.Context, userId int64, _ []string) (string, error) {
+			return s.handleImportFile(ctx, userId, msg.DocumentName, msg.Document)
+		})
+		span.SetOperationName("msg_handler: handle statement file")
+		return s.tgClient.SendMessage(resp, msg.UserID)
+	}
+	if msg.Photo != nil {
+		resp = handleF(span, spanCtx, msg.UserID, []string{"photo"}, 1, withReply(func(ctx context.Context, userId int64, _ []string) (reply, error) {
+			return s.handleReceipt(ctx, userId, msg.Photo, msg.Text)
+		}))
+		span.SetOperationName("msg_handler: handle receipt photo")
+		return s.sendReply(resp, keyboard, alerts, msg.UserID)
+	}
+
+	if !strings.HasPrefix(msg.Text, "/") {
+		// не команда - ответ на вопрос диалога
+		resp = handleF(span, spanCtx, msg.UserID, []string{msg.Text}, 1, withReply(s.handleDialogAnswer))
+		span.SetOperationName("msg_handler: handle dialog answer")
+		return s.sendReply(resp, keyboard, alerts, msg.UserID)
+	}
+
+	// удаление выполняется только после подтверждения кнопкой или /confirm
+	if tokens[0] == "/confirm" && len(tokens) > 1 {
+		tokens = tokens[1:]
+	} else if question, ok := confirmQuestion(l, tokens); ok {
+		command := strings.Join(tokens, " ")
+		span.SetOperationName("msg_handler: confirm cmd `" + tokens[0] + "`")
+		return s.sendReply(l.T("%v\nsend /confirm %v to do it", question, command), confirmKeyboard(l, command), nil, msg.UserID)
+	}
 
 	switch tokens[0] {
 	case "/start":
-		resp = "hello"
+		resp = l.T("hello")
 		span.SetOperationName("msg_handler: handle cmd `/start`")
 	case "/help":
-		resp = helpMsg
+		resp = l.T(helpMsg)
 		span.SetOperationName("msg_handler: handle cmd `/help`")
 	case "/add":
-		resp = handleF(span, spanCtx, tokens, 4, s.handleAdd)
+		// слова можно писать в любом порядке, их разбирает parseAdd
+		tokens = []string{tokens[0], strings.Join(tokens[1:], " ")}
+		resp = handleF(span, spanCtx, msg.UserID, tokens, 2, withReply(s.handleAdd))
 		span.SetOperationName("msg_handler: handle cmd `/add`")
+	case "/income":
+		if len(tokens) == 3 {
+			tokens = append(tokens, time.Now().Format(dtTemplate))
+		}
+		resp = handleF(span, spanCtx, msg.UserID, tokens, 4, s.handleIncome)
+		span.SetOperationName("msg_handler: handle cmd `/income`")
+	case "/incomecategories":
+		resp = formatCategories(s.incomeCategoryService.GetAll())
+		span.SetOperationName("msg_handler: handle cmd `/incomecategories`")
+	case "/addincomecategory":
+		resp = handleF(span, spanCtx, msg.UserID, tokens, 2, s.handleAddIncomeCategory)
+		span.SetOperationName("msg_handler: handle cmd `/addincomecategory`")
+	case "/limit":
+		resp = handleF(span, spanCtx, msg.UserID, tokens, 3, s.handleLimit)
+		span.SetOperationName("msg_handler: handle cmd `/limit`")
+	case "/budget":
+		resp = handleF(span, spanCtx, msg.UserID, tokens, 1, s.handleBudget)
+		span.SetOperationName("msg_handler: handle cmd `/budget`")
+	case "/period":
+		switch len(tokens) {
+		case 1:
+			resp = handleF(span, spanCtx, msg.UserID, tokens, 1, s.handlePeriodInfo)
+		case 2:
+			resp = handleF(span, spanCtx, msg.UserID, append(tokens, "1"), 3, s.handlePeriodChange)
+		default:
+			resp = handleF(span, spanCtx, msg.UserID, tokens, 3, s.handlePeriodChange)
+		}
+		span.SetOperationName("msg_handler: handle cmd `/period`")
+	case "/rollover":
+		resp = handleF(span, spanCtx, msg.UserID, tokens, 2, s.handleRollover)
+		span.SetOperationName("msg_handler: handle cmd `/rollover`")
+	case "/periods":
+		resp = handleF(span, spanCtx, msg.UserID, tokens, 1, s.handleClosedPeriods)
+		span.SetOperationName("msg_handler: handle cmd `/periods`")
+	case "/history":
+		if len(tokens) == 1 {
+			tokens = append(tokens, strconv.Itoa(defaultHistoryCount))
+		}
+		resp = handleF(span, spanCtx, msg.UserID, tokens, 2, s.handleHistory)
+		span.SetOperationName("msg_handler: handle cmd `/history`")
+	case "/find":
+		// период можно не указывать, тогда ищем за всё время
+		period := ""
+		for n := 2; n >= 1 && period == ""; n-- {
+			if len(tokens) <= n+1 {
+				continue
+			}
+			last := strings.Join(tokens[len(tokens)-n:], " ")
+			if _, _, err := time_util.ParseRange(last, time.Now()); err == nil {
+				period, tokens = last, tokens[:len(tokens)-n]
+			}
+		}
+		if len(tokens) >= 2 {
+			tokens = []string{tokens[0], strings.Join(tokens[1:], " "), period}
+		}
+		resp = handleF(span, spanCtx, msg.UserID, tokens, 3, s.handleFind)
+		span.SetOperationName("msg_handler: handle cmd `/find`")
+	case "/edit":
+		resp = handleF(span, spanCtx, msg.UserID, tokens, 5, s.handleEdit)
+		span.SetOperationName("msg_handler: handle cmd `/edit`")
+	case "/delete":
+		resp = handleF(span, spanCtx, msg.UserID, tokens, 2, s.handleDelete)
+		span.SetOperationName("msg_handler: handle cmd `/delete`")
+	case "/undo":
+		resp = handleF(span, spanCtx, msg.UserID, tokens, 1, s.handleUndo)
+		span.SetOperationName("msg_handler: handle cmd `/undo`")
+	case "/recurring":
+		switch {
+		case len(tokens) > 1 && tokens[1] == "add" && len(tokens) >= 5:
+			// расписание - всё, что после суммы
+			tokens = append(tokens[:4], strings.Join(tokens[4:], " "))
+			resp = handleF(span, spanCtx, msg.UserID, tokens, 5, s.handleRecurringAdd)
+		case len(tokens) > 1 && tokens[1] == "delete":
+			resp = handleF(span, spanCtx, msg.UserID, tokens, 3, s.handleRecurringDelete)
+		default:
+			resp = handleF(span, spanCtx, msg.UserID, tokens, 2, s.handleRecurringList)
+		}
+		span.SetOperationName("msg_handler: handle cmd `/recurring`")
 	case "/categories":
 		resp = s.handleCategories()
 		span.SetOperationName("msg_handler: handle cmd `/categories`")
+	case "/addcategory":
+		resp = handleF(span, spanCtx, msg.UserID, tokens, 2, s.handleAddCategory)
+		span.SetOperationName("msg_handler: handle cmd `/addcategory`")
+	case "/renamecategory":
+		resp = handleF(span, spanCtx, msg.UserID, tokens, 3, s.handleRenameCategory)
+		span.SetOperationName("msg_handler: handle cmd `/renamecategory`")
+	case "/deletecategory":
+		if len(tokens) == 3 {
+			resp = handleF(span, spanCtx, msg.UserID, tokens, 3, s.handleMergeCategory)
+		} else {
+			resp = handleF(span, spanCtx, msg.UserID, tokens, 2, s.handleDeleteCategory)
+		}
+		span.SetOperationName("msg_handler: handle cmd `/deletecategory`")
 	case "/report":
-		//resp = handleF(span, spanCtx, tokens, 2, s.handleReport)
-		resp = handleF(span, spanCtx, tokens, 2, func(ctx context.Context, i []string) (string, error) {
-			r, err := s.handleReportAsync(spanCtx, msg.UserID, tokens)
-			if err != nil {
-				return "", err
-			}
-			return r, nil
-		})
+		// период может состоять из нескольких слов: this month, 01-03-2026 31-03-2026
+		tokens = []string{tokens[0], strings.Join(tokens[1:], " ")}
+		if strings.TrimSpace(tokens[1]) == "" {
+			resp, keyboard = l.T("which period?"), reportPeriodsKeyboard(l)
+		} else if s.reportProducer != nil {
+			resp = handleF(span, spanCtx, msg.UserID, tokens, 2, s.handleReportAsync)
+		} else {
+			resp = handleF(span, spanCtx, msg.UserID, tokens, 2, s.handleReport)
+		}
 		span.SetOperationName("msg_handler: handle cmd `/report`")
+	case "/import":
+		tokens = []string{tokens[0], strings.Join(tokens[1:], " ")}
+		resp = handleF(span, spanCtx, msg.UserID, tokens, 2, s.handleImport)
+		span.SetOperationName("msg_handler: handle cmd `/import`")
+	case "/rule":
+		switch {
+		case len(tokens) > 1 && tokens[1] == "add" && len(tokens) > 4:
+			// шаблон может быть из нескольких слов
+			tokens = append(tokens[:4], strings.Join(tokens[4:], " "))
+			resp = handleF(span, spanCtx, msg.UserID, tokens, 5, s.handleRuleAdd)
+		case len(tokens) > 1 && tokens[1] == "delete":
+			resp = handleF(span, spanCtx, msg.UserID, tokens, 3, s.handleRuleDelete)
+		default:
+			resp = handleF(span, spanCtx, msg.UserID, tokens, 2, s.handleRuleList)
+		}
+		span.SetOperationName("msg_handler: handle cmd `/rule`")
+	case "/yes":
+		resp = handleF(span, spanCtx, msg.UserID, tokens, 1, withReply(s.handleYes))
+		span.SetOperationName("msg_handler: handle cmd `/yes`")
+	case "/no", "/cancel":
+		resp = handleF(span, spanCtx, msg.UserID, tokens, 1, s.handleCancel)
+		span.SetOperationName("msg_handler: handle cmd `" + tokens[0] + "`")
+	case "/receipt":
+		tokens = []string{tokens[0], strings.Join(tokens[1:], " ")}
+		resp = handleF(span, spanCtx, msg.UserID, tokens, 2, withReply(s.handleReceiptCategory))
+		span.SetOperationName("msg_handler: handle cmd `/receipt`")
+	case "/export":
+		tokens = []string{tokens[0], strings.Join(tokens[1:], " ")}
+		resp = handleF(span, spanCtx, msg.UserID, tokens, 2, s.handleExport)
+		span.SetOperationName("msg_handler: handle cmd `/export`")
 	case "/currencies":
-		resp = s.handleCurrencies()
+		resp, keyboard = s.handleCurrencies(l), s.currencyKeyboard()
 		span.SetOperationName("msg_handler: handle cmd `/currencies`")
+	case "/convert":
+		resp = handleF(span, spanCtx, msg.UserID, tokens, 4, s.handleConvert)
+		span.SetOperationName("msg_handler: handle cmd `/convert`")
+	case "/rates":
+		tokens = []string{tokens[0], strings.Join(tokens[1:], " ")}
+		resp = handleF(span, spanCtx, msg.UserID, tokens, 2, s.handleRates)
+		span.SetOperationName("msg_handler: handle cmd `/rates`")
 	case "/currency":
-		resp = handleF(span, spanCtx, tokens, 2, s.handleCurrencyChange)
+		resp = handleF(span, spanCtx, msg.UserID, tokens, 2, s.handleCurrencyChange)
 		span.SetOperationName("msg_handler: handle cmd `/currency`")
 	case "/balance":
-		resp = s.handleBalance()
+		resp = s.handleBalance(l, msg.UserID)
 		span.SetOperationName("msg_handler: handle cmd `/balance`")
+	case "/lang":
+		if len(tokens) == 1 {
+			resp, keyboard = l.T("language: %v", languageNames[l]), languageKeyboard
+		} else {
+			resp = handleF(span, spanCtx, msg.UserID, []string{tokens[1], msg.LanguageCode}, 2, s.handleLanguage)
+		}
+		span.SetOperationName("msg_handler: handle cmd `/lang`")
 	default:
-		resp = "не знаю эту команду"
+		resp = l.T("unknown command, see /help")
+	}
+	return s.sendReply(resp, keyboard, alerts, msg.UserID)
+}
+
+// reply is the answer of a handler with optional buttons and alerts sent after it.
+type reply struct {
+	text     string
+	keyboard model.Keyboard
+	alerts   []string
+}
+
+func (s *MessageHandlerService) sendReply(resp string, keyboard model.Keyboard, alerts []string, userId int64) error {
+	send := s.tgClient.SendMessage
+	if len(keyboard) > 0 {
+		send = func(text string, userID int64) error {
+			return s.tgClient.SendKeyboard(text, keyboard, userID)
+		}
+	}
+	if err := send(resp, userId); err != nil {
+		return err
+	}
+	for i := 0; i < len(alerts); i++ {
+		if err := s.tgClient.SendMessage(alerts[i], userId); err != nil {
+			return err
+		}
 	}
-	return s.tgClient.SendMessage(resp, msg.UserID)
+	return nil
 }
 
 var errWrongFormat = errors.New("wrong format")
 
-func handleF(span opentracing.Span, spanCtx context.Context, strs []string, count int, handler func(context.Context, []string) (string, error)) string {
+func handleF(span opentracing.Span, spanCtx context.Context, userId int64, strs []string, count int, handler func(context.Context, int64, []string) (string, error)) string {
+	l := i18n.FromContext(spanCtx)
 	if count != len(strs) {
-		return errWrongFormat.Error()
+		return l.Error(errWrongFormat)
 	}
 
-	if r, err := handler(spanCtx, strs); err != nil {
+	if r, err := handler(spanCtx, userId, strs); err != nil {
 		ext.Error.Set(span, true)
-		return err.Error()
+		return l.Error(err)
 	} else {
 		return r
 	}
@@ -148,26 +496,33 @@ func genListMsg(els []string) string {
 	return sb.String()
 }
 
-func (s *MessageHandlerService) handleBalance() string {
-	if v, err := s.stateService.GetBalance(); err != nil {
-		return err.Error()
+func (s *MessageHandlerService) handleBalance(l i18n.Lang, userId int64) string {
+	if v, err := s.stateService.GetBalance(userId); err != nil {
+		return l.Error(err)
 	} else {
-		return fmt.Sprintf("%v rub", v)
+		return l.Money(v, model.BaseCurrency)
 	}
 }
-func (s *MessageHandlerService) handleCurrencies() string {
+func (s *MessageHandlerService) handleCurrencies(l i18n.Lang) string {
 	allCrns := s.currencyService.GetAll()
 	els := make([]string, len(allCrns))
 
 	for i := 0; i < len(allCrns); i++ {
-		els[i] = allCrns[i].Code
+		if allCrns[i].Code == model.BaseCurrency {
+			els[i] = allCrns[i].Code
+			continue
+		}
+		els[i] = l.T("%v - %v, updated %v", allCrns[i].Code, l.Money(basePerUnit(allCrns[i].Ratio), model.BaseCurrency), formatUpdatedAt(l, allCrns[i].UpdatedAt))
 	}
 
 	return genListMsg(els)
 }
 
 func (s *MessageHandlerService) handleCategories() string {
-	allCats := s.categoryService.GetAll()
+	return formatCategories(s.categoryService.GetAll())
+}
+
+func formatCategories(allCats []model.Category) string {
 	els := make([]string, len(allCats))
 
 	for i := 0; i < len(allCats); i++ {
@@ -177,94 +532,1004 @@ func (s *MessageHandlerService) handleCategories() string {
 	return genListMsg(els)
 }
 
-func (s *MessageHandlerService) handleAdd(ctx context.Context, tokens []string) (string, error) {
+func (s *MessageHandlerService) parseSpending(userId int64, catStr, sumStr, dtStr string) (model.Spending, error) {
+	if sum, err := decimal.NewFromString(sumStr); err != nil {
+		return model.Spending{}, errors.New("sum must be a number")
+	} else if dt, ok := parseDate(dtStr, time.Now()); !ok {
+		return model.Spending{}, errors.New("wrong date format")
+	} else if cat, ok := s.categoryService.Find(catStr); !ok {
+		return model.Spending{}, i18n.Errorf("%w: %v", ErrUnknownCategory, catStr)
+	} else {
+		return model.NewSpending(userId, sum, cat.Id, dt), nil
+	}
+}
+
+func (s *MessageHandlerService) handleAdd(ctx context.Context, userId int64, tokens []string) (reply, error) {
+	if strings.TrimSpace(tokens[1]) == "" {
+		return s.startDialog(ctx, userId)
+	}
+	l := i18n.FromContext(ctx)
+	args, question := parseAdd(l, tokens[1], time.Now(), s.categoryService.Find)
+	if question != "" && !args.needCategory {
+		return reply{text: question}, nil
+	}
+	spending := model.NewSpending(userId, args.sum, args.category.Id, args.date)
+	spending.CurrencyCode = args.currencyCode
+	spending.SetNote(args.words)
+	if !args.needCategory {
+		return s.saveSpending(ctx, spending, l.T("added"))
+	}
+	// категорию спрашиваем в диалоге, ответом может быть её имя или кнопка
+	dialog := model.Dialog{UserId: userId, Spending: spending}
+	if spending.Note == "" {
+		return s.askCategory(ctx, dialog, question)
+	}
+
+	guess, ok, err := s.categorizer.Guess(ctx, userId, spending.Note)
+	if err != nil {
+		return reply{}, err
+	}
+	if !ok {
+		return s.askCategory(ctx, dialog, question)
+	}
+	dialog.Spending.CategoryId, dialog.HasCategory = guess.CategoryId, true
+	if IsCertain(guess) {
+		return s.saveSpending(ctx, dialog.Spending, l.T("added to %v", guess.CategoryName))
+	}
+	dialog.Guessed = true
+	r, err := s.askCategory(ctx, dialog, l.T("is it %v? /yes - save, or send the category", guess.CategoryName))
+	r.keyboard = append(model.Keyboard{{{Text: l.T("yes, %v", guess.CategoryName), Data: "/yes"}}}, r.keyboard...)
+	return r, err
+}
+
+// askCategory saves the dialog waiting for the category with the question of the handler.
+func (s *MessageHandlerService) askCategory(ctx context.Context, dialog model.Dialog, question string) (reply, error) {
+	if err := s.dialogs.Save(ctx, dialog); err != nil {
+		return reply{}, err
+	}
+	return reply{text: question, keyboard: s.dialogCategoryKeyboard()}, nil
+}
+
+// handleYes saves the spending waiting for the confirmation of the guessed category.
+func (s *MessageHandlerService) handleYes(ctx context.Context, userId int64, tokens []string) (reply, error) {
+	dialog, ok, err := s.dialogs.Get(ctx, userId)
+	if err != nil {
+		return reply{}, err
+	}
+	if !ok || !dialog.Guessed {
+		return reply{}, errors.New("nothing to confirm")
+	}
+	dialog.Guessed = false
+	return s.askNext(ctx, dialog)
+}
+
+// saveSpending saves the spending, learns its category from the note and checks the limit of the category.
+func (s *MessageHandlerService) saveSpending(ctx context.Context, spending model.Spending, added string) (reply, error) {
+	userId := spending.UserId
+	before, hasLimit, err := s.stateService.GetCategoryBudget(userId, spending.CategoryId)
+	if err != nil {
+		return reply{}, err
+	}
+	balanceAfter, stale, err := s.spendingService.Save(ctx, spending)
+	if err != nil {
+		return reply{}, err
+	}
+	l := i18n.FromContext(ctx)
+	if amounts, err := s.spendingAmounts(ctx, spending); err != nil {
+		Log.Error("failed to convert spending to display currency", zap.Error(err))
+	} else if amounts != "" {
+		added = fmt.Sprintf("%v %v", added, amounts)
+	}
+	r := reply{text: l.T("%v, current balance: %v", added, l.Number(balanceAfter))}
+	if stale != nil {
+		r.alerts = append(r.alerts, l.T("the %v rate was updated %v, the sum in %v may be inaccurate", stale.Code, formatUpdatedAt(l, stale.UpdatedAt), model.BaseCurrency))
+	}
+	if !hasLimit {
+		return r, nil
+	}
+
+	after, _, err := s.stateService.GetCategoryBudget(userId, spending.CategoryId)
+	if err != nil {
+		Log.Error("failed to check category limit", zap.Error(err))
+		return r, nil
+	}
+	if alert, ok := limitAlert(l, before, after); ok {
+		r.alerts = append(r.alerts, alert)
+	}
+	return r, nil
+}
+
+// spendingAmounts shows the sum entered in another currency together with the sum in the display currency of the user
+// at the rates of the date of the spending, it is empty if the spending is in the display currency.
+func (s *MessageHandlerService) spendingAmounts(ctx context.Context, spending model.Spending) (string, error) {
+	if spending.CurrencyCode == "" {
+		return "", nil
+	}
+	display, err := s.currencyService.GetCurrentCurrency(ctx, spending.UserId)
+	if err != nil {
+		return "", err
+	}
+	if display.Code == spending.CurrencyCode {
+		return "", nil
+	}
+	from, err := s.currencyService.GetRate(ctx, spending.CurrencyCode, spending.Date)
+	if err != nil {
+		return "", err
+	}
+	to, err := s.currencyService.GetRate(ctx, display.Code, spending.Date)
+	if err != nil {
+		return "", err
+	}
+	if from.IsZero() {
+		return "", model.ErrWrongCurrency
+	}
+	l := i18n.FromContext(ctx)
+	converted := spending.Value.Div(from).Mul(to).Round(2)
+	return fmt.Sprintf("%v (%v)", l.Money(spending.Value, spending.CurrencyCode), l.Money(converted, display.Code)), nil
+}
+
+// limitAlert returns a warning if the spending moved the category over one of limitAlertThresholds.
+func limitAlert(l i18n.Lang, before, after model.CategoryBudget) (string, bool) {
+	for i := 0; i < len(limitAlertThresholds); i++ {
+		threshold := after.Limit.Mul(limitAlertThresholds[i])
+		if before.Spent.LessThan(threshold) && after.Spent.GreaterThanOrEqual(threshold) {
+			if limitAlertThresholds[i].Equal(decimal.NewFromInt(1)) {
+				return l.T("limit for %v exceeded: %v of %v", after.CategoryName, l.Money(after.Spent, model.BaseCurrency), l.Money(after.Limit, model.BaseCurrency)), true
+			}
+			return l.T("%v%% of limit for %v used: %v of %v",
+				limitAlertThresholds[i].Mul(decimal.NewFromInt(100)), after.CategoryName, l.Money(after.Spent, model.BaseCurrency), l.Money(after.Limit, model.BaseCurrency)), true
+		}
+	}
+	return "", false
+}
+
+func (s *MessageHandlerService) handleLimit(ctx context.Context, userId int64, tokens []string) (string, error) {
+	cat, ok := s.categoryService.Find(tokens[1])
+	if !ok {
+		return "", i18n.Errorf("%w: %v", ErrUnknownCategory, tokens[1])
+	}
+	v, err := decimal.NewFromString(tokens[2])
+	if err != nil || v.IsNegative() {
+		return "", errors.New("limit must be a non-negative number")
+	}
+	if err := s.stateService.SetCategoryLimit(userId, cat.Id, v); err != nil {
+		return "", err
+	}
+	l := i18n.FromContext(ctx)
+	if v.IsZero() {
+		return l.T("limit for %v removed", cat.Name), nil
+	}
+	return l.T("limit for %v set: %v", cat.Name, l.Money(v, model.BaseCurrency)), nil
+}
+
+func (s *MessageHandlerService) handlePeriodInfo(ctx context.Context, userId int64, tokens []string) (string, error) {
+	state, err := s.stateService.GetState(userId)
+	if err != nil {
+		return "", err
+	}
+	l := i18n.FromContext(ctx)
+	period := l.T(string(state.BudgetPeriod))
+	if state.BudgetPeriod == model.MonthPeriod {
+		period = l.T("%v, day %v", period, state.BudgetPeriodDay)
+	}
+	rollover := l.T("off")
+	if state.BudgetRollover {
+		rollover = l.T("on")
+	}
+	return l.T("period: %v\nrollover: %v\ncurrent: %v - %v\nbudget: %v, balance: %v",
+		period, rollover, l.Date(state.BudgetStartedIn), l.Date(state.BudgetExpiresIn),
+		l.Money(state.BudgetValue, model.BaseCurrency), l.Money(state.BudgetBalance, model.BaseCurrency)), nil
+}
+
+func (s *MessageHandlerService) handlePeriodChange(ctx context.Context, userId int64, tokens []string) (string, error) {
+	period, err := model.ParseBudgetPeriod(tokens[1])
+	if err != nil {
+		return "", err
+	}
+	day, err := strconv.Atoi(tokens[2])
+	if err != nil {
+		return "", errors.New("day must be a number")
+	}
+	if err := s.stateService.SetPeriod(userId, period, day); err != nil {
+		return "", err
+	}
+	return i18n.FromContext(ctx).T("successfully changed"), nil
+}
+
+func (s *MessageHandlerService) handleRollover(ctx context.Context, userId int64, tokens []string) (string, error) {
+	var rollover bool
+	switch tokens[1] {
+	case "on":
+		rollover = true
+	case "off":
+		rollover = false
+	default:
+		return "", errWrongFormat
+	}
+	if err := s.stateService.SetRollover(userId, rollover); err != nil {
+		return "", err
+	}
+	return i18n.FromContext(ctx).T("successfully changed"), nil
+}
+
+func (s *MessageHandlerService) handleClosedPeriods(ctx context.Context, userId int64, tokens []string) (string, error) {
+	periods, err := s.stateService.GetClosedPeriods(userId, closedPeriodsCount)
+	if err != nil {
+		return "", err
+	}
+	l := i18n.FromContext(ctx)
+	if len(periods) == 0 {
+		return l.T("no data"), nil
+	}
+	els := make([]string, len(periods))
+	for i := 0; i < len(periods); i++ {
+		els[i] = l.T("%v - %v: budget %v, left %v",
+			l.Date(periods[i].StartedIn), l.Date(periods[i].ExpiresIn),
+			l.Money(periods[i].BudgetValue, model.BaseCurrency), l.Money(periods[i].BudgetBalance, model.BaseCurrency))
+	}
+	return genListMsg(els), nil
+}
+
+func (s *MessageHandlerService) handleBudget(ctx context.Context, userId int64, tokens []string) (string, error) {
+	budgets, err := s.stateService.GetCategoryBudgets(userId)
+	if err != nil {
+		return "", err
+	}
+	l := i18n.FromContext(ctx)
+	if len(budgets) == 0 {
+		return l.T("no limits"), nil
+	}
+	els := make([]string, len(budgets))
+	for i := 0; i < len(budgets); i++ {
+		els[i] = l.T("%v - %v of %v (%v%%)",
+			budgets[i].CategoryName, l.Money(budgets[i].Spent, model.BaseCurrency), l.Money(budgets[i].Limit, model.BaseCurrency),
+			budgets[i].Spent.Div(budgets[i].Limit).Mul(decimal.NewFromInt(100)).Round(0))
+	}
+	return genListMsg(els), nil
+}
+
+func (s *MessageHandlerService) categoryName(id int) string {
+	if c, ok := s.categoryService.Find(strconv.Itoa(id)); ok {
+		return c.Name
+	}
+	return "#" + strconv.Itoa(id)
+}
+
+func (s *MessageHandlerService) handleHistory(ctx context.Context, userId int64, tokens []string) (string, error) {
+	count, err := strconv.Atoi(tokens[1])
+	if err != nil || count <= 0 {
+		return "", errors.New("count must be a positive number")
+	}
+	if count > maxHistoryCount {
+		count = maxHistoryCount
+	}
+
+	spendings, currencyCode, err := s.spendingService.GetLast(ctx, userId, count)
+	if err != nil {
+		return "", err
+	}
+	l := i18n.FromContext(ctx)
+	if len(spendings) == 0 {
+		return l.T("no data"), nil
+	}
+	els := make([]string, len(spendings))
+	for i := 0; i < len(spendings); i++ {
+		els[i] = fmt.Sprintf("%v. %v %v - %v%v",
+			spendings[i].Id, l.Date(spendings[i].Date), s.categoryName(spendings[i].CategoryId), l.Money(spendings[i].Value, currencyCode), formatNote(spendings[i]))
+	}
+	return genListMsg(els), nil
+}
+
+func formatNote(spending model.Spending) string {
+	words := make([]string, 0, len(spending.Tags)+1)
+	if spending.Note != "" {
+		words = append(words, spending.Note)
+	}
+	for i := 0; i < len(spending.Tags); i++ {
+		words = append(words, "#"+spending.Tags[i])
+	}
+	if len(words) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(words, " ") + ")"
+}
+
+func (s *MessageHandlerService) handleFind(ctx context.Context, userId int64, tokens []string) (string, error) {
+	startAt, endAt := time.Time{}, time.Now()
+	if tokens[2] != "" {
+		var err error
+		if startAt, endAt, err = parseReportReq(ctx, tokens[1:]); err != nil {
+			return "", err
+		}
+	}
+	spendings, currencyCode, err := s.spendingService.Find(ctx, userId, tokens[1], startAt, endAt)
+	if err != nil {
+		return "", err
+	}
+	l := i18n.FromContext(ctx)
+	if len(spendings) == 0 {
+		return l.T("nothing found"), nil
+	}
+	total := decimal.Zero
+	for i := 0; i < len(spendings); i++ {
+		total = total.Add(spendings[i].Value)
+	}
+	shown := spendings
+	if len(shown) > maxHistoryCount {
+		shown = shown[:maxHistoryCount]
+	}
+	els := make([]string, len(shown), len(shown)+1)
+	for i := 0; i < len(shown); i++ {
+		els[i] = fmt.Sprintf("%v. %v %v - %v%v",
+			shown[i].Id, l.Date(shown[i].Date), s.categoryName(shown[i].CategoryId), l.Money(shown[i].Value, currencyCode), formatNote(shown[i]))
+	}
+	els = append(els, l.N(len(spendings), "total: %v in %v spendings", l.Money(total, currencyCode), len(spendings)))
+	return genListMsg(els), nil
+}
+
+func parseSpendingId(idStr string) (int64, error) {
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return 0, errors.New("id must be a number")
+	}
+	return id, nil
+}
+
+func (s *MessageHandlerService) handleEdit(ctx context.Context, userId int64, tokens []string) (string, error) {
+	id, err := parseSpendingId(tokens[1])
+	if err != nil {
+		return "", err
+	}
+	spending, err := s.parseSpending(userId, tokens[2], tokens[3], tokens[4])
+	if err != nil {
+		return "", err
+	}
+	spending.Id = id
+	balanceAfter, err := s.spendingService.Update(ctx, spending)
+	if err != nil {
+		return "", err
+	}
+	l := i18n.FromContext(ctx)
+	return l.T("changed, current balance: %v", l.Number(balanceAfter)), nil
+}
+
+func (s *MessageHandlerService) handleDelete(ctx context.Context, userId int64, tokens []string) (string, error) {
+	id, err := parseSpendingId(tokens[1])
+	if err != nil {
+		return "", err
+	}
+	balanceAfter, err := s.spendingService.Delete(ctx, userId, id)
+	if err != nil {
+		return "", err
+	}
+	l := i18n.FromContext(ctx)
+	return l.T("deleted, current balance: %v", l.Number(balanceAfter)), nil
+}
+
+func (s *MessageHandlerService) handleUndo(ctx context.Context, userId int64, tokens []string) (string, error) {
+	deleted, balanceAfter, err := s.spendingService.Undo(ctx, userId)
+	if err != nil {
+		return "", err
+	}
+	l := i18n.FromContext(ctx)
+	return l.T("deleted %v %v - %v, current balance: %v",
+		l.Date(deleted.Date), s.categoryName(deleted.CategoryId), l.Number(deleted.Value.Round(2)), l.Number(balanceAfter)), nil
+}
+
+func (s *MessageHandlerService) handleRecurringAdd(ctx context.Context, userId int64, tokens []string) (string, error) {
+	cat, ok := s.categoryService.Find(tokens[2])
+	if !ok {
+		return "", i18n.Errorf("%w: %v", ErrUnknownCategory, tokens[2])
+	}
+	sum, err := decimal.NewFromString(tokens[3])
+	if err != nil || !sum.IsPositive() {
+		return "", errors.New("sum must be a positive number")
+	}
+	r, err := s.recurringService.Add(ctx, userId, cat.Id, sum, tokens[4])
+	if err != nil {
+		return "", err
+	}
+	return i18n.FromContext(ctx).T("recurring spending %v added, next: %v", r.Id, r.NextRunAt.Format(recurringDtTemplate)), nil
+}
+
+func (s *MessageHandlerService) handleRecurringList(ctx context.Context, userId int64, tokens []string) (string, error) {
+	if tokens[1] != "list" {
+		return "", errWrongFormat
+	}
+	all, err := s.recurringService.GetAll(userId)
+	if err != nil {
+		return "", err
+	}
+	l := i18n.FromContext(ctx)
+	if len(all) == 0 {
+		return l.T("no data"), nil
+	}
+	els := make([]string, len(all))
+	for i := 0; i < len(all); i++ {
+		els[i] = l.T("%v. %v - %v, %v, next: %v",
+			all[i].Id, all[i].CategoryName, l.Money(all[i].Value, all[i].CurrencyCode), all[i].Schedule, all[i].NextRunAt.Format(recurringDtTemplate))
+	}
+	return genListMsg(els), nil
+}
+
+func (s *MessageHandlerService) handleRecurringDelete(ctx context.Context, userId int64, tokens []string) (string, error) {
+	id, err := parseSpendingId(tokens[2])
+	if err != nil {
+		return "", err
+	}
+	if err := s.recurringService.Delete(userId, id); err != nil {
+		return "", err
+	}
+	return i18n.FromContext(ctx).T("deleted"), nil
+}
+
+func (s *MessageHandlerService) handleIncome(ctx context.Context, userId int64, tokens []string) (string, error) {
 	catStr := tokens[1]
 	sumStr := tokens[2]
 	dtStr := tokens[3]
 	var balanceAfter decimal.Decimal
 
-	if cat, err := strconv.Atoi(catStr); err != nil {
-		return "", errors.New("category must be a number")
-	} else if sum, err := decimal.NewFromString(sumStr); err != nil {
-		return "", errors.New("sum  must be a number")
-	} else if dt, err := time.Parse(dtTemplate, dtStr); err != nil {
+	if sum, err := decimal.NewFromString(sumStr); err != nil {
+		return "", errors.New("sum must be a number")
+	} else if dt, ok := parseDate(dtStr, time.Now()); !ok {
 		return "", errors.New("wrong date format")
-	} else if balanceAfter, err = s.spendingService.SaveTx(ctx, model.NewSpending(sum, cat, dt)); err != nil {
+	} else if cat, ok := s.incomeCategoryService.Find(catStr); !ok {
+		return "", i18n.Errorf("%w: %v", ErrUnknownCategory, catStr)
+	} else if balanceAfter, err = s.incomeService.SaveTx(ctx, model.NewIncome(userId, sum, cat.Id, dt)); err != nil {
 		return "", err
 	}
-	return fmt.Sprintf("added, current balance: %v", balanceAfter), nil
+	l := i18n.FromContext(ctx)
+	return l.T("%v, current balance: %v", l.T("added"), l.Number(balanceAfter)), nil
 }
 
-func parseReportReq(spanCtx context.Context, strs []string) (time.Time, time.Time, error) {
-	endAt := time.Now().Truncate(24 * time.Hour)
-	var startAt time.Time
-
-	switch strs[1] {
-	case "w":
-		startAt = endAt.AddDate(0, 0, -7)
-	case "m":
-		startAt = endAt.AddDate(0, -1, 0)
-	case "y":
-		startAt = endAt.AddDate(-1, 0, 0)
-	default:
-		return time.Time{}, time.Time{}, errWrongFormat
+func (s *MessageHandlerService) handleAddIncomeCategory(ctx context.Context, userId int64, tokens []string) (string, error) {
+	c, err := s.incomeCategoryService.Add(userId, tokens[1])
+	if err != nil {
+		return "", err
 	}
-	return startAt, endAt, nil
+	return i18n.FromContext(ctx).T("income category added: %v - %v", c.Id, c.Name), nil
 }
-func (s *MessageHandlerService) handleReport(spanCtx context.Context, strs []string) (string, error) {
-	startAt, endAt, err := parseReportReq(spanCtx, strs)
+
+func (s *MessageHandlerService) handleAddCategory(ctx context.Context, userId int64, tokens []string) (string, error) {
+	c, err := s.categoryService.Add(userId, tokens[1])
 	if err != nil {
 		return "", err
 	}
+	return i18n.FromContext(ctx).T("category added: %v - %v", c.Id, c.Name), nil
+}
 
-	if data, c, err := s.spendingService.GetStatsBy(spanCtx, startAt, endAt); err != nil {
+func (s *MessageHandlerService) handleRenameCategory(ctx context.Context, userId int64, tokens []string) (string, error) {
+	if err := s.categoryService.Rename(userId, tokens[1], tokens[2]); err != nil {
 		return "", err
-	} else {
-		return formatStats(spanCtx, startAt, endAt, data, c), nil
 	}
+	return i18n.FromContext(ctx).T("successfully renamed"), nil
+}
+
+func (s *MessageHandlerService) handleDeleteCategory(ctx context.Context, userId int64, tokens []string) (string, error) {
+	if err := s.categoryService.Archive(userId, tokens[1]); err != nil {
+		return "", err
+	}
+	return i18n.FromContext(ctx).T("successfully deleted"), nil
+}
+
+func (s *MessageHandlerService) handleMergeCategory(ctx context.Context, userId int64, tokens []string) (string, error) {
+	if err := s.categoryService.Merge(userId, tokens[1], tokens[2]); err != nil {
+		return "", err
+	}
+	return i18n.FromContext(ctx).T("successfully deleted, spendings moved to %v", tokens[2]), nil
+}
+
+func parseReportReq(spanCtx context.Context, strs []string) (time.Time, time.Time, error) {
+	return time_util.ParseRange(strs[1], time.Now())
+}
+
+// parseReportArgs splits /report args into the period and the mode: "m by day", "this month compare", "2025 top 5".
+func parseReportArgs(text string) (string, model.ReportMode, int, error) {
+	tokens := strings.Fields(text)
+	n := len(tokens)
+	switch {
+	case n > 2 && tokens[n-2] == "by":
+		if tokens[n-1] == "category" {
+			return strings.Join(tokens[:n-2], " "), model.CategoryReport, 0, nil
+		}
+		mode := model.ReportMode(tokens[n-1])
+		if !mode.IsBreakdown() {
+			return "", "", 0, model.ErrWrongReportMode
+		}
+		return strings.Join(tokens[:n-2], " "), mode, 0, nil
+	case n > 1 && (tokens[n-1] == "compare" || tokens[n-1] == "vs"):
+		return strings.Join(tokens[:n-1], " "), model.CompareReport, 0, nil
+	case n > 1 && tokens[n-1] == "top":
+		return strings.Join(tokens[:n-1], " "), model.TopReport, defaultTopCount, nil
+	case n > 2 && tokens[n-2] == "top":
+		count, err := strconv.Atoi(tokens[n-1])
+		if err != nil || count <= 0 {
+			return "", "", 0, errors.New("count must be a positive number")
+		}
+		if count > maxHistoryCount {
+			count = maxHistoryCount
+		}
+		return strings.Join(tokens[:n-2], " "), model.TopReport, count, nil
+	}
+	return text, model.CategoryReport, 0, nil
+}
+
+func (s *MessageHandlerService) parseReportCmd(spanCtx context.Context, userId int64, text string) (*model.ReportRequest, time.Time, time.Time, error) {
+	fields := strings.Fields(text)
+	chart := len(fields) > 1 && fields[len(fields)-1] == "chart"
+	if chart {
+		text = strings.Join(fields[:len(fields)-1], " ")
+	}
+	period, mode, top, err := parseReportArgs(text)
+	if err != nil {
+		return nil, time.Time{}, time.Time{}, err
+	}
+	startAt, endAt, err := parseReportReq(spanCtx, []string{"/report", period})
+	if err != nil {
+		return nil, time.Time{}, time.Time{}, err
+	}
+	request := model.NewReportRequest(userId, startAt, endAt)
+	request.Mode = mode
+	request.Top = top
+	request.Chart = chart
+	return request, startAt, endAt, nil
+}
+
+func (s *MessageHandlerService) handleReport(spanCtx context.Context, userId int64, strs []string) (string, error) {
+	request, startAt, endAt, err := s.parseReportCmd(spanCtx, userId, strs[1])
+	if err != nil {
+		return "", err
+	}
+	report, c, err := s.buildReport(spanCtx, request, startAt, endAt)
+	if err != nil {
+		return "", err
+	}
+	if report.Chart {
+		if err := s.sendReportCharts(spanCtx, report, c); err != nil {
+			return "", err
+		}
+	}
+	return formatReport(spanCtx, report, c), nil
+}
+
+func (s *MessageHandlerService) buildReport(spanCtx context.Context, request *model.ReportRequest, startAt, endAt time.Time) (*model.Report, string, error) {
+	userId := request.UserId
+	report := model.NewReport(userId, startAt, endAt, nil, nil)
+	report.Mode = request.Mode
+	report.Chart = request.Chart
+	var c string
+	var err error
+	if request.Chart && !request.Mode.IsBreakdown() {
+		if report.Buckets, _, err = s.spendingService.GetBreakdown(spanCtx, userId, startAt, endAt, model.DayReport); err != nil {
+			return nil, "", err
+		}
+	}
+	switch {
+	case request.Mode.IsBreakdown():
+		report.Buckets, c, err = s.spendingService.GetBreakdown(spanCtx, userId, startAt, endAt, request.Mode)
+	case request.Mode == model.CompareReport:
+		report.PreviousStart, report.PreviousEnd = model.PreviousRange(startAt, endAt)
+		if report.Data, c, err = s.spendingService.GetStatsBy(spanCtx, userId, startAt, endAt); err != nil {
+			return nil, "", err
+		}
+		report.Previous, _, err = s.spendingService.GetStatsBy(spanCtx, userId, report.PreviousStart, report.PreviousEnd)
+	case request.Mode == model.TopReport:
+		report.Top, c, err = s.spendingService.GetTop(spanCtx, userId, startAt, endAt, request.Top)
+	default:
+		if report.Data, c, err = s.spendingService.GetStatsBy(spanCtx, userId, startAt, endAt); err != nil {
+			return nil, "", err
+		}
+		report.Income, _, err = s.incomeService.GetStatsBy(spanCtx, userId, startAt, endAt)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return report, c, nil
 }
 
 func (s *MessageHandlerService) handleReportAsync(spanCtx context.Context, userId int64, strs []string) (string, error) {
-	startAt, endAt, err := parseReportReq(spanCtx, strs)
+	request, _, _, err := s.parseReportCmd(spanCtx, userId, strs[1])
 	if err != nil {
 		return "", err
 	}
-	if err := s.reportProducer.Send(model.NewReportRequest(userId, startAt, endAt)); err != nil {
+	// асинхронный отчёт по категориям всегда приходит с графиками
+	if request.Mode == model.CategoryReport {
+		request.Chart = true
+	}
+	// сервис отчётов считает суммы в валюте пользователя, как и синхронный отчёт
+	cur, err := s.currencyService.GetCurrentCurrency(spanCtx, userId)
+	if err != nil {
 		return "", err
 	}
-	return "calculating report...", err
+	request.Currency = &cur
+	if err := s.reportProducer.Send(spanCtx, request); err != nil {
+		return "", err
+	}
+	return i18n.FromContext(spanCtx).T("calculating report..."), err
 }
 
 func (s *MessageHandlerService) reportResultListen(reportResultCh <-chan *model.Report) {
 	for result := range reportResultCh {
-		if err := s.tgClient.SendMessage(formatStats(context.Background(), result.Start, result.End, result.Data, ""), result.UserId); err != nil {
-			Log.Error("failed to send report request", zap.Error(err))
+		s.sendReportResult(result)
+	}
+}
+
+// sendReportResult sends the report of the report service to the user in the trace of the request.
+func (s *MessageHandlerService) sendReportResult(result *model.Report) {
+	span, ctx := observability.StartSpanFromHeaders(context.Background(), result.Trace, "msg_handler: send report result")
+	defer span.Finish()
+	span.SetTag("request_id", result.RequestId)
+
+	ctx = i18n.WithLang(ctx, s.userLanguage(result.UserId))
+	if result.Error != "" {
+		Log.Error("report service failed", zap.String("error", result.Error), zap.String("requestId", result.RequestId))
+		ext.Error.Set(span, true)
+		if err := s.tgClient.SendMessage(i18n.FromContext(ctx).T("failed to build the report, try again later"), result.UserId); err != nil {
+			Log.Error("failed to send report error", zap.Error(err))
+		}
+		return
+	}
+	if result.DocumentName != "" {
+		if err := s.tgClient.SendDocument(result.Document, result.DocumentName, result.UserId); err != nil {
+			Log.Error("failed to send export", zap.Error(err))
+			ext.Error.Set(span, true)
 		}
+		return
 	}
+	if result.Chart {
+		if err := s.sendReportCharts(ctx, result, result.CurrencyCode); err != nil {
+			Log.Error("failed to send report charts", zap.Error(err))
+			ext.Error.Set(span, true)
+		}
+	}
+	if err := s.tgClient.SendMessage(formatReport(ctx, result, result.CurrencyCode), result.UserId); err != nil {
+		Log.Error("failed to send report request", zap.Error(err))
+		ext.Error.Set(span, true)
+	}
+}
+
+func (s *MessageHandlerService) handleCurrencyChange(ctx context.Context, userId int64, strs []string) (string, error) {
+	if err := s.currencyService.UpdateCurrentCurrency(userId, strs[1]); err != nil {
+		return "", err
+	}
+	return i18n.FromContext(ctx).T("successfully changed"), nil
+}
+
+var languageNames = map[i18n.Lang]string{
+	i18n.English: "English",
+	i18n.Russian: "Русский",
+}
+
+var languageKeyboard = model.Keyboard{
+	{{Text: languageNames[i18n.English], Data: "/lang en"}, {Text: languageNames[i18n.Russian], Data: "/lang ru"}, {Text: "auto", Data: "/lang auto"}},
 }
 
-func (s *MessageHandlerService) handleCurrencyChange(ctx context.Context, strs []string) (string, error) {
-	if err := s.currencyService.UpdateCurrentCurrency(strs[1]); err != nil {
+// handleLanguage sets the language chosen by the user, auto returns to the language of telegram.
+func (s *MessageHandlerService) handleLanguage(ctx context.Context, userId int64, strs []string) (string, error) {
+	l, fixed := i18n.Detect(strs[1]), strs[0] != "auto"
+	if fixed {
+		var ok bool
+		if l, ok = i18n.Parse(strs[0]); !ok {
+			return "", errors.New("unknown language, use en, ru or auto")
+		}
+	}
+	if err := s.languages.SetLanguage(userId, string(l), fixed); err != nil {
 		return "", err
 	}
-	return "successfully changed", nil
+	// ответ уже на новом языке
+	return l.T("language: %v", languageNames[l]), nil
+}
+
+// language returns the language set with /lang or detected from the language of telegram.
+func (s *MessageHandlerService) language(msg *model.Message) i18n.Lang {
+	detected := ""
+	if msg.LanguageCode != "" {
+		detected = string(i18n.Detect(msg.LanguageCode))
+	}
+	code, err := s.languages.ResolveLanguage(msg.UserID, detected)
+	if err != nil {
+		Log.Error("failed to get language", zap.Error(err))
+		return i18n.Detect(msg.LanguageCode)
+	}
+	return i18n.Of(code)
+}
+
+// userLanguage returns the stored language for messages sent not in reply to the user.
+func (s *MessageHandlerService) userLanguage(userId int64) i18n.Lang {
+	code, err := s.languages.GetLanguage(userId)
+	if err != nil {
+		Log.Error("failed to get language", zap.Error(err))
+	}
+	return i18n.Of(code)
 }
 
-func formatStats(spanCtx context.Context, start time.Time, end time.Time, r map[string]decimal.Decimal, currencyCode string) string {
+func formatStats(spanCtx context.Context, start time.Time, end time.Time, expenses, income map[string]decimal.Decimal, currencyCode string) string {
 	span, _ := opentracing.StartSpanFromContext(spanCtx, "msg_handler: formatStats response")
 	defer span.Finish()
 
-	if len(r) != 0 {
-		result := fmt.Sprintf("from: %v, to: %v\n", start.Format(dtTemplate), end.Format(dtTemplate))
-		cats := make([]string, 0, len(r))
-		for k := range r {
+	l := i18n.FromContext(spanCtx)
+	if len(expenses) == 0 && len(income) == 0 {
+		return l.T("no data")
+	}
+	result := l.T("from: %v, to: %v\n", l.Date(start), l.Date(end))
+	expensesSection, expensesTotal := formatStatsSection(l, expenses, currencyCode)
+	if len(expenses) != 0 {
+		result += l.T("expenses:\n") + expensesSection
+	}
+	incomeSection, incomeTotal := formatStatsSection(l, income, currencyCode)
+	if len(income) != 0 {
+		result += l.T("income:\n") + incomeSection
+	}
+	result += l.T("expenses total: %v\n", l.Money(expensesTotal, currencyCode))
+	result += l.T("income total: %v\n", l.Money(incomeTotal, currencyCode))
+	result += l.T("net: %v\n", l.Money(incomeTotal.Sub(expensesTotal), currencyCode))
+	return result
+}
+
+func formatReport(spanCtx context.Context, r *model.Report, currencyCode string) string {
+	l := i18n.FromContext(spanCtx)
+	switch {
+	case r.Mode.IsBreakdown():
+		return formatBreakdown(l, r, currencyCode)
+	case r.Mode == model.CompareReport:
+		return formatComparison(l, r, currencyCode)
+	case r.Mode == model.TopReport:
+		return formatTop(l, r, currencyCode)
+	}
+	return formatStats(spanCtx, r.Start, r.End, r.Data, r.Income, currencyCode)
+}
+
+func formatBreakdown(l i18n.Lang, r *model.Report, currencyCode string) string {
+	if len(r.Buckets) == 0 {
+		return l.T("no data")
+	}
+	result := l.T("from: %v, to: %v by %v\n", l.Date(r.Start), l.Date(r.End), l.T(string(r.Mode)))
+	total := decimal.Zero
+	for i := 0; i < len(r.Buckets); i++ {
+		section, bucketTotal := formatStatsSection(l, r.Buckets[i].Data, currencyCode)
+		result += fmt.Sprintf("%v: %v\n", l.Date(r.Buckets[i].Start), l.Money(bucketTotal, currencyCode))
+		result += "  " + strings.ReplaceAll(strings.TrimSuffix(section, "\n"), "\n", "\n  ") + "\n"
+		total = total.Add(bucketTotal)
+	}
+	result += l.T("expenses total: %v\n", l.Money(total, currencyCode))
+	return result
+}
+
+func formatComparison(l i18n.Lang, r *model.Report, currencyCode string) string {
+	if len(r.Data) == 0 && len(r.Previous) == 0 {
+		return l.T("no data")
+	}
+	result := l.T("%v - %v vs %v - %v\n",
+		l.Date(r.Start), l.Date(r.End), l.Date(r.PreviousStart), l.Date(r.PreviousEnd))
+	cats := make([]string, 0, len(r.Data)+len(r.Previous))
+	for k := range r.Data {
+		cats = append(cats, k)
+	}
+	for k := range r.Previous {
+		if _, ok := r.Data[k]; !ok {
 			cats = append(cats, k)
 		}
-		sort.Slice(cats, func(i, j int) bool { return cats[i] < cats[j] })
-		for i := 0; i < len(cats); i++ {
-			result += fmt.Sprintf("%v - %v %v\n", cats[i], r[cats[i]].Round(2), currencyCode)
+	}
+	sort.Strings(cats)
+	total, prevTotal := decimal.Zero, decimal.Zero
+	for i := 0; i < len(cats); i++ {
+		cur, prev := r.Data[cats[i]], r.Previous[cats[i]]
+		result += cats[i] + ": " + formatDelta(l, cur, prev, currencyCode) + "\n"
+		total, prevTotal = total.Add(cur), prevTotal.Add(prev)
+	}
+	result += l.T("total: %v\n", formatDelta(l, total, prevTotal, currencyCode))
+	return result
+}
+
+// formatDelta formats the value with its change against the previous one: "₽300.00 (was ₽200.00, +100, +50%)".
+func formatDelta(l i18n.Lang, cur, prev decimal.Decimal, currencyCode string) string {
+	delta := cur.Sub(prev)
+	sign := ""
+	if delta.IsPositive() {
+		sign = "+"
+	}
+	percent := l.T("new")
+	if !prev.IsZero() {
+		percent = sign + delta.Div(prev).Mul(decimal.NewFromInt(100)).Round(0).String() + "%"
+	}
+	return l.T("%v (was %v, %v%v, %v)", l.Money(cur, currencyCode), l.Money(prev, currencyCode), sign, l.Number(delta.Round(2)), percent)
+}
+
+func formatTop(l i18n.Lang, r *model.Report, currencyCode string) string {
+	if len(r.Top) == 0 {
+		return l.T("no data")
+	}
+	els := make([]string, len(r.Top)+1)
+	els[0] = l.T("top %v from: %v, to: %v", len(r.Top), l.Date(r.Start), l.Date(r.End))
+	for i := 0; i < len(r.Top); i++ {
+		els[i+1] = fmt.Sprintf("%v. %v %v - %v%v", i+1, l.Date(r.Top[i].Date), r.Top[i].Category, l.Money(r.Top[i].Value, currencyCode),
+			formatNote(model.Spending{Note: r.Top[i].Note}))
+	}
+	return genListMsg(els)
+}
+
+func formatStatsSection(l i18n.Lang, r map[string]decimal.Decimal, currencyCode string) (string, decimal.Decimal) {
+	result := ""
+	total := decimal.Zero
+	cats := make([]string, 0, len(r))
+	for k := range r {
+		cats = append(cats, k)
+	}
+	sort.Slice(cats, func(i, j int) bool { return cats[i] < cats[j] })
+	for i := 0; i < len(cats); i++ {
+		result += fmt.Sprintf("%v - %v\n", cats[i], l.Money(r[cats[i]], currencyCode))
+		total = total.Add(r[cats[i]])
+	}
+	return result, total
+}
+
+func (s *MessageHandlerService) handleExport(spanCtx context.Context, userId int64, strs []string) (string, error) {
+	period, format, err := parseExportArgs(strs[1])
+	if err != nil {
+		return "", err
+	}
+	startAt, endAt, err := time_util.ParseRange(period, time.Now())
+	if err != nil {
+		return "", err
+	}
+	entries, cur, err := s.spendingService.GetEntries(spanCtx, userId, startAt, endAt, maxSyncExportRows+1)
+	if err != nil {
+		return "", err
+	}
+	l := i18n.FromContext(spanCtx)
+	if len(entries) == 0 {
+		return l.T("no spendings for the period"), nil
+	}
+	if len(entries) > maxSyncExportRows {
+		if s.reportProducer != nil {
+			request := model.NewReportRequest(userId, startAt, endAt)
+			request.Export = string(format)
+			request.Currency = &cur
+			if err := s.reportProducer.Send(spanCtx, request); err != nil {
+				return "", err
+			}
+			return l.T("export is being prepared, the file will be sent when it is ready"), nil
+		}
+		if entries, _, err = s.spendingService.GetEntries(spanCtx, userId, startAt, endAt, math.MaxInt32); err != nil {
+			return "", err
+		}
+	}
+	data, err := export.Write(format, entries, cur)
+	if err != nil {
+		return "", err
+	}
+	if err := s.tgClient.SendDocument(data, export.FileName(format, startAt, endAt), userId); err != nil {
+		return "", err
+	}
+	return l.T("exported spendings: %v", len(entries)), nil
+}
+
+// parseExportArgs splits /export arguments into the period and the file format, the last month in csv by default.
+func parseExportArgs(text string) (string, export.Format, error) {
+	fields := strings.Fields(text)
+	format := export.CSV
+	if len(fields) > 0 {
+		if f, err := export.ParseFormat(fields[len(fields)-1]); err == nil {
+			format, fields = f, fields[:len(fields)-1]
+		}
+	}
+	if len(fields) == 0 {
+		return "m", format, nil
+	}
+	return strings.Join(fields, " "), format, nil
+}
+
+// сколько строк выписки показываем до подтверждения
+var importPreviewLines = 20
+
+func (s *MessageHandlerService) handleImportFile(ctx context.Context, userId int64, name string, data []byte) (string, error) {
+	preview, err := s.importService.Preview(ctx, userId, name, data)
+	if err != nil {
+		return "", err
+	}
+	return formatImportPreview(i18n.FromContext(ctx), preview), nil
+}
+
+func formatImportPreview(l i18n.Lang, preview model.ImportPreview) string {
+	var sb strings.Builder
+	sb.WriteString(l.T("spendings to import: %v, duplicates skipped: %v\n", len(preview.Items), preview.Duplicates))
+	for i := 0; i < len(preview.Items) && i < importPreviewLines; i++ {
+		sp := preview.Items[i].Spending
+		sb.WriteString(fmt.Sprintf("%v %v%v - %v (%v)\n",
+			l.Date(sp.Date), preview.Items[i].CategoryName, guessedMark(l, preview.Items[i]), l.Money(sp.Value, sp.CurrencyCode), sp.Note))
+	}
+	if len(preview.Items) > importPreviewLines {
+		sb.WriteString(l.T("... and %v more\n", len(preview.Items)-importPreviewLines))
+	}
+	if len(preview.Uncategorized) > 0 {
+		sb.WriteString(l.N(len(preview.Uncategorized), "no category for %v spendings, they will be skipped, add rules with /rule add [category] substring [merchant] and send the file again:\n", len(preview.Uncategorized)))
+		for i := 0; i < len(preview.Uncategorized) && i < importPreviewLines; i++ {
+			sp := preview.Uncategorized[i].Spending
+			sb.WriteString(fmt.Sprintf("%v %v - %v", l.Date(sp.Date), sp.Note, l.Money(sp.Value, sp.CurrencyCode)))
+			if preview.Uncategorized[i].CategoryName != "" {
+				sb.WriteString(l.T(", maybe %v", preview.Uncategorized[i].CategoryName))
+			}
+			sb.WriteString("\n")
+		}
+		if len(preview.Uncategorized) > importPreviewLines {
+			sb.WriteString(l.T("... and %v more\n", len(preview.Uncategorized)-importPreviewLines))
+		}
+	}
+	switch {
+	case len(preview.Guesses()) > 0:
+		sb.WriteString(l.T("/import confirm - save, /import confirm all - save with the guessed categories too, /import cancel - discard"))
+	case len(preview.Items) > 0:
+		sb.WriteString(l.T("/import confirm - save, /import cancel - discard"))
+	}
+	return sb.String()
+}
+
+func guessedMark(l i18n.Lang, item model.ImportItem) string {
+	if item.Guessed {
+		return l.T(" (guessed)")
+	}
+	return ""
+}
+
+func (s *MessageHandlerService) handleImport(ctx context.Context, userId int64, tokens []string) (string, error) {
+	switch tokens[1] {
+	case "confirm", "confirm all":
+		count, balance, err := s.importService.Confirm(ctx, userId, tokens[1] == "confirm all")
+		if err != nil {
+			return "", err
+		}
+		l := i18n.FromContext(ctx)
+		return l.T("imported spendings: %v, current balance: %v", count, l.Number(balance)), nil
+	case "cancel":
+		if !s.importService.Cancel(userId) {
+			return "", ErrNoPendingImport
 		}
-		return result
+		return i18n.FromContext(ctx).T("import canceled"), nil
+	}
+	return "", errWrongFormat
+}
+
+func (s *MessageHandlerService) handleRuleAdd(ctx context.Context, userId int64, tokens []string) (string, error) {
+	cat, ok := s.categoryService.Find(tokens[2])
+	if !ok {
+		return "", i18n.Errorf("%w: %v", ErrUnknownCategory, tokens[2])
+	}
+	kind, err := model.ParseRuleKind(tokens[3])
+	if err != nil {
+		return "", err
+	}
+	r, err := s.categorizer.AddRule(userId, cat.Id, kind, tokens[4])
+	if err != nil {
+		return "", err
+	}
+	return i18n.FromContext(ctx).T("rule %v added: %v %q - %v", r.Id, r.Kind, r.Pattern, cat.Name), nil
+}
+
+func (s *MessageHandlerService) handleRuleList(ctx context.Context, userId int64, tokens []string) (string, error) {
+	if tokens[1] != "list" {
+		return "", errWrongFormat
+	}
+	all, err := s.categorizer.GetRules(userId)
+	if err != nil {
+		return "", err
+	}
+	if len(all) == 0 {
+		return i18n.FromContext(ctx).T("no data"), nil
+	}
+	els := make([]string, len(all))
+	for i := 0; i < len(all); i++ {
+		els[i] = fmt.Sprintf("%v. %v %q - %v", all[i].Id, all[i].Kind, all[i].Pattern, all[i].CategoryName)
+	}
+	return genListMsg(els), nil
+}
+
+func (s *MessageHandlerService) handleRuleDelete(ctx context.Context, userId int64, tokens []string) (string, error) {
+	id, err := parseSpendingId(tokens[2])
+	if err != nil {
+		return "", err
+	}
+	if err := s.categorizer.DeleteRule(userId, id); err != nil {
+		return "", err
 	}
-	return "no data"
+	return i18n.FromContext(ctx).T("deleted"), nil
 }
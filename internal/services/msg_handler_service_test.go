@@ -1,16 +1,28 @@
 package services
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"image"
+	"image/png"
+	"os"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/api"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/export"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/i18n"
 	mocks "gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/mocks/services"
 	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/observability"
+	"google.golang.org/grpc/metadata"
 )
 
 func Test_OnStartCommand_ShouldAnswerWithIntroMessage(t *testing.T) {
@@ -23,6 +35,15 @@ func Test_OnStartCommand_ShouldAnswerWithIntroMessage(t *testing.T) {
 		mocks.NewMockCurrencyService(ctrl),
 		mocks.NewMockCategoryService(ctrl),
 		mocks.NewMockStateService(ctrl),
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		nil,
 	)
 
 	sender.EXPECT().SendMessage("hello", int64(123))
@@ -39,13 +60,24 @@ func Test_OnUnknownCommand_ShouldAnswerWithHelpMessage(t *testing.T) {
 	ctrl := gomock.NewController(t)
 
 	sender := mocks.NewMockMessageSender(ctrl)
-	sender.EXPECT().SendMessage("не знаю эту команду", int64(123))
+	sender.EXPECT().SendMessage("unknown command, see /help", int64(123))
+	dialogs := mocks.NewMockDialogServiceI(ctrl)
+	dialogs.EXPECT().Get(gomock.Any(), int64(123)).Return(model.Dialog{}, false, nil)
 	handlerService := NewMessageHandlerService(
 		sender,
 		mocks.NewMockSpendingServiceI(ctrl),
 		mocks.NewMockCurrencyService(ctrl),
 		mocks.NewMockCategoryService(ctrl),
 		mocks.NewMockStateService(ctrl),
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		dialogs,
+		anyLanguage(ctrl),
+		nil,
+		nil,
 	)
 
 	err := handlerService.HandleMsg(&model.Message{
@@ -56,17 +88,36 @@ func Test_OnUnknownCommand_ShouldAnswerWithHelpMessage(t *testing.T) {
 	assert.NoError(t, err)
 }
 
-func Test_OnAdd_shouldAnswerErrOnWrongCountOfTokens(t *testing.T) {
+func Test_OnAdd_shouldAskCategoryIfMissing(t *testing.T) {
 	ctrl := gomock.NewController(t)
 
 	sender := mocks.NewMockMessageSender(ctrl)
-	sender.EXPECT().SendMessage("wrong format", int64(123))
+	sender.EXPECT().SendKeyboard("which category is it? e.g. /add 350 food, see /categories", model.Keyboard{
+		{{Text: "food", Data: "1"}, {Text: "taxi", Data: "2"}},
+	}, int64(123))
+	categoryService := mocks.NewMockCategoryService(ctrl)
+	categoryService.EXPECT().GetAll().Return([]model.Category{{Id: 1, Name: "food"}, {Id: 2, Name: "taxi"}})
+	dialogs := mocks.NewMockDialogServiceI(ctrl)
+	dialogs.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, dialog model.Dialog) error {
+		assert.Equal(t, model.StepCategory, dialog.Step())
+		assert.Equal(t, time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), dialog.Spending.Date)
+		return nil
+	})
 	handlerService := NewMessageHandlerService(
 		sender,
 		mocks.NewMockSpendingServiceI(ctrl),
 		mocks.NewMockCurrencyService(ctrl),
-		mocks.NewMockCategoryService(ctrl),
+		categoryService,
 		mocks.NewMockStateService(ctrl),
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		dialogs,
+		anyLanguage(ctrl),
+		nil,
+		nil,
 	)
 
 	err := handlerService.HandleMsg(&model.Message{
@@ -77,18 +128,36 @@ func Test_OnAdd_shouldAnswerErrOnWrongCountOfTokens(t *testing.T) {
 	assert.NoError(t, err)
 }
 
-func Test_OnAdd_shouldAnswerErrOnNonNumberCatValue(t *testing.T) {
+func Test_OnAdd_shouldAskCategoryOnUnknownCat(t *testing.T) {
 	ctrl := gomock.NewController(t)
 
 	sender := mocks.NewMockMessageSender(ctrl)
-	sender.EXPECT().SendMessage("category must be a number", int64(123))
+	sender.EXPECT().SendKeyboard(`which category is it? "q" is unknown, see /categories`, model.Keyboard{
+		{{Text: "food", Data: "1"}},
+	}, int64(123))
+	dialogs := mocks.NewMockDialogServiceI(ctrl)
+	dialogs.EXPECT().Save(gomock.Any(), gomock.Any())
+	categoryService := mocks.NewMockCategoryService(ctrl)
+	categoryService.EXPECT().Find("q").Return(model.Category{}, false)
+	categoryService.EXPECT().GetAll().Return([]model.Category{{Id: 1, Name: "food"}})
+	categorizer := mocks.NewMockCategorizerI(ctrl)
+	categorizer.EXPECT().Guess(gomock.Any(), int64(123), "q").Return(model.CategoryGuess{}, false, nil)
 
 	handlerService := NewMessageHandlerService(
 		sender,
 		mocks.NewMockSpendingServiceI(ctrl),
 		mocks.NewMockCurrencyService(ctrl),
-		mocks.NewMockCategoryService(ctrl),
+		categoryService,
 		mocks.NewMockStateService(ctrl),
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		categorizer,
+		dialogs,
+		anyLanguage(ctrl),
+		nil,
+		nil,
 	)
 
 	err := handlerService.HandleMsg(&model.Message{
@@ -99,44 +168,66 @@ func Test_OnAdd_shouldAnswerErrOnNonNumberCatValue(t *testing.T) {
 	assert.NoError(t, err)
 }
 
-func Test_OnAdd_shouldAnswerErrOnNonNumberSumValue(t *testing.T) {
+func Test_OnAdd_shouldAskSumIfMissing(t *testing.T) {
 	ctrl := gomock.NewController(t)
 
 	sender := mocks.NewMockMessageSender(ctrl)
-	sender.EXPECT().SendMessage("sum  must be a number", int64(123))
+	sender.EXPECT().SendMessage("how much? e.g. /add 350 food", int64(123))
+	categoryService := mocks.NewMockCategoryService(ctrl)
+	categoryService.EXPECT().Find("food").Return(model.Category{Id: 0, Name: "food"}, true)
 
 	handlerService := NewMessageHandlerService(
 		sender,
 		mocks.NewMockSpendingServiceI(ctrl),
 		mocks.NewMockCurrencyService(ctrl),
-		mocks.NewMockCategoryService(ctrl),
+		categoryService,
 		mocks.NewMockStateService(ctrl),
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		nil,
 	)
 
 	err := handlerService.HandleMsg(&model.Message{
-		Text:   "/add 1 q 01-01-2000",
+		Text:   "/add food q 01-01-2000",
 		UserID: 123,
 	}, context.TODO())
 
 	assert.NoError(t, err)
 }
 
-func Test_OnAdd_shouldAnswerErrOnBadDtFormat(t *testing.T) {
+func Test_OnAdd_shouldAskWhichNumberIsSum(t *testing.T) {
 	ctrl := gomock.NewController(t)
 
 	sender := mocks.NewMockMessageSender(ctrl)
-	sender.EXPECT().SendMessage("wrong date format", int64(123))
+	sender.EXPECT().SendMessage("which one is the sum: 350 or 200?", int64(123))
+	categoryService := mocks.NewMockCategoryService(ctrl)
+	categoryService.EXPECT().Find("food").Return(model.Category{Id: 0, Name: "food"}, true)
 
 	handlerService := NewMessageHandlerService(
 		sender,
 		mocks.NewMockSpendingServiceI(ctrl),
 		mocks.NewMockCurrencyService(ctrl),
-		mocks.NewMockCategoryService(ctrl),
+		categoryService,
 		mocks.NewMockStateService(ctrl),
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		nil,
 	)
 
 	err := handlerService.HandleMsg(&model.Message{
-		Text:   "/add 99 1 qwe",
+		Text:   "/add food 350 200",
 		UserID: 123,
 	}, context.TODO())
 
@@ -150,13 +241,26 @@ func Test_OnAdd_shouldSaveSuccessfull(t *testing.T) {
 	sender.EXPECT().SendMessage("added, current balance: 0", int64(123))
 	storage := mocks.NewMockSpendingServiceI(ctrl)
 	dt, _ := time.Parse("02-01-2006", "01-01-2000")
-	storage.EXPECT().SaveTx(gomock.Any(), model.NewSpending(decimal.NewFromInt(1), 1, dt))
+	storage.EXPECT().Save(gomock.Any(), model.NewSpending(123, decimal.NewFromInt(1), 1, dt))
+	categoryService := mocks.NewMockCategoryService(ctrl)
+	categoryService.EXPECT().Find("1").Return(model.Category{Id: 1, Name: "other"}, true)
+	stateService := mocks.NewMockStateService(ctrl)
+	stateService.EXPECT().GetCategoryBudget(int64(123), 1).Return(model.CategoryBudget{}, false, nil)
 	handlerService := NewMessageHandlerService(
 		sender,
 		storage,
-		mocks.NewMockCurrencyService(ctrl),
+		freshRates(ctrl),
+		categoryService,
+		stateService,
+		mocks.NewMockIncomeServiceI(ctrl),
 		mocks.NewMockCategoryService(ctrl),
-		mocks.NewMockStateService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		nil,
 	)
 
 	err := handlerService.HandleMsg(&model.Message{
@@ -167,11 +271,83 @@ func Test_OnAdd_shouldSaveSuccessfull(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func Test_OnAdd_shouldSaveByCategoryName(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	sender := mocks.NewMockMessageSender(ctrl)
+	sender.EXPECT().SendMessage("added, current balance: 0", int64(123))
+	storage := mocks.NewMockSpendingServiceI(ctrl)
+	dt, _ := time.Parse("02-01-2006", "01-01-2000")
+	storage.EXPECT().Save(gomock.Any(), model.NewSpending(123, decimal.NewFromInt(1), 0, dt))
+	categoryService := mocks.NewMockCategoryService(ctrl)
+	categoryService.EXPECT().Find("food").Return(model.Category{Id: 0, Name: "food"}, true)
+	stateService := mocks.NewMockStateService(ctrl)
+	stateService.EXPECT().GetCategoryBudget(int64(123), 0).Return(model.CategoryBudget{}, false, nil)
+	handlerService := NewMessageHandlerService(
+		sender,
+		storage,
+		freshRates(ctrl),
+		categoryService,
+		stateService,
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		nil,
+	)
+
+	err := handlerService.HandleMsg(&model.Message{
+		Text:   "/add food 1 01-01-2000",
+		UserID: 123,
+	}, context.TODO())
+
+	assert.NoError(t, err)
+}
+
+func Test_OnDeleteCategory_shouldMergeIntoTarget(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	sender := mocks.NewMockMessageSender(ctrl)
+	sender.EXPECT().SendMessage("successfully deleted, spendings moved to other", int64(123))
+	categoryService := mocks.NewMockCategoryService(ctrl)
+	categoryService.EXPECT().Merge(int64(123), "taxi", "other")
+	handlerService := NewMessageHandlerService(
+		sender,
+		mocks.NewMockSpendingServiceI(ctrl),
+		mocks.NewMockCurrencyService(ctrl),
+		categoryService,
+		mocks.NewMockStateService(ctrl),
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		nil,
+	)
+
+	err := handlerService.HandleMsg(&model.Message{
+		Text:   "/confirm /deletecategory taxi other",
+		UserID: 123,
+	}, context.TODO())
+
+	assert.NoError(t, err)
+}
+
 func Test_OnAdd_shouldReportSuccessfull(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	end := time.Now().Truncate(24 * time.Hour)
 	start := end.AddDate(0, 0, -7)
-	response := fmt.Sprintf("from: %v, to: %v\nfood - 1 rub\nother - 2 rub\n", start.Format("02-01-2006"), end.Format("02-01-2006"))
+	response := fmt.Sprintf("from: %v, to: %v\n"+
+		"expenses:\nfood - ₽1.00\nother - ₽2.00\n"+
+		"income:\nsalary - ₽10.00\n"+
+		"expenses total: ₽3.00\nincome total: ₽10.00\nnet: ₽7.00\n", start.Format("02-01-2006"), end.Format("02-01-2006"))
 	sender := mocks.NewMockMessageSender(ctrl)
 	sender.EXPECT().SendMessage(response, int64(123))
 	storage := mocks.NewMockSpendingServiceI(ctrl)
@@ -179,13 +355,25 @@ func Test_OnAdd_shouldReportSuccessfull(t *testing.T) {
 	reportData := make(map[string]decimal.Decimal)
 	reportData["food"] = decimal.NewFromInt(1)
 	reportData["other"] = decimal.NewFromInt(2)
-	storage.EXPECT().GetStatsBy(gomock.Any(), start, end).Return(reportData, "rub", nil)
+	storage.EXPECT().GetStatsBy(gomock.Any(), int64(123), start, end).Return(reportData, "rub", nil)
+	incomeService := mocks.NewMockIncomeServiceI(ctrl)
+	incomeService.EXPECT().GetStatsBy(gomock.Any(), int64(123), start, end).
+		Return(map[string]decimal.Decimal{"salary": decimal.NewFromInt(10)}, "rub", nil)
 	handlerService := NewMessageHandlerService(
 		sender,
 		storage,
 		mocks.NewMockCurrencyService(ctrl),
 		mocks.NewMockCategoryService(ctrl),
 		mocks.NewMockStateService(ctrl),
+		incomeService,
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		nil,
 	)
 
 	err := handlerService.HandleMsg(&model.Message{
@@ -195,3 +383,1645 @@ func Test_OnAdd_shouldReportSuccessfull(t *testing.T) {
 
 	assert.NoError(t, err)
 }
+
+func Test_OnHistory_shouldListLastSpendings(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	dt, _ := time.Parse("02-01-2006", "01-01-2000")
+
+	sender := mocks.NewMockMessageSender(ctrl)
+	sender.EXPECT().SendMessage("7. 01-01-2000 food - ₽10.00\n", int64(123))
+	storage := mocks.NewMockSpendingServiceI(ctrl)
+	storage.EXPECT().GetLast(gomock.Any(), int64(123), 10).
+		Return([]model.Spending{{Id: 7, UserId: 123, Value: decimal.NewFromInt(10), CategoryId: 0, Date: dt}}, "rub", nil)
+	categoryService := mocks.NewMockCategoryService(ctrl)
+	categoryService.EXPECT().Find("0").Return(model.Category{Id: 0, Name: "food"}, true)
+	handlerService := NewMessageHandlerService(
+		sender,
+		storage,
+		mocks.NewMockCurrencyService(ctrl),
+		categoryService,
+		mocks.NewMockStateService(ctrl),
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		nil,
+	)
+
+	err := handlerService.HandleMsg(&model.Message{
+		Text:   "/history",
+		UserID: 123,
+	}, context.TODO())
+
+	assert.NoError(t, err)
+}
+
+func Test_OnDelete_shouldAnswerNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	sender := mocks.NewMockMessageSender(ctrl)
+	sender.EXPECT().SendMessage("spending not found", int64(123))
+	storage := mocks.NewMockSpendingServiceI(ctrl)
+	storage.EXPECT().Delete(gomock.Any(), int64(123), int64(7)).Return(decimal.Decimal{}, model.ErrSpendingNotFound)
+	handlerService := NewMessageHandlerService(
+		sender,
+		storage,
+		mocks.NewMockCurrencyService(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockStateService(ctrl),
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		nil,
+	)
+
+	err := handlerService.HandleMsg(&model.Message{
+		Text:   "/confirm /delete 7",
+		UserID: 123,
+	}, context.TODO())
+
+	assert.NoError(t, err)
+}
+
+func Test_OnIncome_shouldSaveWithTodayByDefault(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	sender := mocks.NewMockMessageSender(ctrl)
+	sender.EXPECT().SendMessage("added, current balance: 1,100", int64(123))
+	today, _ := time.Parse("02-01-2006", time.Now().Format("02-01-2006"))
+	incomeService := mocks.NewMockIncomeServiceI(ctrl)
+	incomeService.EXPECT().SaveTx(gomock.Any(), model.NewIncome(123, decimal.NewFromInt(100), 1, today)).
+		Return(decimal.NewFromInt(1100), nil)
+	incomeCategoryService := mocks.NewMockCategoryService(ctrl)
+	incomeCategoryService.EXPECT().Find("salary").Return(model.Category{Id: 1, Name: "salary"}, true)
+	handlerService := NewMessageHandlerService(
+		sender,
+		mocks.NewMockSpendingServiceI(ctrl),
+		mocks.NewMockCurrencyService(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockStateService(ctrl),
+		incomeService,
+		incomeCategoryService,
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		nil,
+	)
+
+	err := handlerService.HandleMsg(&model.Message{
+		Text:   "/income salary 100",
+		UserID: 123,
+	}, context.TODO())
+
+	assert.NoError(t, err)
+}
+
+func Test_OnAdd_shouldAlertOnExceededLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	sender := mocks.NewMockMessageSender(ctrl)
+	gomock.InOrder(
+		sender.EXPECT().SendMessage("added, current balance: 0", int64(123)),
+		sender.EXPECT().SendMessage("limit for food exceeded: ₽110.00 of ₽100.00", int64(123)),
+	)
+	storage := mocks.NewMockSpendingServiceI(ctrl)
+	storage.EXPECT().Save(gomock.Any(), gomock.Any())
+	categoryService := mocks.NewMockCategoryService(ctrl)
+	categoryService.EXPECT().Find("food").Return(model.Category{Id: 0, Name: "food"}, true)
+	stateService := mocks.NewMockStateService(ctrl)
+	gomock.InOrder(
+		stateService.EXPECT().GetCategoryBudget(int64(123), 0).
+			Return(model.CategoryBudget{CategoryName: "food", Limit: decimal.NewFromInt(100), Spent: decimal.NewFromInt(90)}, true, nil),
+		stateService.EXPECT().GetCategoryBudget(int64(123), 0).
+			Return(model.CategoryBudget{CategoryName: "food", Limit: decimal.NewFromInt(100), Spent: decimal.NewFromInt(110)}, true, nil),
+	)
+	handlerService := NewMessageHandlerService(
+		sender,
+		storage,
+		freshRates(ctrl),
+		categoryService,
+		stateService,
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		nil,
+	)
+
+	err := handlerService.HandleMsg(&model.Message{
+		Text:   "/add food 20 01-01-2000",
+		UserID: 123,
+	}, context.TODO())
+
+	assert.NoError(t, err)
+}
+
+func Test_OnAdd_shouldShowSumInDisplayCurrency(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	today := time.Now()
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+
+	sender := mocks.NewMockMessageSender(ctrl)
+	sender.EXPECT().SendMessage("added €20.00 ($21.50), current balance: 900", int64(123))
+	storage := mocks.NewMockSpendingServiceI(ctrl)
+	storage.EXPECT().Save(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, spending model.Spending) (decimal.Decimal, *model.Currency, error) {
+			assert.Equal(t, "eur", spending.CurrencyCode)
+			assert.True(t, spending.Value.Equal(decimal.NewFromInt(20)))
+			return decimal.NewFromInt(900), nil, nil
+		})
+	categoryService := mocks.NewMockCategoryService(ctrl)
+	categoryService.EXPECT().Find("food").Return(model.Category{Id: 0, Name: "food"}, true)
+	currencyService := mocks.NewMockCurrencyService(ctrl)
+	// валюта отображения пользователя остаётся usd
+	currencyService.EXPECT().GetCurrentCurrency(gomock.Any(), int64(123)).Return(model.Currency{Code: "usd"}, nil)
+	currencyService.EXPECT().GetRate(gomock.Any(), "eur", today).Return(decimal.RequireFromString("0.01"), nil)
+	currencyService.EXPECT().GetRate(gomock.Any(), "usd", today).Return(decimal.RequireFromString("0.01075"), nil)
+	stateService := mocks.NewMockStateService(ctrl)
+	stateService.EXPECT().GetCategoryBudget(int64(123), 0).Return(model.CategoryBudget{}, false, nil)
+	handlerService := NewMessageHandlerService(
+		sender,
+		storage,
+		currencyService,
+		categoryService,
+		stateService,
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		nil,
+	)
+
+	err := handlerService.HandleMsg(&model.Message{
+		Text:   "/add food 20 eur",
+		UserID: 123,
+	}, context.TODO())
+
+	assert.NoError(t, err)
+}
+
+func Test_OnAdd_shouldWarnAboutStaleRate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	updatedAt := time.Date(2026, 3, 1, 10, 30, 0, 0, time.UTC)
+	sender := mocks.NewMockMessageSender(ctrl)
+	gomock.InOrder(
+		sender.EXPECT().SendMessage("added, current balance: 0", int64(123)),
+		sender.EXPECT().SendMessage("the usd rate was updated 01-03-2026 10:30, the sum in rub may be inaccurate", int64(123)),
+	)
+	storage := mocks.NewMockSpendingServiceI(ctrl)
+	// курс проверяет сервис трат при конвертации, обработчику остаётся только предупредить
+	storage.EXPECT().Save(gomock.Any(), gomock.Any()).Return(decimal.Zero, &model.Currency{Code: "usd", UpdatedAt: updatedAt}, nil)
+	categoryService := mocks.NewMockCategoryService(ctrl)
+	categoryService.EXPECT().Find("food").Return(model.Category{Id: 0, Name: "food"}, true)
+	currencyService := mocks.NewMockCurrencyService(ctrl)
+	stateService := mocks.NewMockStateService(ctrl)
+	stateService.EXPECT().GetCategoryBudget(int64(123), 0).Return(model.CategoryBudget{}, false, nil)
+	handlerService := NewMessageHandlerService(
+		sender,
+		storage,
+		currencyService,
+		categoryService,
+		stateService,
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		nil,
+	)
+
+	err := handlerService.HandleMsg(&model.Message{
+		Text:   "/add food 20",
+		UserID: 123,
+	}, context.TODO())
+
+	assert.NoError(t, err)
+}
+
+func Test_OnAdd_shouldRefuseStaleRate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	sender := mocks.NewMockMessageSender(ctrl)
+	sender.EXPECT().SendMessage("курс устарел: курс usd загружен 72 ч. назад, попробуйте позже", int64(123))
+	categoryService := mocks.NewMockCategoryService(ctrl)
+	categoryService.EXPECT().Find("food").Return(model.Category{Id: 0, Name: "food"}, true)
+	spendingService := mocks.NewMockSpendingServiceI(ctrl)
+	spendingService.EXPECT().Save(gomock.Any(), gomock.Any()).
+		Return(decimal.Zero, nil, i18n.Errorf("%w: the %v rate was loaded %v hours ago, try again later", model.ErrStaleRate, "usd", 72))
+	stateService := mocks.NewMockStateService(ctrl)
+	stateService.EXPECT().GetCategoryBudget(int64(123), 0).Return(model.CategoryBudget{}, false, nil)
+	languages := mocks.NewMockLanguageServiceI(ctrl)
+	languages.EXPECT().ResolveLanguage(int64(123), gomock.Any()).Return("ru", nil)
+	handlerService := NewMessageHandlerService(
+		sender,
+		spendingService,
+		mocks.NewMockCurrencyService(ctrl),
+		categoryService,
+		stateService,
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		languages,
+		nil,
+		nil,
+	)
+
+	err := handlerService.HandleMsg(&model.Message{
+		Text:   "/add food 20",
+		UserID: 123,
+	}, context.TODO())
+
+	assert.NoError(t, err)
+}
+
+func Test_limitAlert(t *testing.T) {
+	limit := decimal.NewFromInt(100)
+	tests := []struct {
+		name   string
+		lang   i18n.Lang
+		before decimal.Decimal
+		after  decimal.Decimal
+		alert  string
+	}{
+		{name: "below threshold", before: decimal.NewFromInt(10), after: decimal.NewFromInt(79)},
+		{name: "crossed 80%", before: decimal.NewFromInt(70), after: decimal.NewFromInt(85), alert: "80% of limit for food used: ₽85.00 of ₽100.00"},
+		{name: "already over 80%", before: decimal.NewFromInt(81), after: decimal.NewFromInt(90)},
+		{name: "crossed 100%", before: decimal.NewFromInt(90), after: decimal.NewFromInt(100), alert: "limit for food exceeded: ₽100.00 of ₽100.00"},
+		{name: "crossed both", before: decimal.NewFromInt(10), after: decimal.NewFromInt(120), alert: "limit for food exceeded: ₽120.00 of ₽100.00"},
+		{name: "russian", lang: i18n.Russian, before: decimal.NewFromInt(70), after: decimal.NewFromInt(85), alert: "израсходовано 80% лимита по food: 85,00 ₽ из 100,00 ₽"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.lang == "" {
+				tt.lang = i18n.English
+			}
+			alert, ok := limitAlert(
+				tt.lang,
+				model.CategoryBudget{CategoryName: "food", Limit: limit, Spent: tt.before},
+				model.CategoryBudget{CategoryName: "food", Limit: limit, Spent: tt.after},
+			)
+			assert.Equal(t, tt.alert != "", ok)
+			assert.Equal(t, tt.alert, alert)
+		})
+	}
+}
+
+func Test_OnPeriod_shouldChangeToMonthlyOnDay(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	sender := mocks.NewMockMessageSender(ctrl)
+	sender.EXPECT().SendMessage("successfully changed", int64(123))
+	stateService := mocks.NewMockStateService(ctrl)
+	stateService.EXPECT().SetPeriod(int64(123), model.MonthPeriod, 25)
+	handlerService := NewMessageHandlerService(
+		sender,
+		mocks.NewMockSpendingServiceI(ctrl),
+		mocks.NewMockCurrencyService(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		stateService,
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		nil,
+	)
+
+	err := handlerService.HandleMsg(&model.Message{
+		Text:   "/period month 25",
+		UserID: 123,
+	}, context.TODO())
+
+	assert.NoError(t, err)
+}
+
+func Test_OnPeriod_shouldAnswerErrOnUnknownPeriod(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	sender := mocks.NewMockMessageSender(ctrl)
+	sender.EXPECT().SendMessage("wrong budget period", int64(123))
+	handlerService := NewMessageHandlerService(
+		sender,
+		mocks.NewMockSpendingServiceI(ctrl),
+		mocks.NewMockCurrencyService(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockStateService(ctrl),
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		nil,
+	)
+
+	err := handlerService.HandleMsg(&model.Message{
+		Text:   "/period day",
+		UserID: 123,
+	}, context.TODO())
+
+	assert.NoError(t, err)
+}
+
+func Test_OnRecurringAdd_shouldJoinScheduleTokens(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	sender := mocks.NewMockMessageSender(ctrl)
+	sender.EXPECT().SendMessage("recurring spending 3 added, next: 01-04-2026 09:00", int64(123))
+	categoryService := mocks.NewMockCategoryService(ctrl)
+	categoryService.EXPECT().Find("rent").Return(model.Category{Id: 2, Name: "rent"}, true)
+	recurringService := mocks.NewMockRecurringServiceI(ctrl)
+	recurringService.EXPECT().Add(gomock.Any(), int64(123), 2, decimal.NewFromInt(30000), "0 9 1 * *").
+		Return(model.RecurringSpending{Id: 3, NextRunAt: time.Date(2026, 4, 1, 9, 0, 0, 0, time.UTC)}, nil)
+	handlerService := NewMessageHandlerService(
+		sender,
+		mocks.NewMockSpendingServiceI(ctrl),
+		mocks.NewMockCurrencyService(ctrl),
+		categoryService,
+		mocks.NewMockStateService(ctrl),
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		recurringService,
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		nil,
+	)
+
+	err := handlerService.HandleMsg(&model.Message{
+		Text:   "/recurring add rent 30000 0 9 1 * *",
+		UserID: 123,
+	}, context.TODO())
+
+	assert.NoError(t, err)
+}
+
+func Test_OnRecurringAdd_shouldAcceptScheduleAlias(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	sender := mocks.NewMockMessageSender(ctrl)
+	sender.EXPECT().SendMessage("recurring spending 4 added, next: 01-04-2026 00:00", int64(123))
+	categoryService := mocks.NewMockCategoryService(ctrl)
+	categoryService.EXPECT().Find("rent").Return(model.Category{Id: 2, Name: "rent"}, true)
+	recurringService := mocks.NewMockRecurringServiceI(ctrl)
+	recurringService.EXPECT().Add(gomock.Any(), int64(123), 2, decimal.NewFromInt(30000), "@monthly").
+		Return(model.RecurringSpending{Id: 4, NextRunAt: time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)}, nil)
+	handlerService := NewMessageHandlerService(
+		sender,
+		mocks.NewMockSpendingServiceI(ctrl),
+		mocks.NewMockCurrencyService(ctrl),
+		categoryService,
+		mocks.NewMockStateService(ctrl),
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		recurringService,
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		nil,
+	)
+
+	err := handlerService.HandleMsg(&model.Message{
+		Text:   "/recurring add rent 30000 @monthly",
+		UserID: 123,
+	}, context.TODO())
+
+	assert.NoError(t, err)
+}
+
+func Test_OnRecurringList_shouldShowEntries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	sender := mocks.NewMockMessageSender(ctrl)
+	sender.EXPECT().SendMessage("1. rent - ₽30,000.00, @monthly, next: 01-04-2026 00:00\n", int64(123))
+	recurringService := mocks.NewMockRecurringServiceI(ctrl)
+	recurringService.EXPECT().GetAll(int64(123)).Return([]model.RecurringSpending{{
+		Id: 1, CategoryName: "rent", Value: decimal.NewFromInt(30000), CurrencyCode: "rub",
+		Schedule: "@monthly", NextRunAt: time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+	}}, nil)
+	handlerService := NewMessageHandlerService(
+		sender,
+		mocks.NewMockSpendingServiceI(ctrl),
+		mocks.NewMockCurrencyService(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockStateService(ctrl),
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		recurringService,
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		nil,
+	)
+
+	err := handlerService.HandleMsg(&model.Message{
+		Text:   "/recurring list",
+		UserID: 123,
+	}, context.TODO())
+
+	assert.NoError(t, err)
+}
+
+func Test_OnAdd_shouldSaveNoteAndTags(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	sender := mocks.NewMockMessageSender(ctrl)
+	sender.EXPECT().SendMessage("added, current balance: 0", int64(123))
+	storage := mocks.NewMockSpendingServiceI(ctrl)
+	dt, _ := time.Parse("02-01-2006", "01-01-2000")
+	expected := model.NewSpending(123, decimal.NewFromInt(3000), 1, dt)
+	expected.Note = "new boots"
+	expected.Tags = []string{"winter", "kids"}
+	storage.EXPECT().Save(gomock.Any(), expected)
+	categoryService := mocks.NewMockCategoryService(ctrl)
+	categoryService.EXPECT().Find("other").Return(model.Category{Id: 1, Name: "other"}, true)
+	stateService := mocks.NewMockStateService(ctrl)
+	stateService.EXPECT().GetCategoryBudget(int64(123), 1).Return(model.CategoryBudget{}, false, nil)
+	categorizer := mocks.NewMockCategorizerI(ctrl)
+	handlerService := NewMessageHandlerService(
+		sender,
+		storage,
+		freshRates(ctrl),
+		categoryService,
+		stateService,
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		categorizer,
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		nil,
+	)
+
+	err := handlerService.HandleMsg(&model.Message{
+		Text:   "/add other 3000 01-01-2000 new #Winter boots #kids",
+		UserID: 123,
+	}, context.TODO())
+
+	assert.NoError(t, err)
+}
+
+func Test_OnFind_shouldShowMatchesWithTotal(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	sender := mocks.NewMockMessageSender(ctrl)
+	sender.EXPECT().SendMessage("2. 02-01-2000 other - ₽200.00 (taxi #trip)\n1. 01-01-2000 other - ₽100.00 (#trip)\ntotal: ₽300.00 in 2 spendings\n", int64(123))
+	spendingService := mocks.NewMockSpendingServiceI(ctrl)
+	spendingService.EXPECT().Find(gomock.Any(), int64(123), "#trip", gomock.Any(), gomock.Any()).Return([]model.Spending{
+		{Id: 2, Value: decimal.NewFromInt(200), CategoryId: 1, Date: time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC), Note: "taxi", Tags: []string{"trip"}},
+		{Id: 1, Value: decimal.NewFromInt(100), CategoryId: 1, Date: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), Tags: []string{"trip"}},
+	}, "rub", nil)
+	categoryService := mocks.NewMockCategoryService(ctrl)
+	categoryService.EXPECT().Find("1").Return(model.Category{Id: 1, Name: "other"}, true).Times(2)
+	handlerService := NewMessageHandlerService(
+		sender,
+		spendingService,
+		mocks.NewMockCurrencyService(ctrl),
+		categoryService,
+		mocks.NewMockStateService(ctrl),
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		nil,
+	)
+
+	err := handlerService.HandleMsg(&model.Message{
+		Text:   "/find #trip y",
+		UserID: 123,
+	}, context.TODO())
+
+	assert.NoError(t, err)
+}
+
+func Test_OnReport_shouldUseCustomRange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+	sender := mocks.NewMockMessageSender(ctrl)
+	sender.EXPECT().SendMessage("from: 01-03-2026, to: 31-03-2026\n"+
+		"expenses:\nfood - ₽5.00\n"+
+		"expenses total: ₽5.00\nincome total: ₽0.00\nnet: -₽5.00\n", int64(123))
+	spendingService := mocks.NewMockSpendingServiceI(ctrl)
+	spendingService.EXPECT().GetStatsBy(gomock.Any(), int64(123), start, end).
+		Return(map[string]decimal.Decimal{"food": decimal.NewFromInt(5)}, "rub", nil)
+	incomeService := mocks.NewMockIncomeServiceI(ctrl)
+	incomeService.EXPECT().GetStatsBy(gomock.Any(), int64(123), start, end).Return(map[string]decimal.Decimal{}, "rub", nil)
+	handlerService := NewMessageHandlerService(
+		sender,
+		spendingService,
+		mocks.NewMockCurrencyService(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockStateService(ctrl),
+		incomeService,
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		nil,
+	)
+
+	err := handlerService.HandleMsg(&model.Message{
+		Text:   "/report 01-03-2026 31-03-2026",
+		UserID: 123,
+	}, context.TODO())
+
+	assert.NoError(t, err)
+}
+
+func Test_OnReport_shouldFormatForLanguageOfTelegram(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+	sender := mocks.NewMockMessageSender(ctrl)
+	sender.EXPECT().SendMessage("с 01.03.2026 по 31.03.2026\n"+
+		"расходы:\nfood - 1 234,50 ₽\n"+
+		"доходы:\nsalary - 50 000,00 ₽\n"+
+		"всего расходов: 1 234,50 ₽\nвсего доходов: 50 000,00 ₽\nитог: 48 765,50 ₽\n", int64(123))
+	spendingService := mocks.NewMockSpendingServiceI(ctrl)
+	spendingService.EXPECT().GetStatsBy(gomock.Any(), int64(123), start, end).
+		Return(map[string]decimal.Decimal{"food": decimal.RequireFromString("1234.5")}, "rub", nil)
+	incomeService := mocks.NewMockIncomeServiceI(ctrl)
+	incomeService.EXPECT().GetStatsBy(gomock.Any(), int64(123), start, end).
+		Return(map[string]decimal.Decimal{"salary": decimal.NewFromInt(50000)}, "rub", nil)
+	languages := mocks.NewMockLanguageServiceI(ctrl)
+	languages.EXPECT().ResolveLanguage(int64(123), "ru").Return("ru", nil)
+	handlerService := NewMessageHandlerService(
+		sender,
+		spendingService,
+		mocks.NewMockCurrencyService(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockStateService(ctrl),
+		incomeService,
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		languages,
+		nil,
+		nil,
+	)
+
+	err := handlerService.HandleMsg(&model.Message{
+		Text:         "/report 01-03-2026 31-03-2026",
+		UserID:       123,
+		LanguageCode: "ru-RU",
+	}, context.TODO())
+
+	assert.NoError(t, err)
+}
+
+func Test_OnLang_shouldFixLanguageOrFollowTelegram(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	sender := mocks.NewMockMessageSender(ctrl)
+	languages := mocks.NewMockLanguageServiceI(ctrl)
+	languages.EXPECT().ResolveLanguage(int64(123), "en").Return("en", nil).Times(3)
+	gomock.InOrder(
+		languages.EXPECT().SetLanguage(int64(123), "ru", true).Return(nil),
+		sender.EXPECT().SendMessage("язык: Русский", int64(123)),
+		languages.EXPECT().SetLanguage(int64(123), "en", false).Return(nil),
+		sender.EXPECT().SendMessage("language: English", int64(123)),
+		sender.EXPECT().SendMessage("unknown language, use en, ru or auto", int64(123)),
+	)
+	handlerService := NewMessageHandlerService(
+		sender,
+		mocks.NewMockSpendingServiceI(ctrl),
+		mocks.NewMockCurrencyService(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockStateService(ctrl),
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		languages,
+		nil,
+		nil,
+	)
+
+	for _, text := range []string{"/lang ru", "/lang auto", "/lang de"} {
+		err := handlerService.HandleMsg(&model.Message{Text: text, UserID: 123, LanguageCode: "en-US"}, context.TODO())
+		assert.NoError(t, err)
+	}
+}
+
+func Test_OnReport_shouldCompareWithPreviousPeriod(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	start, end := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+	prevStart, prevEnd := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 2, 28, 0, 0, 0, 0, time.UTC)
+	sender := mocks.NewMockMessageSender(ctrl)
+	sender.EXPECT().SendMessage("01-03-2026 - 31-03-2026 vs 01-02-2026 - 28-02-2026\n"+
+		"food: ₽300.00 (was ₽200.00, +100, +50%)\n"+
+		"fun: ₽100.00 (was ₽0.00, +100, new)\n"+
+		"taxi: ₽0.00 (was ₽50.00, -50, -100%)\n"+
+		"total: ₽400.00 (was ₽250.00, +150, +60%)\n", int64(123))
+	spendingService := mocks.NewMockSpendingServiceI(ctrl)
+	spendingService.EXPECT().GetStatsBy(gomock.Any(), int64(123), start, end).
+		Return(map[string]decimal.Decimal{"food": decimal.NewFromInt(300), "fun": decimal.NewFromInt(100)}, "rub", nil)
+	spendingService.EXPECT().GetStatsBy(gomock.Any(), int64(123), prevStart, prevEnd).
+		Return(map[string]decimal.Decimal{"food": decimal.NewFromInt(200), "taxi": decimal.NewFromInt(50)}, "rub", nil)
+	handlerService := NewMessageHandlerService(
+		sender,
+		spendingService,
+		mocks.NewMockCurrencyService(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockStateService(ctrl),
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		nil,
+	)
+
+	err := handlerService.HandleMsg(&model.Message{
+		Text:   "/report 03-2026 compare",
+		UserID: 123,
+	}, context.TODO())
+
+	assert.NoError(t, err)
+}
+
+func Test_OnReport_shouldShowBreakdownByWeek(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	start, end := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC)
+	sender := mocks.NewMockMessageSender(ctrl)
+	sender.EXPECT().SendMessage("from: 01-03-2026, to: 14-03-2026 by week\n"+
+		"23-02-2026: ₽10.00\n  food - ₽10.00\n"+
+		"02-03-2026: ₽25.00\n  food - ₽5.00\n  taxi - ₽20.00\n"+
+		"expenses total: ₽35.00\n", int64(123))
+	spendingService := mocks.NewMockSpendingServiceI(ctrl)
+	spendingService.EXPECT().GetBreakdown(gomock.Any(), int64(123), start, end, model.WeekReport).Return([]model.ReportBucket{
+		{Start: time.Date(2026, 2, 23, 0, 0, 0, 0, time.UTC), Data: map[string]decimal.Decimal{"food": decimal.NewFromInt(10)}},
+		{Start: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), Data: map[string]decimal.Decimal{"food": decimal.NewFromInt(5), "taxi": decimal.NewFromInt(20)}},
+	}, "rub", nil)
+	handlerService := NewMessageHandlerService(
+		sender,
+		spendingService,
+		mocks.NewMockCurrencyService(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockStateService(ctrl),
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		nil,
+	)
+
+	err := handlerService.HandleMsg(&model.Message{
+		Text:   "/report 01-03-2026 14-03-2026 by week",
+		UserID: 123,
+	}, context.TODO())
+
+	assert.NoError(t, err)
+}
+
+func Test_OnReport_shouldShowTop(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	start, end := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)
+	sender := mocks.NewMockMessageSender(ctrl)
+	sender.EXPECT().SendMessage("top 2 from: 01-01-2025, to: 31-12-2025\n"+
+		"1. 05-06-2025 travel - ₽50,000.00 (flights)\n"+
+		"2. 01-09-2025 kids - ₽20,000.00\n", int64(123))
+	spendingService := mocks.NewMockSpendingServiceI(ctrl)
+	spendingService.EXPECT().GetTop(gomock.Any(), int64(123), start, end, 2).Return([]model.ReportEntry{
+		{Date: time.Date(2025, 6, 5, 0, 0, 0, 0, time.UTC), Category: "travel", Value: decimal.NewFromInt(50000), Note: "flights"},
+		{Date: time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC), Category: "kids", Value: decimal.NewFromInt(20000)},
+	}, "rub", nil)
+	handlerService := NewMessageHandlerService(
+		sender,
+		spendingService,
+		mocks.NewMockCurrencyService(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockStateService(ctrl),
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		nil,
+	)
+
+	err := handlerService.HandleMsg(&model.Message{
+		Text:   "/report 2025 top 2",
+		UserID: 123,
+	}, context.TODO())
+
+	assert.NoError(t, err)
+}
+
+func Test_OnReport_shouldSendChartsBeforeReport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	start, end := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+	sender := mocks.NewMockMessageSender(ctrl)
+	gomock.InOrder(
+		sender.EXPECT().SendPhoto(gomock.Any(), "expenses 01-03-2026 - 31-03-2026\n🟥 food - 75%\n🟧 taxi - 25%\n", int64(123)),
+		sender.EXPECT().SendPhoto(gomock.Any(), "expenses by day 01-03-2026 - 31-03-2026", int64(123)),
+		sender.EXPECT().SendPhoto(gomock.Any(), "spent vs budget ₽1,000.00", int64(123)),
+		sender.EXPECT().SendMessage("from: 01-03-2026, to: 31-03-2026\n"+
+			"expenses:\nfood - ₽30.00\ntaxi - ₽10.00\n"+
+			"expenses total: ₽40.00\nincome total: ₽0.00\nnet: -₽40.00\n", int64(123)),
+	)
+	spendingService := mocks.NewMockSpendingServiceI(ctrl)
+	spendingService.EXPECT().GetBreakdown(gomock.Any(), int64(123), start, end, model.DayReport).Return([]model.ReportBucket{
+		{Start: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), Data: map[string]decimal.Decimal{"food": decimal.NewFromInt(30)}},
+		{Start: time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC), Data: map[string]decimal.Decimal{"taxi": decimal.NewFromInt(10)}},
+	}, "rub", nil)
+	spendingService.EXPECT().GetStatsBy(gomock.Any(), int64(123), start, end).
+		Return(map[string]decimal.Decimal{"food": decimal.NewFromInt(30), "taxi": decimal.NewFromInt(10)}, "rub", nil)
+	incomeService := mocks.NewMockIncomeServiceI(ctrl)
+	incomeService.EXPECT().GetStatsBy(gomock.Any(), int64(123), start, end).Return(map[string]decimal.Decimal{}, "rub", nil)
+	stateService := mocks.NewMockStateService(ctrl)
+	stateService.EXPECT().GetState(int64(123)).Return(model.State{BudgetValue: decimal.NewFromInt(1000)}, nil)
+	currencyService := mocks.NewMockCurrencyService(ctrl)
+	currencyService.EXPECT().GetAll().Return([]model.Currency{{Code: "rub", Ratio: decimal.NewFromInt(1)}})
+	handlerService := NewMessageHandlerService(
+		sender,
+		spendingService,
+		currencyService,
+		mocks.NewMockCategoryService(ctrl),
+		stateService,
+		incomeService,
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		nil,
+	)
+
+	err := handlerService.HandleMsg(&model.Message{
+		Text:   "/report 03-2026 chart",
+		UserID: 123,
+	}, context.TODO())
+
+	assert.NoError(t, err)
+}
+
+func Test_OnAsyncReport_shouldShowSumsInCurrencyOfReport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	sent := make(chan struct{})
+	sender := mocks.NewMockMessageSender(ctrl)
+	sender.EXPECT().SendMessage("from: 01-03-2026, to: 31-03-2026\n"+
+		"expenses:\nfood - $30.00\n"+
+		"expenses total: $30.00\nincome total: $0.00\nnet: -$30.00\n", int64(123)).
+		Do(func(string, int64) { close(sent) })
+	resultCh := make(chan *model.Report)
+	NewMessageHandlerService(
+		sender,
+		mocks.NewMockSpendingServiceI(ctrl),
+		mocks.NewMockCurrencyService(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockStateService(ctrl),
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		resultCh,
+	)
+
+	// сервис отчётов посчитал суммы в долларах пользователя
+	_, err := (&server{resultCh: resultCh}).Send(context.TODO(), &api.ReportResult{
+		UserId: 123, Start: "01-03-2026", End: "31-03-2026", Data: map[string]float64{"food": 30}, CurrencyCode: "usd",
+	})
+	require.NoError(t, err)
+
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Fatal("report is not sent")
+	}
+}
+
+func Test_OnAsyncReport_shouldReplyInTraceOfRequest(t *testing.T) {
+	tracer := mocktracer.New()
+	opentracing.SetGlobalTracer(tracer)
+	defer opentracing.SetGlobalTracer(opentracing.NoopTracer{})
+	ctrl := gomock.NewController(t)
+
+	sent := make(chan struct{})
+	sender := mocks.NewMockMessageSender(ctrl)
+	sender.EXPECT().SendMessage(gomock.Any(), int64(123)).Do(func(string, int64) { close(sent) })
+	resultCh := make(chan *model.Report)
+	NewMessageHandlerService(
+		sender,
+		mocks.NewMockSpendingServiceI(ctrl),
+		mocks.NewMockCurrencyService(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockStateService(ctrl),
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		resultCh,
+	)
+
+	// сервис отчётов передал трейс команды /report в метаданных
+	request, ctx := opentracing.StartSpanFromContext(context.Background(), "msg_handler: handle cmd `/report`")
+	request.Finish()
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.New(observability.Inject(ctx)))
+	_, err := (&server{resultCh: resultCh}).Send(ctx, &api.ReportResult{
+		UserId: 123, Start: "01-03-2026", End: "31-03-2026", Data: map[string]float64{"food": 30}, CurrencyCode: "usd", RequestId: "42",
+	})
+	require.NoError(t, err)
+
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Fatal("report is not sent")
+	}
+	assert.Eventually(t, func() bool {
+		for _, span := range tracer.FinishedSpans() {
+			if span.OperationName == "msg_handler: send report result" {
+				return span.SpanContext.TraceID == request.(*mocktracer.MockSpan).SpanContext.TraceID
+			}
+		}
+		return false
+	}, time.Second, 10*time.Millisecond)
+}
+
+func Test_OnAsyncReport_shouldReportFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	sent := make(chan struct{})
+	sender := mocks.NewMockMessageSender(ctrl)
+	sender.EXPECT().SendMessage("failed to build the report, try again later", int64(123)).Do(func(string, int64) { close(sent) })
+	resultCh := make(chan *model.Report)
+	NewMessageHandlerService(
+		sender,
+		mocks.NewMockSpendingServiceI(ctrl),
+		mocks.NewMockCurrencyService(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockStateService(ctrl),
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		resultCh,
+	)
+
+	// сервис отчётов отклонил запрос, дат периода в ответе нет
+	_, err := (&server{resultCh: resultCh}).Send(context.TODO(), &api.ReportResult{
+		UserId: 123, RequestId: "42", Error: "unknown report mode",
+	})
+	require.NoError(t, err)
+
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Fatal("failure is not sent")
+	}
+}
+
+func Test_bucketItems_shouldMatchDatesFromDB(t *testing.T) {
+	// lib/pq разбирает date в зону без имени, а период отчёта - в utc
+	db := time.FixedZone("", 0)
+	r := &model.Report{
+		Start: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC),
+		Buckets: []model.ReportBucket{
+			{Start: time.Date(2026, 3, 2, 0, 0, 0, 0, db), Data: map[string]decimal.Decimal{"food": decimal.NewFromInt(30), "taxi": decimal.NewFromInt(10)}},
+		},
+	}
+
+	items, daily := bucketItems(r)
+
+	assert.True(t, daily)
+	assert.Equal(t, []float64{0, 40, 0}, []float64{items[0].Value, items[1].Value, items[2].Value})
+}
+
+func Test_parseReportArgs(t *testing.T) {
+	tests := []struct {
+		text   string
+		period string
+		mode   model.ReportMode
+		top    int
+		err    bool
+	}{
+		{text: "m", period: "m", mode: model.CategoryReport},
+		{text: "this month by day", period: "this month", mode: model.DayReport},
+		{text: "y by category", period: "y", mode: model.CategoryReport},
+		{text: "last month compare", period: "last month", mode: model.CompareReport},
+		{text: "q1 top", period: "q1", mode: model.TopReport, top: defaultTopCount},
+		{text: "q1 2025 top 3", period: "q1 2025", mode: model.TopReport, top: 3},
+		{text: "m by year", err: true},
+		{text: "m top x", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			period, mode, top, err := parseReportArgs(tt.text)
+			assert.Equal(t, tt.err, err != nil)
+			if tt.err {
+				return
+			}
+			assert.Equal(t, tt.period, period)
+			assert.Equal(t, tt.mode, mode)
+			assert.Equal(t, tt.top, top)
+		})
+	}
+}
+
+func Test_OnExport_shouldSendFile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	start, end := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+	sender := mocks.NewMockMessageSender(ctrl)
+	sender.EXPECT().SendDocument([]byte("date,category,amount rub,amount usd,note\n2026-03-02,food,100,1.25,lunch\n"),
+		"spendings_2026-03-01_2026-03-31.csv", int64(123))
+	sender.EXPECT().SendMessage("exported spendings: 1", int64(123))
+	spendingService := mocks.NewMockSpendingServiceI(ctrl)
+	spendingService.EXPECT().GetEntries(gomock.Any(), int64(123), start, end, maxSyncExportRows+1).Return([]model.ReportEntry{
+		{Date: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), Category: "food", Value: decimal.NewFromInt(100), Note: "lunch"},
+	}, model.Currency{Code: "usd", Ratio: decimal.RequireFromString("0.0125")}, nil)
+	handlerService := NewMessageHandlerService(
+		sender,
+		spendingService,
+		mocks.NewMockCurrencyService(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockStateService(ctrl),
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		nil,
+	)
+
+	err := handlerService.HandleMsg(&model.Message{
+		Text:   "/export 03-2026 csv",
+		UserID: 123,
+	}, context.TODO())
+
+	assert.NoError(t, err)
+}
+
+func Test_parseExportArgs(t *testing.T) {
+	tests := []struct {
+		text   string
+		period string
+		format export.Format
+	}{
+		{text: "", period: "m", format: export.CSV},
+		{text: "xlsx", period: "m", format: export.XLSX},
+		{text: "last month", period: "last month", format: export.CSV},
+		{text: "01-03-2026 31-03-2026 xlsx", period: "01-03-2026 31-03-2026", format: export.XLSX},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			period, format, err := parseExportArgs(tt.text)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.period, period)
+			assert.Equal(t, tt.format, format)
+		})
+	}
+}
+
+func Test_OnDocument_shouldShowImportPreview(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	sender := mocks.NewMockMessageSender(ctrl)
+	sender.EXPECT().SendMessage("spendings to import: 1, duplicates skipped: 2\n"+
+		"01-03-2026 food - ₽350.00 (PYATEROCHKA)\n"+
+		"no category for 1 spending, it will be skipped, add rules with /rule add [category] substring [merchant] and send the file again:\n"+
+		"02-03-2026 UNKNOWN - ₽99.00, maybe fun\n"+
+		"/import confirm - save, /import confirm all - save with the guessed categories too, /import cancel - discard", int64(123))
+	importService := mocks.NewMockImportServiceI(ctrl)
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	food := model.NewSpending(123, decimal.NewFromInt(350), 2, day)
+	food.CurrencyCode, food.Note = "rub", "PYATEROCHKA"
+	unknown := model.NewSpending(123, decimal.NewFromInt(99), 0, day.AddDate(0, 0, 1))
+	unknown.CurrencyCode, unknown.Note = "rub", "UNKNOWN"
+	importService.EXPECT().Preview(gomock.Any(), int64(123), "bank.csv", []byte("data")).Return(model.ImportPreview{
+		Items:         []model.ImportItem{{Spending: food, CategoryName: "food"}},
+		Duplicates:    2,
+		Uncategorized: []model.ImportItem{{Spending: unknown, CategoryName: "fun"}},
+	}, nil)
+	handlerService := NewMessageHandlerService(
+		sender,
+		mocks.NewMockSpendingServiceI(ctrl),
+		mocks.NewMockCurrencyService(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockStateService(ctrl),
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		importService,
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		nil,
+	)
+
+	err := handlerService.HandleMsg(&model.Message{
+		UserID:       123,
+		Document:     []byte("data"),
+		DocumentName: "bank.csv",
+	}, context.TODO())
+
+	assert.NoError(t, err)
+}
+
+func Test_OnImportConfirmAll_shouldSaveGuessedCategories(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	sender := mocks.NewMockMessageSender(ctrl)
+	sender.EXPECT().SendMessage("imported spendings: 3, current balance: 650", int64(123))
+	importService := mocks.NewMockImportServiceI(ctrl)
+	importService.EXPECT().Confirm(gomock.Any(), int64(123), true).Return(3, decimal.NewFromInt(650), nil)
+	handlerService := NewMessageHandlerService(
+		sender,
+		mocks.NewMockSpendingServiceI(ctrl),
+		mocks.NewMockCurrencyService(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockStateService(ctrl),
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		importService,
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		nil,
+	)
+
+	err := handlerService.HandleMsg(&model.Message{
+		Text:   "/import confirm all",
+		UserID: 123,
+	}, context.TODO())
+
+	assert.NoError(t, err)
+}
+
+func Test_OnAdd_shouldAskToConfirmUncertainGuess(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	sender := mocks.NewMockMessageSender(ctrl)
+	gomock.InOrder(
+		sender.EXPECT().SendKeyboard("is it food? /yes - save, or send the category", model.Keyboard{
+			{{Text: "yes, food", Data: "/yes"}},
+			{{Text: "taxi", Data: "1"}, {Text: "food", Data: "2"}},
+		}, int64(123)),
+		sender.EXPECT().SendMessage("added, current balance: 100", int64(123)),
+	)
+	categoryService := mocks.NewMockCategoryService(ctrl)
+	categoryService.EXPECT().Find("Пятёрочка").Return(model.Category{}, false)
+	categoryService.EXPECT().GetAll().Return([]model.Category{{Id: 1, Name: "taxi"}, {Id: 2, Name: "food"}})
+	categorizer := mocks.NewMockCategorizerI(ctrl)
+	categorizer.EXPECT().Guess(gomock.Any(), int64(123), "Пятёрочка").
+		Return(model.CategoryGuess{CategoryId: 2, CategoryName: "food", Confidence: 0.6, Source: model.HistoryGuess}, true, nil)
+	stateService := mocks.NewMockStateService(ctrl)
+	stateService.EXPECT().GetCategoryBudget(int64(123), 2).Return(model.CategoryBudget{}, false, nil)
+	spendingService := mocks.NewMockSpendingServiceI(ctrl)
+	spendingService.EXPECT().Save(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, spending model.Spending) (decimal.Decimal, *model.Currency, error) {
+			assert.Equal(t, 2, spending.CategoryId)
+			assert.True(t, spending.Value.Equal(decimal.NewFromInt(500)))
+			return decimal.NewFromInt(100), nil, nil
+		})
+	handlerService := NewMessageHandlerService(
+		sender,
+		spendingService,
+		freshRates(ctrl),
+		categoryService,
+		stateService,
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		categorizer,
+		newFakeDialogs(),
+		anyLanguage(ctrl),
+		nil,
+		nil,
+	)
+
+	err := handlerService.HandleMsg(&model.Message{Text: "/add 500 Пятёрочка", UserID: 123}, context.TODO())
+	assert.NoError(t, err)
+	err = handlerService.HandleMsg(&model.Message{Text: "/yes", UserID: 123}, context.TODO())
+	assert.NoError(t, err)
+}
+
+func Test_OnReceiptPhoto_shouldAskCategoryAndSave(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	photo, err := os.ReadFile("testdata/receipt.png")
+	require.NoError(t, err)
+
+	sender := mocks.NewMockMessageSender(ctrl)
+	gomock.InOrder(
+		sender.EXPECT().SendKeyboard("receipt of 01.03.2026 12:30 for ₽350.00, which category is it? /receipt [category]", model.Keyboard{
+			{{Text: "taxi", Data: "1"}, {Text: "food", Data: "0"}},
+		}, int64(123)),
+		sender.EXPECT().SendMessage("added, current balance: 650", int64(123)),
+	)
+	categoryService := mocks.NewMockCategoryService(ctrl)
+	categoryService.EXPECT().GetAll().Return([]model.Category{{Id: 1, Name: "taxi"}, {Id: 0, Name: "food"}})
+	// у food id 0, он не должен считаться невыбранной категорией
+	categoryService.EXPECT().Find("food").Return(model.Category{Id: 0, Name: "food"}, true)
+	stateService := mocks.NewMockStateService(ctrl)
+	stateService.EXPECT().GetCategoryBudget(int64(123), 0).Return(model.CategoryBudget{}, false, nil)
+	spendingService := mocks.NewMockSpendingServiceI(ctrl)
+	spendingService.EXPECT().Save(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, spending model.Spending) (decimal.Decimal, *model.Currency, error) {
+			assert.Equal(t, 0, spending.CategoryId)
+			assert.Equal(t, "rub", spending.CurrencyCode)
+			assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), spending.Date)
+			assert.True(t, spending.Value.Equal(decimal.NewFromInt(350)))
+			return decimal.NewFromInt(650), nil, nil
+		})
+	handlerService := NewMessageHandlerService(
+		sender,
+		spendingService,
+		freshRates(ctrl),
+		categoryService,
+		stateService,
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		newFakeDialogs(),
+		anyLanguage(ctrl),
+		nil,
+		nil,
+	)
+
+	err = handlerService.HandleMsg(&model.Message{Photo: photo, UserID: 123}, context.TODO())
+	assert.NoError(t, err)
+	err = handlerService.HandleMsg(&model.Message{Text: "/receipt food", UserID: 123}, context.TODO())
+	assert.NoError(t, err)
+}
+
+func Test_OnPhoto_shouldReplyIfNoReceipt(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 100, 100))))
+
+	sender := mocks.NewMockMessageSender(ctrl)
+	sender.EXPECT().SendMessage(errReceiptNotFound.Error(), int64(123))
+	handlerService := NewMessageHandlerService(
+		sender,
+		mocks.NewMockSpendingServiceI(ctrl),
+		mocks.NewMockCurrencyService(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockStateService(ctrl),
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		nil,
+	)
+
+	err := handlerService.HandleMsg(&model.Message{Photo: buf.Bytes(), UserID: 123}, context.TODO())
+	assert.NoError(t, err)
+}
+
+func Test_OnDelete_shouldDeleteOnlyAfterConfirmButton(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	sender := mocks.NewMockMessageSender(ctrl)
+	gomock.InOrder(
+		sender.EXPECT().SendKeyboard("delete spending 7?\nsend /confirm /delete 7 to do it", model.Keyboard{
+			{{Text: "yes", Data: "/confirm /delete 7"}, {Text: "no", Data: "/no"}},
+		}, int64(123)),
+		sender.EXPECT().SendMessage("deleted, current balance: 900", int64(123)),
+	)
+	spendingService := mocks.NewMockSpendingServiceI(ctrl)
+	spendingService.EXPECT().Delete(gomock.Any(), int64(123), int64(7)).Return(decimal.NewFromInt(900), nil)
+	handlerService := NewMessageHandlerService(
+		sender,
+		spendingService,
+		mocks.NewMockCurrencyService(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockStateService(ctrl),
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		nil,
+	)
+
+	err := handlerService.HandleMsg(&model.Message{Text: "/delete 7", UserID: 123}, context.TODO())
+	assert.NoError(t, err)
+	// нажатая кнопка приходит как сообщение с её данными
+	err = handlerService.HandleMsg(&model.Message{Text: "/confirm /delete 7", UserID: 123}, context.TODO())
+	assert.NoError(t, err)
+}
+
+func Test_OnAdd_shouldAskArgumentsStepByStep(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	now := time.Now()
+	yesterday := time.Date(now.Year(), now.Month(), now.Day()-1, 0, 0, 0, 0, time.UTC)
+
+	sender := mocks.NewMockMessageSender(ctrl)
+	gomock.InOrder(
+		sender.EXPECT().SendMessage("how much? e.g. 350 or 20$, /cancel to stop", int64(123)),
+		sender.EXPECT().SendKeyboard("which category is it?", model.Keyboard{
+			{{Text: "taxi", Data: "1"}, {Text: "food", Data: "0"}},
+		}, int64(123)),
+		sender.EXPECT().SendKeyboard("which date? e.g. 12.03, today if not set", dateKeyboard(i18n.English), int64(123)),
+		sender.EXPECT().SendMessage("added $3.50 (₽280.00), current balance: 650", int64(123)),
+	)
+	currencyService := freshRates(ctrl)
+	currencyService.EXPECT().GetRate(gomock.Any(), "usd", yesterday).Return(decimal.RequireFromString("0.0125"), nil)
+	currencyService.EXPECT().GetRate(gomock.Any(), "rub", yesterday).Return(decimal.NewFromInt(1), nil)
+	categoryService := mocks.NewMockCategoryService(ctrl)
+	categoryService.EXPECT().GetAll().Return([]model.Category{{Id: 1, Name: "taxi"}, {Id: 0, Name: "food"}})
+	// кнопка категории приходит как сообщение с её id, у food он 0
+	categoryService.EXPECT().Find("0").Return(model.Category{Id: 0, Name: "food"}, true)
+	stateService := mocks.NewMockStateService(ctrl)
+	stateService.EXPECT().GetCategoryBudget(int64(123), 0).Return(model.CategoryBudget{}, false, nil)
+	spendingService := mocks.NewMockSpendingServiceI(ctrl)
+	spendingService.EXPECT().Save(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, spending model.Spending) (decimal.Decimal, *model.Currency, error) {
+			assert.Equal(t, 0, spending.CategoryId)
+			assert.Equal(t, "usd", spending.CurrencyCode)
+			assert.Equal(t, yesterday, spending.Date)
+			assert.True(t, spending.Value.Equal(decimal.RequireFromString("3.5")))
+			return decimal.NewFromInt(650), nil, nil
+		})
+	dialogs := newFakeDialogs()
+	handlerService := NewMessageHandlerService(
+		sender,
+		spendingService,
+		currencyService,
+		categoryService,
+		stateService,
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		dialogs,
+		anyLanguage(ctrl),
+		nil,
+		nil,
+	)
+
+	for _, text := range []string{"/add", "3.5$", "0", "yesterday"} {
+		err := handlerService.HandleMsg(&model.Message{Text: text, UserID: 123}, context.TODO())
+		assert.NoError(t, err)
+	}
+	assert.Empty(t, dialogs.dialogs)
+}
+
+func Test_OnCancel_shouldDropDialog(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	sender := mocks.NewMockMessageSender(ctrl)
+	gomock.InOrder(
+		sender.EXPECT().SendMessage("how much? e.g. 350 or 20$, /cancel to stop", int64(123)),
+		sender.EXPECT().SendMessage("ok, nothing changed", int64(123)),
+		sender.EXPECT().SendMessage("unknown command, see /help", int64(123)),
+	)
+	dialogs := newFakeDialogs()
+	handlerService := NewMessageHandlerService(
+		sender,
+		mocks.NewMockSpendingServiceI(ctrl),
+		mocks.NewMockCurrencyService(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockStateService(ctrl),
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		dialogs,
+		anyLanguage(ctrl),
+		nil,
+		nil,
+	)
+
+	for _, text := range []string{"/add", "/cancel", "350"} {
+		err := handlerService.HandleMsg(&model.Message{Text: text, UserID: 123}, context.TODO())
+		assert.NoError(t, err)
+	}
+}
+
+func Test_OnCurrencies_shouldOfferButtons(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	sender := mocks.NewMockMessageSender(ctrl)
+	sender.EXPECT().SendKeyboard(gomock.Any(), model.Keyboard{
+		{{Text: "rub", Data: "/currency rub"}, {Text: "usd", Data: "/currency usd"}},
+	}, int64(123))
+	currencyService := mocks.NewMockCurrencyService(ctrl)
+	currencyService.EXPECT().GetAll().Return([]model.Currency{{Code: "rub"}, {Code: "usd"}}).Times(2)
+	handlerService := NewMessageHandlerService(
+		sender,
+		mocks.NewMockSpendingServiceI(ctrl),
+		currencyService,
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockStateService(ctrl),
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		nil,
+	)
+
+	err := handlerService.HandleMsg(&model.Message{Text: "/currencies", UserID: 123}, context.TODO())
+	assert.NoError(t, err)
+}
+
+func Test_OnReportWithoutPeriod_shouldOfferPeriods(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	sender := mocks.NewMockMessageSender(ctrl)
+	sender.EXPECT().SendKeyboard("which period?", reportPeriodsKeyboard(i18n.English), int64(123))
+	handlerService := NewMessageHandlerService(
+		sender,
+		mocks.NewMockSpendingServiceI(ctrl),
+		mocks.NewMockCurrencyService(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockStateService(ctrl),
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		nil,
+	)
+
+	err := handlerService.HandleMsg(&model.Message{Text: "/report", UserID: 123}, context.TODO())
+	assert.NoError(t, err)
+}
+
+// fakeDialogs keeps dialogs in memory instead of redis.
+type fakeDialogs struct {
+	dialogs map[int64]model.Dialog
+}
+
+func newFakeDialogs() *fakeDialogs {
+	return &fakeDialogs{dialogs: make(map[int64]model.Dialog)}
+}
+
+func (f *fakeDialogs) Get(ctx context.Context, userId int64) (model.Dialog, bool, error) {
+	d, ok := f.dialogs[userId]
+	return d, ok, nil
+}
+
+func (f *fakeDialogs) Save(ctx context.Context, dialog model.Dialog) error {
+	f.dialogs[dialog.UserId] = dialog
+	return nil
+}
+
+func (f *fakeDialogs) Delete(ctx context.Context, userId int64) error {
+	delete(f.dialogs, userId)
+	return nil
+}
+
+// anyLanguage answers in english as for a user with an unknown language of telegram.
+func anyLanguage(ctrl *gomock.Controller) *mocks.MockLanguageServiceI {
+	languages := mocks.NewMockLanguageServiceI(ctrl)
+	languages.EXPECT().ResolveLanguage(gomock.Any(), gomock.Any()).Return("", nil).AnyTimes()
+	languages.EXPECT().GetLanguage(gomock.Any()).Return("", nil).AnyTimes()
+	return languages
+}
+
+// freshRates is a currency service where rub is the display currency.
+func freshRates(ctrl *gomock.Controller) *mocks.MockCurrencyService {
+	currencyService := mocks.NewMockCurrencyService(ctrl)
+	currencyService.EXPECT().GetCurrentCurrency(gomock.Any(), gomock.Any()).Return(model.Currency{Code: "rub"}, nil).AnyTimes()
+	return currencyService
+}
+
+func Test_OnConvert_shouldConvertAtStoredRates(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	sender := mocks.NewMockMessageSender(ctrl)
+	sender.EXPECT().SendMessage("$100.00 = €80.00", int64(123))
+	currencyService := mocks.NewMockCurrencyService(ctrl)
+	currencyService.EXPECT().Convert(decimal.NewFromInt(100), "usd", "eur").Return(decimal.NewFromInt(80), nil)
+	handlerService := NewMessageHandlerService(
+		sender,
+		mocks.NewMockSpendingServiceI(ctrl),
+		currencyService,
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockStateService(ctrl),
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		nil,
+	)
+
+	err := handlerService.HandleMsg(&model.Message{Text: "/convert 100 USD eur", UserID: 123}, context.TODO())
+	assert.NoError(t, err)
+}
+
+func Test_OnRates_shouldShowChangeOverPeriod(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	updatedAt := time.Date(2026, 3, 18, 9, 30, 0, 0, time.UTC)
+	today := time.Now().Truncate(24 * time.Hour)
+
+	sender := mocks.NewMockMessageSender(ctrl)
+	sender.EXPECT().SendMessage(fmt.Sprintf("rates from %v to %v:\nusd: ₽80.00 (+25%%), updated 18-03-2026 09:30\n",
+		today.AddDate(0, 0, -90).Format("02-01-2006"), today.Format("02-01-2006")), int64(123))
+	currencyService := mocks.NewMockCurrencyService(ctrl)
+	currencyService.EXPECT().GetAll().Return([]model.Currency{
+		{Code: "eur", Ratio: decimal.RequireFromString("0.01"), UpdatedAt: updatedAt},
+		{Code: "rub", Ratio: decimal.NewFromInt(1)},
+		{Code: "usd", Ratio: decimal.RequireFromString("0.0125"), UpdatedAt: updatedAt},
+	}).Times(2)
+	// 90 дней назад доллар стоил 64 рубля
+	currencyService.EXPECT().GetRate(gomock.Any(), "usd", today.AddDate(0, 0, -90)).Return(decimal.RequireFromString("0.015625"), nil)
+	currencyService.EXPECT().GetRate(gomock.Any(), "usd", today).Return(decimal.RequireFromString("0.0125"), nil)
+	handlerService := NewMessageHandlerService(
+		sender,
+		mocks.NewMockSpendingServiceI(ctrl),
+		currencyService,
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockStateService(ctrl),
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		nil,
+	)
+
+	err := handlerService.HandleMsg(&model.Message{Text: "/rates usd 90d", UserID: 123}, context.TODO())
+	assert.NoError(t, err)
+}
+
+func Test_OnRates_shouldShowChangeUntilEndOfPastPeriod(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	updatedAt := time.Date(2026, 3, 18, 9, 30, 0, 0, time.UTC)
+
+	sender := mocks.NewMockMessageSender(ctrl)
+	sender.EXPECT().SendMessage("rates from 01-01-2025 to 31-01-2025:\nusd: ₽80.00 (+10%), updated 18-03-2026 09:30\n", int64(123))
+	currencyService := mocks.NewMockCurrencyService(ctrl)
+	currencyService.EXPECT().GetAll().Return([]model.Currency{
+		{Code: "rub", Ratio: decimal.NewFromInt(1)},
+		{Code: "usd", Ratio: decimal.RequireFromString("0.0125"), UpdatedAt: updatedAt},
+	}).Times(2)
+	// за январь доллар подорожал со 100 до 110 рублей, сегодняшний курс на изменение не влияет
+	currencyService.EXPECT().GetRate(gomock.Any(), "usd", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)).Return(decimal.RequireFromString("0.01"), nil)
+	currencyService.EXPECT().GetRate(gomock.Any(), "usd", time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)).Return(decimal.NewFromInt(1).Div(decimal.NewFromInt(110)), nil)
+	handlerService := NewMessageHandlerService(
+		sender,
+		mocks.NewMockSpendingServiceI(ctrl),
+		currencyService,
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockStateService(ctrl),
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		nil,
+	)
+
+	err := handlerService.HandleMsg(&model.Message{Text: "/rates usd 01-01-2025 31-01-2025", UserID: 123}, context.TODO())
+	assert.NoError(t, err)
+}
+
+func Test_OnRatesChart_shouldSendChartOfHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	today := time.Now().Truncate(24 * time.Hour)
+
+	sender := mocks.NewMockMessageSender(ctrl)
+	gomock.InOrder(
+		sender.EXPECT().SendPhoto(gomock.Any(), fmt.Sprintf("usd rate in rub %v - %v",
+			today.AddDate(0, -1, 0).Format("02-01-2006"), today.Format("02-01-2006")), int64(123)),
+		sender.EXPECT().SendMessage(gomock.Any(), int64(123)),
+	)
+	currencyService := mocks.NewMockCurrencyService(ctrl)
+	currencyService.EXPECT().GetAll().Return([]model.Currency{{Code: "rub", Ratio: decimal.NewFromInt(1)}, {Code: "usd", Ratio: decimal.RequireFromString("0.0125")}}).Times(2)
+	currencyService.EXPECT().GetHistory(gomock.Any(), "usd", today.AddDate(0, -1, 0), today).Return([]model.CurrencyRate{
+		{Code: "usd", Date: today.AddDate(0, 0, -2), Ratio: decimal.RequireFromString("0.0124")},
+		{Code: "usd", Date: today, Ratio: decimal.RequireFromString("0.0125")},
+	}, nil)
+	currencyService.EXPECT().GetRate(gomock.Any(), "usd", today.AddDate(0, -1, 0)).Return(decimal.RequireFromString("0.0124"), nil)
+	currencyService.EXPECT().GetRate(gomock.Any(), "usd", today).Return(decimal.RequireFromString("0.0125"), nil)
+	handlerService := NewMessageHandlerService(
+		sender,
+		mocks.NewMockSpendingServiceI(ctrl),
+		currencyService,
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockStateService(ctrl),
+		mocks.NewMockIncomeServiceI(ctrl),
+		mocks.NewMockCategoryService(ctrl),
+		mocks.NewMockRecurringServiceI(ctrl),
+		mocks.NewMockImportServiceI(ctrl),
+		mocks.NewMockCategorizerI(ctrl),
+		mocks.NewMockDialogServiceI(ctrl),
+		anyLanguage(ctrl),
+		nil,
+		nil,
+	)
+
+	err := handlerService.HandleMsg(&model.Message{Text: "/rates chart usd", UserID: 123}, context.TODO())
+	assert.NoError(t, err)
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/shopspring/decimal"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/charts"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/i18n"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/time_util"
+)
+
+// период /rates по умолчанию
+const defaultRatesPeriod = "m"
+
+var ErrNoRateHistory = errors.New("no rates in the period")
+
+// handleConvert converts the sum at the stored rates: /convert 100 usd eur.
+func (s *MessageHandlerService) handleConvert(ctx context.Context, userId int64, tokens []string) (string, error) {
+	sum, err := decimal.NewFromString(strings.Replace(tokens[1], ",", ".", 1))
+	if err != nil {
+		return "", errors.New("sum must be a number")
+	}
+	from, to := strings.ToLower(tokens[2]), strings.ToLower(tokens[3])
+	converted, err := s.currencyService.Convert(sum, from, to)
+	if err != nil {
+		return "", err
+	}
+	l := i18n.FromContext(ctx)
+	return fmt.Sprintf("%v = %v", l.Money(sum, from), l.Money(converted, to)), nil
+}
+
+// ratesArgs are the arguments of /rates [chart] [currency] [period].
+type ratesArgs struct {
+	chart bool
+	code  string
+	start time.Time
+	end   time.Time
+}
+
+func (s *MessageHandlerService) parseRatesArgs(text string, now time.Time) (ratesArgs, error) {
+	args := ratesArgs{}
+	tokens := strings.Fields(strings.ToLower(text))
+	if len(tokens) > 0 && tokens[0] == "chart" {
+		args.chart, tokens = true, tokens[1:]
+	}
+	if len(tokens) > 0 && s.findCurrency(tokens[0]) {
+		args.code, tokens = tokens[0], tokens[1:]
+	}
+	period := defaultRatesPeriod
+	if len(tokens) > 0 {
+		period = strings.Join(tokens, " ")
+	}
+	var err error
+	if args.start, args.end, err = time_util.ParseRange(period, now); err != nil {
+		return ratesArgs{}, err
+	}
+	if args.chart && args.code == "" {
+		return ratesArgs{}, model.ErrWrongCurrency
+	}
+	return args, nil
+}
+
+func (s *MessageHandlerService) findCurrency(code string) bool {
+	currencies := s.currencyService.GetAll()
+	for i := 0; i < len(currencies); i++ {
+		if currencies[i].Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// handleRates shows rates to the base currency and their change over the period, /rates chart sends the chart of one currency.
+func (s *MessageHandlerService) handleRates(ctx context.Context, userId int64, tokens []string) (string, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "msg_handler: rates")
+	defer span.Finish()
+
+	args, err := s.parseRatesArgs(tokens[1], time.Now())
+	if err != nil {
+		return "", err
+	}
+	l := i18n.FromContext(ctx)
+	if args.chart {
+		if err := s.sendRateChart(ctx, userId, args); err != nil {
+			return "", err
+		}
+	}
+
+	currencies := s.currencyService.GetAll()
+	els := []string{l.T("rates from %v to %v:", l.Date(args.start), l.Date(args.end))}
+	for i := 0; i < len(currencies); i++ {
+		c := currencies[i]
+		if c.Code == model.BaseCurrency || (args.code != "" && c.Code != args.code) || c.Ratio.IsZero() {
+			continue
+		}
+		start, err := s.currencyService.GetRate(ctx, c.Code, args.start)
+		if err != nil {
+			return "", err
+		}
+		// у прошедшего периода изменение считается до его конца, а не до сегодня
+		end, err := s.currencyService.GetRate(ctx, c.Code, args.end)
+		if err != nil {
+			return "", err
+		}
+		els = append(els, l.T("%v: %v (%v), updated %v", c.Code, l.Money(basePerUnit(c.Ratio), model.BaseCurrency), formatChange(l, start, end), formatUpdatedAt(l, c.UpdatedAt)))
+	}
+	return genListMsg(els), nil
+}
+
+func (s *MessageHandlerService) sendRateChart(ctx context.Context, userId int64, args ratesArgs) error {
+	history, err := s.currencyService.GetHistory(ctx, args.code, args.start, args.end)
+	if err != nil {
+		return err
+	}
+	if len(history) == 0 {
+		return ErrNoRateHistory
+	}
+	items := make([]charts.Item, len(history))
+	for i := 0; i < len(history); i++ {
+		items[i] = charts.Item{Label: history[i].Date.Format("02.01"), Value: basePerUnit(history[i].Ratio).InexactFloat64()}
+	}
+	png, err := charts.Line(items)
+	if err != nil {
+		return err
+	}
+	l := i18n.FromContext(ctx)
+	return s.tgClient.SendPhoto(png, l.T("%v rate in %v %v - %v", args.code, model.BaseCurrency, l.Date(args.start), l.Date(args.end)), userId)
+}
+
+// basePerUnit returns the price of one unit of the currency in the base currency, ratio is units of the currency per unit of the base.
+func basePerUnit(ratio decimal.Decimal) decimal.Decimal {
+	if ratio.IsZero() {
+		return decimal.Zero
+	}
+	return decimal.NewFromInt(1).Div(ratio)
+}
+
+// formatChange returns the change of the price in the base currency from the start ratio to the end one in percents.
+func formatChange(l i18n.Lang, start, end decimal.Decimal) string {
+	if end.IsZero() || start.IsZero() {
+		return "0%"
+	}
+	change := start.Div(end).Sub(decimal.NewFromInt(1)).Mul(decimal.NewFromInt(100)).Round(2)
+	sign := ""
+	if change.IsPositive() {
+		sign = "+"
+	}
+	return sign + l.Number(change) + "%"
+}
+
+func formatUpdatedAt(l i18n.Lang, t time.Time) string {
+	if t.IsZero() {
+		return l.T("never")
+	}
+	return l.Date(t) + " " + t.Format("15:04")
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	// форматы фото, которые присылает telegram
+	_ "image/jpeg"
+	_ "image/png"
+	"strings"
+
+	"github.com/opentracing/opentracing-go"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/i18n"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/qr"
+)
+
+var (
+	errWrongPhoto      = errors.New("can't read the photo")
+	errReceiptNotFound = errors.New("no receipt qr code found on the photo, try to take it closer and without glare")
+)
+
+const receiptDtTemplate = "02.01.2006 15:04"
+
+// handleReceipt reads the QR code of the receipt on the photo and adds a spending with its sum and date.
+// The caption of the photo is used as the category, without it the category is asked.
+func (s *MessageHandlerService) handleReceipt(ctx context.Context, userId int64, photo []byte, caption string) (reply, error) {
+	receipt, err := decodeReceipt(ctx, photo)
+	if err != nil {
+		return reply{}, err
+	}
+	spending := model.NewSpending(userId, receipt.Sum, 0, receipt.Date())
+	// в чеках суммы всегда в рублях
+	spending.CurrencyCode = "rub"
+
+	l := i18n.FromContext(ctx)
+	if caption = strings.TrimSpace(caption); caption != "" {
+		if cat, ok := s.categoryService.Find(caption); ok {
+			spending.CategoryId = cat.Id
+			return s.saveSpending(ctx, spending, l.T("added"))
+		}
+	}
+	return s.askCategory(ctx, model.Dialog{UserId: userId, Spending: spending},
+		l.T("receipt of %v for %v, which category is it? /receipt [category]", receipt.Time.Format(receiptDtTemplate), l.Money(receipt.Sum, "rub")))
+}
+
+func decodeReceipt(ctx context.Context, photo []byte) (model.Receipt, error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "msg_handler: decoding receipt qr")
+	defer span.Finish()
+
+	img, _, err := image.Decode(bytes.NewReader(photo))
+	if err != nil {
+		return model.Receipt{}, errWrongPhoto
+	}
+	text, err := qr.Decode(img)
+	if err != nil {
+		return model.Receipt{}, errReceiptNotFound
+	}
+	return model.ParseReceipt(text)
+}
+
+// handleReceiptCategory saves the spending from the last receipt photo to the category.
+func (s *MessageHandlerService) handleReceiptCategory(ctx context.Context, userId int64, tokens []string) (reply, error) {
+	cat, ok := s.categoryService.Find(tokens[1])
+	if !ok {
+		return reply{}, i18n.Errorf("%w: %v", ErrUnknownCategory, tokens[1])
+	}
+	dialog, ok, err := s.dialogs.Get(ctx, userId)
+	if err != nil {
+		return reply{}, err
+	}
+	if !ok || dialog.Step() != model.StepCategory {
+		return reply{}, errors.New("no receipt waiting for the category, send a photo of the receipt first")
+	}
+	dialog.Spending.CategoryId, dialog.HasCategory, dialog.Guessed = cat.Id, true, false
+	return s.askNext(ctx, dialog)
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/i18n"
+	. "gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/logger"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/time_util"
+	"go.uber.org/zap"
+)
+
+// сколько пропущенных запусков одной записи проводится за один проход, остальные догонятся на следующих
+var maxRecurringCatchUp = 100
+
+type recurringStorageI interface {
+	Add(r model.RecurringSpending) (int64, error)
+	GetAll(userId int64) ([]model.RecurringSpending, error)
+	Delete(userId int64, id int64) error
+	GetDue(now time.Time) ([]model.RecurringSpending, error)
+}
+
+type recurringSpendingSaver interface {
+	// SaveRecurring moves the next run in the transaction of the spending, so the run can't be posted twice
+	SaveRecurring(context.Context, model.Spending, model.RecurringRun) (decimal.Decimal, error)
+}
+
+type userLanguageI interface {
+	GetLanguage(userId int64) (string, error)
+}
+
+type RecurringService struct {
+	jobMutex        sync.Once
+	storage         recurringStorageI
+	spendingService recurringSpendingSaver
+	currencyService currencyServiceI
+	languages       userLanguageI
+	notifier        MessageSender
+}
+
+func NewRecurringService(
+	storage recurringStorageI,
+	spendingService recurringSpendingSaver,
+	currencyService currencyServiceI,
+	languages userLanguageI,
+	notifier MessageSender) *RecurringService {
+	return &RecurringService{
+		storage:         storage,
+		spendingService: spendingService,
+		currencyService: currencyService,
+		languages:       languages,
+		notifier:        notifier,
+	}
+}
+
+// Add creates a recurring spending, the sum is fixed in the current currency of the user.
+func (s *RecurringService) Add(ctx context.Context, userId int64, categoryId int, value decimal.Decimal, schedule string) (model.RecurringSpending, error) {
+	cron, err := time_util.ParseCron(schedule)
+	if err != nil {
+		return model.RecurringSpending{}, err
+	}
+	next := cron.Next(time.Now())
+	if next.IsZero() {
+		return model.RecurringSpending{}, time_util.ErrWrongCron
+	}
+	cur, err := s.currencyService.GetCurrentCurrency(ctx, userId)
+	if err != nil {
+		return model.RecurringSpending{}, err
+	}
+	r := model.RecurringSpending{
+		UserId:       userId,
+		Value:        value,
+		CurrencyCode: cur.Code,
+		CategoryId:   categoryId,
+		Schedule:     schedule,
+		NextRunAt:    next,
+	}
+	if r.Id, err = s.storage.Add(r); err != nil {
+		return model.RecurringSpending{}, err
+	}
+	return r, nil
+}
+
+func (s *RecurringService) GetAll(userId int64) ([]model.RecurringSpending, error) {
+	return s.storage.GetAll(userId)
+}
+
+func (s *RecurringService) Delete(userId int64, id int64) error {
+	return s.storage.Delete(userId, id)
+}
+
+func (s *RecurringService) RunRecurringDaemon(ctx context.Context, interval time.Duration) {
+	go s.jobMutex.Do(func() {
+		// сразу после старта проводим всё, что пропустили за время простоя
+		if err := s.postDue(ctx, time.Now()); err != nil {
+			Log.Error("error on posting recurring spendings", zap.Error(err))
+		}
+		ticker := time.NewTicker(interval)
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.postDue(ctx, time.Now()); err != nil {
+					Log.Error("error on posting recurring spendings", zap.Error(err))
+				}
+			case <-ctx.Done():
+				Log.Info("cancel recurring spendings job")
+				return
+			}
+		}
+	})
+}
+
+// postDue posts every run of the recurring spendings scheduled by now.
+// Each run is saved together with the move of next_run_at, so it is posted exactly once.
+func (s *RecurringService) postDue(ctx context.Context, now time.Time) error {
+	due, err := s.storage.GetDue(now)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(due); i++ {
+		if err := s.postRuns(ctx, due[i], now); err != nil {
+			Log.Error("error on posting recurring spending", zap.Int64("id", due[i].Id), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+func (s *RecurringService) postRuns(ctx context.Context, r model.RecurringSpending, now time.Time) error {
+	cron, err := time_util.ParseCron(r.Schedule)
+	if err != nil {
+		return err
+	}
+	code, err := s.languages.GetLanguage(r.UserId)
+	if err != nil {
+		Log.Error("failed to get language", zap.Error(err))
+	}
+	l := i18n.Of(code)
+	for n := 0; n < maxRecurringCatchUp && !r.NextRunAt.After(now); n++ {
+		prev, next := r.NextRunAt, cron.Next(r.NextRunAt)
+		if next.IsZero() {
+			return fmt.Errorf("no next run for schedule %q", r.Schedule)
+		}
+		spending := model.NewSpending(r.UserId, r.Value, r.CategoryId, prev)
+		spending.CurrencyCode = r.CurrencyCode
+		balanceAfter, err := s.spendingService.SaveRecurring(ctx, spending, model.RecurringRun{Id: r.Id, Prev: prev, Next: next})
+		if errors.Is(err, model.ErrRecurringSpendingNotFound) {
+			// запись удалили или её запуск уже провёл другой экземпляр бота
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		r.NextRunAt = next
+
+		msg := l.T("recurring spending added: %v %v - %v, current balance: %v",
+			l.Date(prev), r.CategoryName, l.Money(r.Value, r.CurrencyCode), l.Number(balanceAfter))
+		if err := s.notifier.SendMessage(msg, r.UserId); err != nil {
+			Log.Error("error on sending recurring spending notification", zap.Error(err))
+		}
+	}
+	return nil
+}
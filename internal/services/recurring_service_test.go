@@ -0,0 +1,58 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	mocks "gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/mocks/services"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
+)
+
+type fakeRecurringStorage struct {
+	recurringStorageI
+	due []model.RecurringSpending
+}
+
+func (s *fakeRecurringStorage) GetDue(now time.Time) ([]model.RecurringSpending, error) {
+	return s.due, nil
+}
+
+// fakeRecurringSpendingSaver records the runs of the saved spendings
+type fakeRecurringSpendingSaver struct {
+	runs []model.RecurringRun
+}
+
+func (s *fakeRecurringSpendingSaver) SaveRecurring(ctx context.Context, spending model.Spending, run model.RecurringRun) (decimal.Decimal, error) {
+	if spending.CurrencyCode != "usd" || !spending.Date.Equal(run.Prev) {
+		return decimal.Decimal{}, model.ErrWrongCurrency
+	}
+	s.runs = append(s.runs, run)
+	return decimal.NewFromInt(500), nil
+}
+
+func Test_postDue_shouldCatchUpEveryMissedRunOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	storage := &fakeRecurringStorage{due: []model.RecurringSpending{{
+		Id: 1, UserId: 123, Value: decimal.NewFromInt(100), CurrencyCode: "usd", CategoryId: 2, CategoryName: "food",
+		Schedule: "0 9 * * *", NextRunAt: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC),
+	}}}
+	spendingService := &fakeRecurringSpendingSaver{}
+	sender := mocks.NewMockMessageSender(ctrl)
+	sender.EXPECT().SendMessage("recurring spending added: 01-03-2026 food - $100.00, current balance: 500", int64(123))
+	sender.EXPECT().SendMessage(gomock.Any(), int64(123)).Times(2)
+
+	s := NewRecurringService(storage, spendingService, nil, anyLanguage(ctrl), sender)
+	err := s.postDue(context.TODO(), time.Date(2026, 3, 3, 12, 0, 0, 0, time.UTC))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []model.RecurringRun{
+		{Id: 1, Prev: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC), Next: time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)},
+		{Id: 1, Prev: time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC), Next: time.Date(2026, 3, 3, 9, 0, 0, 0, time.UTC)},
+		{Id: 1, Prev: time.Date(2026, 3, 3, 9, 0, 0, 0, time.UTC), Next: time.Date(2026, 3, 4, 9, 0, 0, 0, time.UTC)},
+	}, spendingService.runs)
+}
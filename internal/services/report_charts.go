@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/shopspring/decimal"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/charts"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/i18n"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
+)
+
+// за более длинный период дневные графики не строим
+var maxChartDays = 366
+
+// sendReportCharts sends the category pie, spendings by day and their running total against the budget.
+func (s *MessageHandlerService) sendReportCharts(spanCtx context.Context, r *model.Report, currencyCode string) error {
+	span, _ := opentracing.StartSpanFromContext(spanCtx, "msg_handler: rendering charts")
+	defer span.Finish()
+
+	l := i18n.FromContext(spanCtx)
+	period := fmt.Sprintf("%v - %v", l.Date(r.Start), l.Date(r.End))
+	if len(r.Data) > 0 {
+		items := make([]charts.Item, 0, len(r.Data))
+		for k, v := range r.Data {
+			items = append(items, charts.Item{Label: k, Value: v.InexactFloat64()})
+		}
+		png, legend, err := charts.Pie(items)
+		if err := s.sendChart(png, l.T("expenses %v", period)+"\n"+legend, r.UserId, err); err != nil {
+			return err
+		}
+	}
+
+	items, daily := bucketItems(r)
+	png, err := charts.Bars(items)
+	if err := s.sendChart(png, l.T("expenses by %v %v", l.T(string(bucketsUnit(r))), period), r.UserId, err); err != nil {
+		return err
+	}
+	if !daily {
+		return nil
+	}
+	budget, err := s.budgetIn(r.UserId, currencyCode)
+	if err != nil {
+		return err
+	}
+	png, err = charts.CumulativeLine(items, budget.InexactFloat64())
+	return s.sendChart(png, l.T("spent vs budget %v", l.Money(budget, currencyCode)), r.UserId, err)
+}
+
+func (s *MessageHandlerService) sendChart(png []byte, caption string, userId int64, renderErr error) error {
+	if errors.Is(renderErr, charts.ErrNoData) {
+		return nil
+	}
+	if renderErr != nil {
+		return renderErr
+	}
+	return s.tgClient.SendPhoto(png, caption, userId)
+}
+
+func bucketsUnit(r *model.Report) model.ReportMode {
+	if r.Mode.IsBreakdown() {
+		return r.Mode
+	}
+	return model.DayReport
+}
+
+// bucketItems returns the total of every bucket, for daily buckets every day of the period is present.
+// Totals are keyed by the day: dates from the db and the period of the report come in different locations.
+func bucketItems(r *model.Report) ([]charts.Item, bool) {
+	totals := make(map[string]float64, len(r.Buckets))
+	for i := 0; i < len(r.Buckets); i++ {
+		total := decimal.Zero
+		for _, v := range r.Buckets[i].Data {
+			total = total.Add(v)
+		}
+		totals[r.Buckets[i].Start.Format(dtTemplate)] = total.InexactFloat64()
+	}
+	days := int(r.End.Sub(r.Start).Hours()/24) + 1
+	if bucketsUnit(r) != model.DayReport || days > maxChartDays {
+		items := make([]charts.Item, len(r.Buckets))
+		for i := 0; i < len(r.Buckets); i++ {
+			items[i] = charts.Item{Label: r.Buckets[i].Start.Format("02.01"), Value: totals[r.Buckets[i].Start.Format(dtTemplate)]}
+		}
+		return items, false
+	}
+	label := "02"
+	if days > 31 {
+		label = "02.01"
+	}
+	items := make([]charts.Item, days)
+	for i := 0; i < days; i++ {
+		day := r.Start.AddDate(0, 0, i)
+		items[i] = charts.Item{Label: day.Format(label), Value: totals[day.Format(dtTemplate)]}
+	}
+	return items, true
+}
+
+// budgetIn returns the budget of the user in the currency of the report, the base currency if it is not set.
+func (s *MessageHandlerService) budgetIn(userId int64, currencyCode string) (decimal.Decimal, error) {
+	state, err := s.stateService.GetState(userId)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	if currencyCode == "" {
+		return state.BudgetValue, nil
+	}
+	currencies := s.currencyService.GetAll()
+	for i := 0; i < len(currencies); i++ {
+		if currencies[i].Code == currencyCode {
+			return state.BudgetValue.Mul(currencies[i].Ratio), nil
+		}
+	}
+	return decimal.Decimal{}, model.ErrWrongCurrency
+}
@@ -2,13 +2,16 @@ package services
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"github.com/opentracing/opentracing-go"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/api"
 	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/config"
 	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/kafka/producer"
 	. "gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/logger"
 	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/observability"
 	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
 )
 
 type ReportProducer struct {
@@ -23,13 +26,18 @@ func NewReportProducer(ctx context.Context, config *config.Config) (*ReportProdu
 	return &ReportProducer{p}, nil
 }
 
-func (p *ReportProducer) Send(request *model.ReportRequest) error {
-	js, err := json.Marshal(request)
+// Send sends the request as api.ReportRequest, the trace of the context is continued by the report service.
+func (p *ReportProducer) Send(ctx context.Context, request *model.ReportRequest) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "report_producer: send")
+	defer span.Finish()
+	span.SetTag("request_id", request.RequestId)
+
+	data, err := proto.Marshal(api.FromReportRequest(request))
 	if err != nil {
-		Log.Error("failed to send report request")
+		Log.Error("failed to send report request", zap.Error(err))
 		return err
 	}
-	p.producer.Send(fmt.Sprint(request.UserId), string(js))
-	Log.Info("send report request", zap.Int64("userId", request.UserId))
+	p.producer.Send(fmt.Sprint(request.UserId), data, observability.Inject(ctx))
+	Log.Info("send report request", zap.Int64("userId", request.UserId), zap.String("requestId", request.RequestId))
 	return nil
 }
@@ -14,34 +14,59 @@ import (
 
 type spendingStorageI interface {
 	SaveTx(context.Context, *sqlx.Tx, model.Spending) error
-	GetStatsBy(context.Context, time.Time, time.Time) (map[string]decimal.Decimal, error)
+	GetLast(context.Context, int64, int, string) ([]model.Spending, error)
+	FindByText(context.Context, int64, string, time.Time, time.Time, string) ([]model.Spending, error)
+	FindByTag(context.Context, int64, string, time.Time, time.Time, string) ([]model.Spending, error)
+	GetTx(*sqlx.Tx, int64, int64) (model.Spending, error)
+	GetLastTx(*sqlx.Tx, int64) (model.Spending, error)
+	UpdateTx(context.Context, *sqlx.Tx, model.Spending) error
+	DeleteTx(context.Context, *sqlx.Tx, int64, int64) error
+	GetStatsBy(context.Context, int64, time.Time, time.Time, string) (map[string]decimal.Decimal, error)
+	GetBreakdown(context.Context, int64, time.Time, time.Time, string, string) ([]model.ReportBucket, error)
+	GetTop(context.Context, int64, time.Time, time.Time, int, string) ([]model.ReportEntry, error)
+	GetEntries(context.Context, int64, time.Time, time.Time, int, string) ([]model.ReportEntry, error)
 }
 
 type currencyServiceI interface {
-	GetCurrentCurrency(ctx context.Context) (model.Currency, error)
+	GetCurrentCurrency(ctx context.Context, userId int64) (model.Currency, error)
+	GetCurrency(code string) (model.Currency, error)
+	GetRate(ctx context.Context, code string, date time.Time) (decimal.Decimal, error)
+	CheckRate(ctx context.Context, userId int64, code string, date time.Time) (model.Currency, bool, error)
 }
 type stateServiceI interface {
-	DecreaseBalanceTx(tx *sqlx.Tx, v decimal.Decimal) (decimal.Decimal, error)
+	DecreaseBalanceTx(tx *sqlx.Tx, userId int64, v decimal.Decimal) (decimal.Decimal, error)
+}
+
+type categoryModelLearnerI interface {
+	LearnTx(tx *sqlx.Tx, userId int64, categoryId int, words []string) error
+}
+
+type recurringRunStorageI interface {
+	UpdateNextRunTx(tx *sqlx.Tx, id int64, prev time.Time, next time.Time) error
 }
 
 type SpendingService struct {
-	spendingStorage spendingStorageI
-	currencyService currencyServiceI
-	stateService    stateServiceI
+	spendingStorage  spendingStorageI
+	currencyService  currencyServiceI
+	stateService     stateServiceI
+	categoryModel    categoryModelLearnerI
+	recurringStorage recurringRunStorageI
 }
 
 func NewSpendingService(
 	spendingStorage spendingStorageI,
 	currencyService currencyServiceI,
-	stateServiceTx stateServiceI) *SpendingService {
-	return &SpendingService{spendingStorage, currencyService, stateServiceTx}
+	stateServiceTx stateServiceI,
+	categoryModel categoryModelLearnerI,
+	recurringStorage recurringRunStorageI) *SpendingService {
+	return &SpendingService{spendingStorage, currencyService, stateServiceTx, categoryModel, recurringStorage}
 }
 
 func (s *SpendingService) saveSpendingTxFuncs(ctx context.Context, balanceAfter *decimal.Decimal, spending model.Spending) []func(tx *sqlx.Tx) error {
-	return []func(tx *sqlx.Tx) error{
+	fs := []func(tx *sqlx.Tx) error{
 		func(tx *sqlx.Tx) error {
 			var err error
-			*balanceAfter, err = s.stateService.DecreaseBalanceTx(tx, spending.Value)
+			*balanceAfter, err = s.stateService.DecreaseBalanceTx(tx, spending.UserId, spending.Value)
 			return err
 		},
 		func(tx *sqlx.Tx) error {
@@ -49,37 +74,277 @@ func (s *SpendingService) saveSpendingTxFuncs(ctx context.Context, balanceAfter
 			return err
 		},
 	}
+	// заметка сохранённой траты пополняет историю для угадывания категории
+	if words := model.NoteWords(spending.Note); len(words) > 0 {
+		fs = append(fs, func(tx *sqlx.Tx) error {
+			return s.categoryModel.LearnTx(tx, spending.UserId, spending.CategoryId, words)
+		})
+	}
+	return fs
 }
 
-func (s *SpendingService) SaveTx(ctx context.Context, spending model.Spending) (decimal.Decimal, error) {
-	if cur, err := s.currencyService.GetCurrentCurrency(context.TODO()); err != nil {
-		return decimal.Decimal{}, err
-	} else {
-		spending.Value = spending.Value.Div(cur.Ratio)
+// Save saves the spending, decreases the balance and learns the category of its note.
+// The currency of the spending is returned if it was converted at the stale rate.
+func (s *SpendingService) Save(ctx context.Context, spending model.Spending) (decimal.Decimal, *model.Currency, error) {
+	return s.save(ctx, spending)
+}
+
+// SaveRecurring saves the spending of the run of the recurring spending and moves its next run,
+// model.ErrRecurringSpendingNotFound is returned if the run is already posted.
+func (s *SpendingService) SaveRecurring(ctx context.Context, spending model.Spending, run model.RecurringRun) (decimal.Decimal, error) {
+	balanceAfter, _, err := s.save(ctx, spending, func(tx *sqlx.Tx) error {
+		return s.recurringStorage.UpdateNextRunTx(tx, run.Id, run.Prev, run.Next)
+	})
+	return balanceAfter, err
+}
+
+func (s *SpendingService) save(ctx context.Context, spending model.Spending, extra ...func(tx *sqlx.Tx) error) (decimal.Decimal, *model.Currency, error) {
+	spending, stale, err := s.toBase(ctx, spending)
+	if err != nil {
+		return decimal.Decimal{}, nil, err
+	}
+
+	var balanceAfter decimal.Decimal
+	if err := pgdatabase.RunInTx(append(s.saveSpendingTxFuncs(ctx, &balanceAfter, spending), extra...)...); err != nil {
+		return decimal.Decimal{}, nil, err
 	}
+	return balanceAfter, stale, nil
+}
 
+// SaveBatch saves all spendings in one transaction and learns their categories, nothing is saved if any of them fails.
+func (s *SpendingService) SaveBatch(ctx context.Context, spendings []model.Spending) (decimal.Decimal, error) {
 	var balanceAfter decimal.Decimal
-	err := pgdatabase.RunInTx(s.saveSpendingTxFuncs(ctx, &balanceAfter, spending)...)
+	fs := make([]func(tx *sqlx.Tx) error, 0, len(spendings)*3)
+	for i := 0; i < len(spendings); i++ {
+		spending, _, err := s.toBase(ctx, spendings[i])
+		if err != nil {
+			return decimal.Decimal{}, err
+		}
+		fs = append(fs, s.saveSpendingTxFuncs(ctx, &balanceAfter, spending)...)
+	}
+	err := pgdatabase.RunInTx(fs...)
 	return balanceAfter, err
 }
 
-func (s *SpendingService) GetStatsBy(ctx context.Context, start, end time.Time) (map[string]decimal.Decimal, string, error) {
+// toBase keeps the entered value as the original one and converts Value to the base currency at the rate of the date of the spending,
+// the currency is returned if its rate is stale.
+func (s *SpendingService) toBase(ctx context.Context, spending model.Spending) (model.Spending, *model.Currency, error) {
+	// пустой код - текущая валюта пользователя; устаревший курс не должен молча попадать в сумму в базовой валюте, если это запрещено конфигом
+	cur, stale, err := s.currencyService.CheckRate(ctx, spending.UserId, spending.CurrencyCode, spending.Date)
+	if err != nil {
+		return model.Spending{}, nil, err
+	}
+	rate, err := s.currencyService.GetRate(ctx, cur.Code, spending.Date)
+	if err != nil {
+		return model.Spending{}, nil, err
+	}
+	if rate.IsZero() {
+		return model.Spending{}, nil, model.ErrWrongCurrency
+	}
+	spending.CurrencyCode = cur.Code
+	spending.Rate = rate
+	spending.OriginalValue = spending.Value
+	spending.Value = spending.Value.Div(rate)
+	if stale {
+		return spending, &cur, nil
+	}
+	return spending, nil, nil
+}
+
+func (s *SpendingService) GetStatsBy(ctx context.Context, userId int64, start, end time.Time) (map[string]decimal.Decimal, string, error) {
 	span, childContext := opentracing.StartSpanFromContext(ctx, "spending_service: getting report")
 	defer span.Finish()
 
-	data, err := s.spendingStorage.GetStatsBy(childContext, start, end)
+	ct, err := s.currencyService.GetCurrentCurrency(childContext, userId)
 	if err != nil {
 		ext.Error.Set(span, true)
 		return nil, "", err
 	}
-	rs := make(map[string]decimal.Decimal)
-	ct, err := s.currencyService.GetCurrentCurrency(childContext)
+	data, err := s.spendingStorage.GetStatsBy(childContext, userId, start, end, ct.Code)
 	if err != nil {
 		ext.Error.Set(span, true)
 		return nil, "", err
 	}
-	for k, v := range data {
-		rs[k] = ct.Ratio.Mul(v)
+	return data, ct.Code, nil
+}
+
+// GetBreakdown returns sums per category for every day, week or month of the period.
+func (s *SpendingService) GetBreakdown(ctx context.Context, userId int64, start, end time.Time, mode model.ReportMode) ([]model.ReportBucket, string, error) {
+	span, childContext := opentracing.StartSpanFromContext(ctx, "spending_service: getting report breakdown")
+	defer span.Finish()
+
+	if !mode.IsBreakdown() {
+		return nil, "", model.ErrWrongReportMode
+	}
+	ct, err := s.currencyService.GetCurrentCurrency(childContext, userId)
+	if err != nil {
+		ext.Error.Set(span, true)
+		return nil, "", err
+	}
+	buckets, err := s.spendingStorage.GetBreakdown(childContext, userId, start, end, string(mode), ct.Code)
+	if err != nil {
+		ext.Error.Set(span, true)
+		return nil, "", err
+	}
+	return buckets, ct.Code, nil
+}
+
+func (s *SpendingService) GetTop(ctx context.Context, userId int64, start, end time.Time, count int) ([]model.ReportEntry, string, error) {
+	span, childContext := opentracing.StartSpanFromContext(ctx, "spending_service: getting top spendings")
+	defer span.Finish()
+
+	ct, err := s.currencyService.GetCurrentCurrency(childContext, userId)
+	if err != nil {
+		ext.Error.Set(span, true)
+		return nil, "", err
+	}
+	top, err := s.spendingStorage.GetTop(childContext, userId, start, end, count, ct.Code)
+	if err != nil {
+		ext.Error.Set(span, true)
+		return nil, "", err
+	}
+	return top, ct.Code, nil
+}
+
+// GetEntries returns up to limit spendings of the period in the base currency with the rates of the current currency of the user on their dates.
+func (s *SpendingService) GetEntries(ctx context.Context, userId int64, start, end time.Time, limit int) ([]model.ReportEntry, model.Currency, error) {
+	span, childContext := opentracing.StartSpanFromContext(ctx, "spending_service: getting spendings for export")
+	defer span.Finish()
+
+	ct, err := s.currencyService.GetCurrentCurrency(childContext, userId)
+	if err != nil {
+		ext.Error.Set(span, true)
+		return nil, model.Currency{}, err
+	}
+	entries, err := s.spendingStorage.GetEntries(childContext, userId, start, end, limit, ct.Code)
+	if err != nil {
+		ext.Error.Set(span, true)
+		return nil, model.Currency{}, err
+	}
+	return entries, ct, nil
+}
+
+// GetLast returns the last count spendings of the user converted to the current currency.
+func (s *SpendingService) GetLast(ctx context.Context, userId int64, count int) ([]model.Spending, string, error) {
+	span, childContext := opentracing.StartSpanFromContext(ctx, "spending_service: getting history")
+	defer span.Finish()
+
+	ct, err := s.currencyService.GetCurrentCurrency(childContext, userId)
+	if err != nil {
+		ext.Error.Set(span, true)
+		return nil, "", err
+	}
+	spendings, err := s.spendingStorage.GetLast(childContext, userId, count, ct.Code)
+	if err != nil {
+		ext.Error.Set(span, true)
+		return nil, "", err
+	}
+	return spendings, ct.Code, nil
+}
+
+// Find returns spendings of the period matching the query, "#tag" searches by tag, anything else - by note text.
+func (s *SpendingService) Find(ctx context.Context, userId int64, query string, start, end time.Time) ([]model.Spending, string, error) {
+	span, childContext := opentracing.StartSpanFromContext(ctx, "spending_service: finding spendings")
+	defer span.Finish()
+
+	ct, err := s.currencyService.GetCurrentCurrency(childContext, userId)
+	if err != nil {
+		ext.Error.Set(span, true)
+		return nil, "", err
+	}
+	var spendings []model.Spending
+	if tag, ok := model.ParseTag(query); ok {
+		spendings, err = s.spendingStorage.FindByTag(childContext, userId, tag, start, end, ct.Code)
+	} else {
+		spendings, err = s.spendingStorage.FindByText(childContext, userId, query, start, end, ct.Code)
+	}
+	if err != nil {
+		ext.Error.Set(span, true)
+		return nil, "", err
+	}
+	return spendings, ct.Code, nil
+}
+
+// Update replaces value, category and date of the spending, the balance is corrected by the difference.
+func (s *SpendingService) Update(ctx context.Context, spending model.Spending) (decimal.Decimal, error) {
+	spending, _, err := s.toBase(ctx, spending)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+
+	var old model.Spending
+	var balanceAfter decimal.Decimal
+	err = pgdatabase.RunInTx(
+		func(tx *sqlx.Tx) error {
+			var err error
+			old, err = s.spendingStorage.GetTx(tx, spending.UserId, spending.Id)
+			return err
+		},
+		func(tx *sqlx.Tx) error {
+			return s.spendingStorage.UpdateTx(ctx, tx, spending)
+		},
+		func(tx *sqlx.Tx) error {
+			var err error
+			balanceAfter, err = s.stateService.DecreaseBalanceTx(tx, spending.UserId, spending.Value.Sub(old.Value))
+			return err
+		},
+	)
+	return balanceAfter, err
+}
+
+func (s *SpendingService) Delete(ctx context.Context, userId int64, id int64) (decimal.Decimal, error) {
+	var balanceAfter decimal.Decimal
+	err := pgdatabase.RunInTx(s.deleteSpendingTxFuncs(ctx, &balanceAfter, &model.Spending{}, func(tx *sqlx.Tx) (model.Spending, error) {
+		return s.spendingStorage.GetTx(tx, userId, id)
+	})...)
+	return balanceAfter, err
+}
+
+// Undo deletes the last added spending of the user and returns it.
+func (s *SpendingService) Undo(ctx context.Context, userId int64) (model.Spending, decimal.Decimal, error) {
+	var deleted model.Spending
+	var balanceAfter decimal.Decimal
+	err := pgdatabase.RunInTx(s.deleteSpendingTxFuncs(ctx, &balanceAfter, &deleted, func(tx *sqlx.Tx) (model.Spending, error) {
+		return s.spendingStorage.GetLastTx(tx, userId)
+	})...)
+	if err != nil {
+		return model.Spending{}, decimal.Decimal{}, err
+	}
+
+	cur, err := s.currencyService.GetCurrentCurrency(ctx, userId)
+	if err != nil {
+		return model.Spending{}, decimal.Decimal{}, err
+	}
+	if deleted.CurrencyCode == cur.Code {
+		deleted.Value = deleted.OriginalValue
+	} else {
+		rate, err := s.currencyService.GetRate(ctx, cur.Code, deleted.Date)
+		if err != nil {
+			return model.Spending{}, decimal.Decimal{}, err
+		}
+		deleted.Value = rate.Mul(deleted.Value)
+	}
+	return deleted, balanceAfter, nil
+}
+
+func (s *SpendingService) deleteSpendingTxFuncs(
+	ctx context.Context,
+	balanceAfter *decimal.Decimal,
+	deleted *model.Spending,
+	getF func(tx *sqlx.Tx) (model.Spending, error)) []func(tx *sqlx.Tx) error {
+	return []func(tx *sqlx.Tx) error{
+		func(tx *sqlx.Tx) error {
+			var err error
+			*deleted, err = getF(tx)
+			return err
+		},
+		func(tx *sqlx.Tx) error {
+			return s.spendingStorage.DeleteTx(ctx, tx, deleted.UserId, deleted.Id)
+		},
+		func(tx *sqlx.Tx) error {
+			var err error
+			*balanceAfter, err = s.stateService.DecreaseBalanceTx(tx, deleted.UserId, deleted.Value.Neg())
+			return err
+		},
 	}
-	return rs, ct.Code, nil
 }
@@ -2,6 +2,8 @@ package services
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -11,59 +13,222 @@ import (
 	"go.uber.org/zap"
 )
 
+// пока нет ни одного пользователя, проверяем истечение бюджетов раз в сутки
+var emptyStateCheckInterval = 24 * time.Hour
+
+var updateStateRetryInterval = time.Minute
+
 type stateStorage interface {
-	GetState() (model.State, error)
-	DecreaseBalanceTx(*sqlx.Tx, decimal.Decimal) (decimal.Decimal, error)
-	UpdateBalanceAndExpiresIn(time.Time) error
+	GetState(userId int64) (model.State, error)
+	DecreaseBalanceTx(*sqlx.Tx, int64, decimal.Decimal) (decimal.Decimal, error)
+	GetNearestExpiresIn() (time.Time, bool, error)
+	GetExpiredStates(now time.Time) ([]model.State, error)
+	ClosePeriod(state model.State, expiresIn time.Time, balance decimal.Decimal) error
+	UpdatePeriod(userId int64, period model.BudgetPeriod, day int, expiresIn time.Time) error
+	UpdateRollover(userId int64, rollover bool) error
+	UpdateLanguage(userId int64, lang string, fixed bool) error
+	GetClosedPeriods(userId int64, count int) ([]model.ClosedPeriod, error)
+	SetCategoryLimit(userId int64, categoryId int, v decimal.Decimal) error
+	DeleteCategoryLimit(userId int64, categoryId int) error
+	GetCategoryBudgets(userId int64) ([]model.CategoryBudget, error)
+	GetCategoryBudget(userId int64, categoryId int) (model.CategoryBudget, bool, error)
 }
+type userLanguage struct {
+	lang  string
+	fixed bool
+}
+
 type stateService struct {
 	stateStorage stateStorage
+	// будит runJob, когда пользователь меняет период и ближайшее истечение бюджета могло сдвинуться
+	reschedule chan struct{}
+	// язык нужен на каждое сообщение, в базу за ним ходим только при первом
+	languagesMutex sync.Mutex
+	languages      map[int64]userLanguage
 }
 
 func NewStateService(storage stateStorage, ctx context.Context) (*stateService, error) {
-	state, err := storage.GetState()
+	service := &stateService{stateStorage: storage, reschedule: make(chan struct{}, 1), languages: make(map[int64]userLanguage)}
+	nextTriggerTime, err := service.nextTriggerTime()
 	if err != nil {
 		return nil, err
 	}
-	service := &stateService{stateStorage: storage}
 
-	go service.runJob(ctx, time.Until(state.BudgetExpiresIn))
+	go service.runJob(ctx, nextTriggerTime)
 
 	return service, nil
 }
 
-func (s *stateService) GetBalance() (decimal.Decimal, error) {
-	state, err := s.stateStorage.GetState()
+func (s *stateService) GetState(userId int64) (model.State, error) {
+	return s.stateStorage.GetState(userId)
+}
+
+func (s *stateService) GetBalance(userId int64) (decimal.Decimal, error) {
+	state, err := s.stateStorage.GetState(userId)
 	if err != nil {
 		return decimal.Decimal{}, err
 	}
 	return state.BudgetBalance, nil
 }
 
-func (s *stateService) DecreaseBalanceTx(tx *sqlx.Tx, v decimal.Decimal) (decimal.Decimal, error) {
-	result, err := s.stateStorage.DecreaseBalanceTx(tx, v)
+func (s *stateService) DecreaseBalanceTx(tx *sqlx.Tx, userId int64, v decimal.Decimal) (decimal.Decimal, error) {
+	result, err := s.stateStorage.DecreaseBalanceTx(tx, userId, v)
 	if err != nil {
 		return decimal.Decimal{}, err
 	}
 	return result, nil
 }
 
+func (s *stateService) IncreaseBalanceTx(tx *sqlx.Tx, userId int64, v decimal.Decimal) (decimal.Decimal, error) {
+	return s.DecreaseBalanceTx(tx, userId, v.Neg())
+}
+
+// SetPeriod changes the budget period of the user, the current period now ends according to the new settings.
+func (s *stateService) SetPeriod(userId int64, period model.BudgetPeriod, day int) error {
+	if period == model.MonthPeriod && (day < 1 || day > 31) {
+		return errors.New("day must be between 1 and 31")
+	}
+	expiresIn := period.Next(time.Now(), day)
+	if err := s.stateStorage.UpdatePeriod(userId, period, day, expiresIn); err != nil {
+		return err
+	}
+	select {
+	case s.reschedule <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (s *stateService) SetRollover(userId int64, rollover bool) error {
+	return s.stateStorage.UpdateRollover(userId, rollover)
+}
+
+// ResolveLanguage returns the language of replies, the detected language of telegram replaces the stored one unless it is set with /lang.
+func (s *stateService) ResolveLanguage(userId int64, detected string) (string, error) {
+	stored, err := s.language(userId)
+	if err != nil {
+		return "", err
+	}
+	if stored.fixed || detected == "" || detected == stored.lang {
+		return stored.lang, nil
+	}
+	if err := s.SetLanguage(userId, detected, false); err != nil {
+		return "", err
+	}
+	return detected, nil
+}
+
+func (s *stateService) GetLanguage(userId int64) (string, error) {
+	stored, err := s.language(userId)
+	return stored.lang, err
+}
+
+func (s *stateService) SetLanguage(userId int64, lang string, fixed bool) error {
+	if err := s.stateStorage.UpdateLanguage(userId, lang, fixed); err != nil {
+		return err
+	}
+	s.languagesMutex.Lock()
+	defer s.languagesMutex.Unlock()
+	s.languages[userId] = userLanguage{lang: lang, fixed: fixed}
+	return nil
+}
+
+func (s *stateService) language(userId int64) (userLanguage, error) {
+	s.languagesMutex.Lock()
+	stored, ok := s.languages[userId]
+	s.languagesMutex.Unlock()
+	if ok {
+		return stored, nil
+	}
+	state, err := s.stateStorage.GetState(userId)
+	if err != nil {
+		return userLanguage{}, err
+	}
+	stored = userLanguage{lang: state.Language, fixed: state.LanguageFixed}
+	s.languagesMutex.Lock()
+	defer s.languagesMutex.Unlock()
+	s.languages[userId] = stored
+	return stored, nil
+}
+
+func (s *stateService) GetClosedPeriods(userId int64, count int) ([]model.ClosedPeriod, error) {
+	return s.stateStorage.GetClosedPeriods(userId, count)
+}
+
+// SetCategoryLimit sets the limit of the category for the budget period, zero limit removes it.
+func (s *stateService) SetCategoryLimit(userId int64, categoryId int, v decimal.Decimal) error {
+	if v.IsZero() {
+		return s.stateStorage.DeleteCategoryLimit(userId, categoryId)
+	}
+	return s.stateStorage.SetCategoryLimit(userId, categoryId, v)
+}
+
+func (s *stateService) GetCategoryBudgets(userId int64) ([]model.CategoryBudget, error) {
+	return s.stateStorage.GetCategoryBudgets(userId)
+}
+
+func (s *stateService) GetCategoryBudget(userId int64, categoryId int) (model.CategoryBudget, bool, error) {
+	return s.stateStorage.GetCategoryBudget(userId, categoryId)
+}
+
+func (s *stateService) nextTriggerTime() (time.Duration, error) {
+	expiresIn, ok, err := s.stateStorage.GetNearestExpiresIn()
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return emptyStateCheckInterval, nil
+	}
+	return time.Until(expiresIn), nil
+}
+
+// closeExpiredPeriods starts a new budget period for every user whose period expired by now.
+func (s *stateService) closeExpiredPeriods(now time.Time) error {
+	states, err := s.stateStorage.GetExpiredStates(now)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(states); i++ {
+		state := states[i]
+		expiresIn := state.BudgetPeriod.Next(state.BudgetExpiresIn, state.BudgetPeriodDay)
+		// после простоя бота пропущенные периоды схлопываются в один
+		for !expiresIn.After(now) {
+			expiresIn = state.BudgetPeriod.Next(expiresIn, state.BudgetPeriodDay)
+		}
+		balance := state.BudgetValue
+		if state.BudgetRollover {
+			balance = balance.Add(state.BudgetBalance)
+		}
+		if err := s.stateStorage.ClosePeriod(state, expiresIn, balance); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (s *stateService) runJob(ctx context.Context, nextTriggerTime time.Duration) {
 	timer := time.NewTimer(nextTriggerTime)
 
 	for {
 		select {
 		case <-timer.C:
-			nextTime := time.Now().AddDate(0, 1, 0).Truncate(24 * time.Hour)
-
-			timer = time.NewTimer(time.Until(nextTime))
-
-			if err := s.stateStorage.UpdateBalanceAndExpiresIn(nextTime); err != nil {
+			if err := s.closeExpiredPeriods(time.Now()); err != nil {
 				Log.Error("error on update state", zap.Error(err))
+				timer = time.NewTimer(updateStateRetryInterval)
+				continue
 			}
+		case <-s.reschedule:
+			timer.Stop()
 		case <-ctx.Done():
 			Log.Info("cancel update state job")
 			return
 		}
+
+		next, err := s.nextTriggerTime()
+		if err != nil {
+			Log.Error("error on getting next budget expiration", zap.Error(err))
+			next = updateStateRetryInterval
+		}
+		timer = time.NewTimer(next)
 	}
 }
@@ -0,0 +1,146 @@
+package statement
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"strings"
+)
+
+var ErrUnknownColumns = errors.New("unknown csv columns, add a mapping to import_mappings in config")
+
+// CSVMapping describes the columns of a CSV statement of a bank, columns are matched by their headers.
+type CSVMapping struct {
+	Name       string `yaml:"name"`
+	Delimiter  string `yaml:"delimiter"`
+	Date       string `yaml:"date"`
+	DateLayout string `yaml:"date_layout"`
+	// сумма со знаком, траты отрицательные
+	Amount string `yaml:"amount"`
+	// либо отдельная колонка расходов с положительными суммами
+	Expense     string `yaml:"expense"`
+	Currency    string `yaml:"currency"`
+	Description string `yaml:"description"`
+}
+
+// DefaultMappings are the mappings of common Russian banks, used if none are set in config.
+var DefaultMappings = []CSVMapping{
+	{
+		Name:        "tinkoff",
+		Delimiter:   ";",
+		Date:        "Дата операции",
+		DateLayout:  "02.01.2006",
+		Amount:      "Сумма операции",
+		Currency:    "Валюта операции",
+		Description: "Описание",
+	},
+	{
+		Name:        "alfa",
+		Delimiter:   ";",
+		Date:        "Дата операции",
+		DateLayout:  "02.01.06",
+		Expense:     "Расход",
+		Currency:    "Валюта",
+		Description: "Описание операции",
+	},
+	{
+		Name:        "sber",
+		Delimiter:   ";",
+		Date:        "Дата",
+		DateLayout:  "02.01.2006",
+		Amount:      "Сумма",
+		Description: "Описание",
+	},
+}
+
+// ParseCSV parses the statement with the first mapping whose columns are all in the header.
+func ParseCSV(data []byte, mappings []CSVMapping) ([]Transaction, error) {
+	for _, m := range mappings {
+		rows, err := readCSV(data, m.Delimiter)
+		if err != nil || len(rows) == 0 {
+			continue
+		}
+		if cols, ok := m.columns(rows[0]); ok {
+			return m.parse(rows[1:], cols)
+		}
+	}
+	return nil, ErrUnknownColumns
+}
+
+func readCSV(data []byte, delimiter string) ([][]string, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	if delimiter != "" {
+		r.Comma = []rune(delimiter)[0]
+	}
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	return r.ReadAll()
+}
+
+type csvColumns struct {
+	date, amount, expense, currency, description int
+}
+
+func (m CSVMapping) columns(header []string) (csvColumns, bool) {
+	find := func(name string) int {
+		if name == "" {
+			return -1
+		}
+		for i := 0; i < len(header); i++ {
+			if strings.EqualFold(strings.TrimSpace(header[i]), name) {
+				return i
+			}
+		}
+		return -1
+	}
+	cols := csvColumns{
+		date:        find(m.Date),
+		amount:      find(m.Amount),
+		expense:     find(m.Expense),
+		currency:    find(m.Currency),
+		description: find(m.Description),
+	}
+	ok := cols.date >= 0 && cols.description >= 0 && (cols.amount >= 0 || cols.expense >= 0) &&
+		(m.Amount == "" || cols.amount >= 0) && (m.Expense == "" || cols.expense >= 0)
+	return cols, ok
+}
+
+func (m CSVMapping) parse(rows [][]string, cols csvColumns) ([]Transaction, error) {
+	r := make([]Transaction, 0, len(rows))
+	for _, row := range rows {
+		if len(strings.Join(row, "")) == 0 {
+			continue
+		}
+		get := func(i int) string {
+			if i < 0 || i >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
+		}
+		var t Transaction
+		var err error
+		if cols.expense >= 0 {
+			if get(cols.expense) == "" {
+				continue
+			}
+			if t.Amount, err = parseAmount(get(cols.expense)); err != nil {
+				return nil, err
+			}
+		} else {
+			if t.Amount, err = parseAmount(get(cols.amount)); err != nil {
+				return nil, err
+			}
+			t.Amount = t.Amount.Neg()
+		}
+		if !t.Amount.IsPositive() {
+			continue
+		}
+		if t.Date, err = parseDate(get(cols.date), m.DateLayout); err != nil {
+			return nil, err
+		}
+		t.Currency = currencyCode(get(cols.currency))
+		t.Merchant = get(cols.description)
+		r = append(r, t)
+	}
+	return r, nil
+}
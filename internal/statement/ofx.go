@@ -0,0 +1,45 @@
+package statement
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	ofxTransactionRe = regexp.MustCompile(`(?s)<STMTTRN>(.*?)</STMTTRN>`)
+	// в OFX 1.x (SGML) у простых полей нет закрывающих тегов
+	ofxFieldRe = regexp.MustCompile(`<([A-Z0-9.]+)>([^<\r\n]*)`)
+)
+
+// ParseOFX parses the transactions of both SGML and XML OFX statements.
+func ParseOFX(data []byte) ([]Transaction, error) {
+	text := string(data)
+	currency := ""
+	if m := regexp.MustCompile(`<CURDEF>([^<\r\n]*)`).FindStringSubmatch(text); m != nil {
+		currency = currencyCode(m[1])
+	}
+	r := make([]Transaction, 0)
+	for _, block := range ofxTransactionRe.FindAllStringSubmatch(text, -1) {
+		fields := make(map[string]string)
+		for _, f := range ofxFieldRe.FindAllStringSubmatch(block[1], -1) {
+			fields[f[1]] = strings.TrimSpace(f[2])
+		}
+		amount, err := parseAmount(fields["TRNAMT"])
+		if err != nil {
+			return nil, err
+		}
+		if !amount.IsNegative() {
+			continue
+		}
+		dt, err := parseDate(fields["DTPOSTED"], "20060102")
+		if err != nil {
+			return nil, err
+		}
+		merchant := fields["NAME"]
+		if merchant == "" {
+			merchant = fields["MEMO"]
+		}
+		r = append(r, Transaction{Date: dt, Amount: amount.Neg(), Currency: currency, Merchant: merchant})
+	}
+	return r, nil
+}
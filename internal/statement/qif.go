@@ -0,0 +1,53 @@
+package statement
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+var qifDateLayouts = []string{"02.01.2006", "02.01.06", "01/02/2006", "1/2/2006", "01/02/06", "1/2/06"}
+
+// ParseQIF parses the bank and card sections of a QIF statement.
+func ParseQIF(data []byte) ([]Transaction, error) {
+	r := make([]Transaction, 0)
+	var t Transaction
+	var amount decimal.Decimal
+	var memo string
+	var err error
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" || strings.HasPrefix(line, "!") {
+			continue
+		}
+		value := strings.TrimSpace(line[1:])
+		switch line[0] {
+		case 'D':
+			// 1/2'06 - старый вариант записи года
+			if t.Date, err = parseDate(strings.Replace(value, "'", "/", 1), qifDateLayouts...); err != nil {
+				return nil, err
+			}
+		case 'T', 'U':
+			if amount, err = parseAmount(value); err != nil {
+				return nil, err
+			}
+		case 'P':
+			t.Merchant = value
+		case 'M':
+			memo = value
+		case '^':
+			if t.Merchant == "" {
+				t.Merchant = memo
+			}
+			if amount.IsNegative() && !t.Date.IsZero() {
+				t.Amount = amount.Neg()
+				r = append(r, t)
+			}
+			t, amount, memo = Transaction{}, decimal.Zero, ""
+		}
+	}
+	return r, scanner.Err()
+}
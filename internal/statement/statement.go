@@ -0,0 +1,113 @@
+// Package statement parses bank statements in CSV, OFX and QIF formats.
+package statement
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/shopspring/decimal"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/charset"
+)
+
+var (
+	ErrUnknownFormat = errors.New("unknown statement format, csv, ofx and qif are supported")
+	ErrWrongAmount   = errors.New("wrong amount in statement")
+	ErrWrongDate     = errors.New("wrong date in statement")
+)
+
+// Transaction is a spending from a statement, incomes and refunds are skipped.
+type Transaction struct {
+	Date time.Time
+	// сумма траты, всегда положительная
+	Amount decimal.Decimal
+	// код валюты в нижнем регистре, пустой - рубли
+	Currency string
+	Merchant string
+}
+
+// Parse detects the format of the statement by the file name or by the content and returns its spendings.
+func Parse(name string, data []byte, mappings []CSVMapping) ([]Transaction, error) {
+	if !utf8.Valid(data) {
+		// выгрузки российских банков часто в windows-1251
+		data = charset.DecodeWindows1251(data)
+	}
+	data = bytes.TrimPrefix(data, []byte("\uFEFF"))
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".ofx", ".qfx":
+		return ParseOFX(data)
+	case ".qif":
+		return ParseQIF(data)
+	case ".csv", ".txt":
+		return ParseCSV(data, mappings)
+	}
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.Contains(trimmed, []byte("<OFX>")):
+		return ParseOFX(data)
+	case bytes.HasPrefix(trimmed, []byte("!Type")):
+		return ParseQIF(data)
+	}
+	return nil, ErrUnknownFormat
+}
+
+// parseAmount parses amounts like -1 234,50, 1,234.50 or 1234.5.
+func parseAmount(s string) (decimal.Decimal, error) {
+	s = strings.Map(func(r rune) rune {
+		if r == ' ' || r == '\u00a0' || r == '\'' {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(s))
+	comma, dot := strings.LastIndex(s, ","), strings.LastIndex(s, ".")
+	switch {
+	case comma >= 0 && dot >= 0:
+		// разделитель, который встречается раньше, отделяет тысячи
+		if comma < dot {
+			s = strings.ReplaceAll(s, ",", "")
+		} else {
+			s = strings.ReplaceAll(s, ".", "")
+			s = strings.Replace(s, ",", ".", 1)
+		}
+	case comma >= 0 && len(s)-comma-1 <= 2:
+		s = strings.Replace(s, ",", ".", 1)
+	case comma >= 0:
+		s = strings.ReplaceAll(s, ",", "")
+	}
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return decimal.Decimal{}, ErrWrongAmount
+	}
+	return d, nil
+}
+
+func parseDate(s string, layouts ...string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range layouts {
+		if dt, err := time.Parse(layout, s); err == nil {
+			return truncateDay(dt), nil
+		}
+		// время операции после даты не нужно
+		if len(s) > len(layout) {
+			if dt, err := time.Parse(layout, s[:len(layout)]); err == nil {
+				return truncateDay(dt), nil
+			}
+		}
+	}
+	return time.Time{}, ErrWrongDate
+}
+
+func truncateDay(dt time.Time) time.Time {
+	return time.Date(dt.Year(), dt.Month(), dt.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func currencyCode(s string) string {
+	code := strings.ToLower(strings.TrimSpace(s))
+	if code == "rub" || code == "rur" || code == "руб" || code == "руб." || code == "₽" {
+		return ""
+	}
+	return code
+}
@@ -0,0 +1,104 @@
+package statement
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func day(d int) time.Time {
+	return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC)
+}
+
+// assertTransactions compares amounts by value, 1234.50 and 1234.5 are the same.
+func assertTransactions(t *testing.T, want []Transaction, got []Transaction) {
+	assert.Len(t, got, len(want))
+	for i := 0; i < len(want) && i < len(got); i++ {
+		assert.True(t, want[i].Amount.Equal(got[i].Amount), "amount of %v: %v", i, got[i].Amount)
+		want[i].Amount, got[i].Amount = decimal.Zero, decimal.Zero
+		assert.Equal(t, want[i], got[i])
+	}
+}
+
+func TestParse_TinkoffCSV(t *testing.T) {
+	data := "Дата операции;Дата платежа;Статус;Сумма операции;Валюта операции;Описание\n" +
+		"01.03.2026 12:30:00;01.03.2026;OK;-1 234,50;RUB;Пятёрочка\n" +
+		"02.03.2026 09:00:00;02.03.2026;OK;50000,00;RUB;Зарплата\n" +
+		"03.03.2026 18:10:00;03.03.2026;OK;-12,00;USD;\"Steam; games\"\n"
+
+	r, err := Parse("operations.csv", []byte(data), DefaultMappings)
+
+	assert.NoError(t, err)
+	assertTransactions(t, []Transaction{
+		{Date: day(1), Amount: decimal.RequireFromString("1234.5"), Merchant: "Пятёрочка"},
+		{Date: day(3), Amount: decimal.NewFromInt(12), Currency: "usd", Merchant: "Steam; games"},
+	}, r)
+}
+
+func TestParse_AlfaCSVInWindows1251(t *testing.T) {
+	// "Дата операции;Описание операции;Приход;Расход" в windows-1251
+	header := []byte{0xC4, 0xE0, 0xF2, 0xE0, ' ', 0xEE, 0xEF, 0xE5, 0xF0, 0xE0, 0xF6, 0xE8, 0xE8, ';',
+		0xCE, 0xEF, 0xE8, 0xF1, 0xE0, 0xED, 0xE8, 0xE5, ' ', 0xEE, 0xEF, 0xE5, 0xF0, 0xE0, 0xF6, 0xE8, 0xE8, ';',
+		0xCF, 0xF0, 0xE8, 0xF5, 0xEE, 0xE4, ';', 0xD0, 0xE0, 0xF1, 0xF5, 0xEE, 0xE4, '\n'}
+	data := append(header, []byte("05.03.26;YANDEX TAXI;0;350,00\n06.03.26;CASHBACK;100;0\n")...)
+
+	r, err := Parse("statement.csv", data, DefaultMappings)
+
+	assert.NoError(t, err)
+	assertTransactions(t, []Transaction{{Date: day(5), Amount: decimal.NewFromInt(350), Merchant: "YANDEX TAXI"}}, r)
+}
+
+func TestParse_UnknownColumns(t *testing.T) {
+	_, err := Parse("statement.csv", []byte("a;b;c\n1;2;3\n"), DefaultMappings)
+
+	assert.ErrorIs(t, err, ErrUnknownColumns)
+}
+
+func TestParse_OFX(t *testing.T) {
+	data := `OFXHEADER:100
+DATA:OFXSGML
+
+<OFX><BANKMSGSRSV1><STMTTRNRS><STMTRS><CURDEF>RUB
+<BANKTRANLIST>
+<STMTTRN><TRNTYPE>DEBIT<DTPOSTED>20260307120000[+3:MSK]<TRNAMT>-99.90<FITID>1<NAME>KOFEMANIYA</STMTTRN>
+<STMTTRN><TRNTYPE>CREDIT<DTPOSTED>20260308<TRNAMT>1000.00<FITID>2<NAME>TRANSFER</STMTTRN>
+<STMTTRN><TRNTYPE>DEBIT<DTPOSTED>20260309<TRNAMT>-10<FITID>3<MEMO>no name</STMTTRN>
+</BANKTRANLIST></STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>`
+
+	r, err := Parse("bank.ofx", []byte(data), nil)
+
+	assert.NoError(t, err)
+	assertTransactions(t, []Transaction{
+		{Date: day(7), Amount: decimal.RequireFromString("99.9"), Merchant: "KOFEMANIYA"},
+		{Date: day(9), Amount: decimal.NewFromInt(10), Merchant: "no name"},
+	}, r)
+}
+
+func TestParse_QIF(t *testing.T) {
+	data := "!Type:Bank\nD03/10/2026\nT-1,250.00\nPPIKABU SHOP\n^\nD3/11'26\nT500.00\nPSALARY\n^\nD12.03.2026\nU-40,5\nMmetro\n^\n"
+
+	r, err := Parse("export", []byte(data), nil)
+
+	assert.NoError(t, err)
+	assertTransactions(t, []Transaction{
+		{Date: day(10), Amount: decimal.NewFromInt(1250), Merchant: "PIKABU SHOP"},
+		{Date: day(12), Amount: decimal.RequireFromString("40.5"), Merchant: "metro"},
+	}, r)
+}
+
+func Test_parseAmount(t *testing.T) {
+	for s, want := range map[string]string{
+		"-1 234,50": "-1234.5",
+		"1,234.50":  "1234.5",
+		"1.234,50":  "1234.5",
+		"1,234":     "1234",
+		"12,5":      "12.5",
+		"100":       "100",
+	} {
+		got, err := parseAmount(s)
+		assert.NoError(t, err, s)
+		assert.True(t, got.Equal(decimal.RequireFromString(want)), s)
+	}
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"context"
+
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
+)
+
+type categoryStorageI interface {
+	GetAll() ([]model.Category, error)
+	Add(name string) (model.Category, error)
+	Rename(id int, name string) error
+	Archive(id int) error
+	Merge(fromId int, toId int) error
+	GetUserIds(id int) ([]int64, error)
+}
+
+// CachedCategoryStorage drops the cached reports of the users whose spendings are renamed or moved to another category.
+type CachedCategoryStorage struct {
+	targetStorage categoryStorageI
+	cache         cacheI
+}
+
+func NewCachedCategoryStorage(storage categoryStorageI, cacheI cacheI) *CachedCategoryStorage {
+	return &CachedCategoryStorage{targetStorage: storage, cache: cacheI}
+}
+
+func (s *CachedCategoryStorage) GetAll() ([]model.Category, error) {
+	return s.targetStorage.GetAll()
+}
+
+func (s *CachedCategoryStorage) Add(name string) (model.Category, error) {
+	return s.targetStorage.Add(name)
+}
+
+// Rename changes the name of the category, reports keep sums by the names of categories.
+func (s *CachedCategoryStorage) Rename(id int, name string) error {
+	userIds, err := s.targetStorage.GetUserIds(id)
+	if err != nil {
+		return err
+	}
+	if err := s.targetStorage.Rename(id, name); err != nil {
+		return err
+	}
+	return s.dropReports(userIds)
+}
+
+func (s *CachedCategoryStorage) Archive(id int) error {
+	return s.targetStorage.Archive(id)
+}
+
+func (s *CachedCategoryStorage) Merge(fromId int, toId int) error {
+	userIds, err := s.targetStorage.GetUserIds(fromId)
+	if err != nil {
+		return err
+	}
+	if err := s.targetStorage.Merge(fromId, toId); err != nil {
+		return err
+	}
+	return s.dropReports(userIds)
+}
+
+func (s *CachedCategoryStorage) dropReports(userIds []int64) error {
+	for i := 0; i < len(userIds); i++ {
+		if err := s.cache.Delete(context.Background(), cacheKey(userIds[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"fmt"
 	"github.com/jmoiron/sqlx"
 	"github.com/shopspring/decimal"
 	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/cache"
@@ -17,10 +18,22 @@ type cacheI interface {
 }
 type spendingStorageI interface {
 	SaveTx(tx *sqlx.Tx, spending model.Spending) error
-	GetStatsBy(context.Context, time.Time, time.Time) (map[string]decimal.Decimal, error)
+	GetLast(ctx context.Context, userId int64, count int, currencyCode string) ([]model.Spending, error)
+	FindByText(ctx context.Context, userId int64, text string, startAt, endAt time.Time, currencyCode string) ([]model.Spending, error)
+	FindByTag(ctx context.Context, userId int64, tag string, startAt, endAt time.Time, currencyCode string) ([]model.Spending, error)
+	GetTx(tx *sqlx.Tx, userId int64, id int64) (model.Spending, error)
+	GetLastTx(tx *sqlx.Tx, userId int64) (model.Spending, error)
+	UpdateTx(tx *sqlx.Tx, spending model.Spending) error
+	DeleteTx(tx *sqlx.Tx, userId int64, id int64) error
+	GetStatsBy(context.Context, int64, time.Time, time.Time, string) (map[string]decimal.Decimal, error)
+	GetBreakdown(ctx context.Context, userId int64, startAt, endAt time.Time, unit string, currencyCode string) ([]model.ReportBucket, error)
+	GetTop(ctx context.Context, userId int64, startAt, endAt time.Time, count int, currencyCode string) ([]model.ReportEntry, error)
+	GetEntries(ctx context.Context, userId int64, startAt, endAt time.Time, limit int, currencyCode string) ([]model.ReportEntry, error)
 }
 
-var cacheKey = "report"
+func cacheKey(userId int64) string {
+	return fmt.Sprintf("report:%d", userId)
+}
 
 type CachedSpendingStorage struct {
 	targetStorage spendingStorageI
@@ -32,19 +45,53 @@ func NewCachedSpendingStorage(storage spendingStorageI, cacheI cacheI) *CachedSp
 }
 
 func (s *CachedSpendingStorage) SaveTx(ctx context.Context, tx *sqlx.Tx, spending model.Spending) error {
-	if err := s.cache.Delete(ctx, cacheKey); err != nil {
+	if err := s.cache.Delete(ctx, cacheKey(spending.UserId)); err != nil {
 		return err
 	}
 	return s.targetStorage.SaveTx(tx, spending)
 }
 
-func (s *CachedSpendingStorage) GetStatsBy(ctx context.Context, start time.Time, end time.Time) (map[string]decimal.Decimal, error) {
-	cacheResult, err := s.cache.Get(ctx, cacheKey)
+func (s *CachedSpendingStorage) GetLast(ctx context.Context, userId int64, count int, currencyCode string) ([]model.Spending, error) {
+	return s.targetStorage.GetLast(ctx, userId, count, currencyCode)
+}
+
+func (s *CachedSpendingStorage) FindByText(ctx context.Context, userId int64, text string, startAt, endAt time.Time, currencyCode string) ([]model.Spending, error) {
+	return s.targetStorage.FindByText(ctx, userId, text, startAt, endAt, currencyCode)
+}
+
+func (s *CachedSpendingStorage) FindByTag(ctx context.Context, userId int64, tag string, startAt, endAt time.Time, currencyCode string) ([]model.Spending, error) {
+	return s.targetStorage.FindByTag(ctx, userId, tag, startAt, endAt, currencyCode)
+}
+
+func (s *CachedSpendingStorage) GetTx(tx *sqlx.Tx, userId int64, id int64) (model.Spending, error) {
+	return s.targetStorage.GetTx(tx, userId, id)
+}
+
+func (s *CachedSpendingStorage) GetLastTx(tx *sqlx.Tx, userId int64) (model.Spending, error) {
+	return s.targetStorage.GetLastTx(tx, userId)
+}
+
+func (s *CachedSpendingStorage) UpdateTx(ctx context.Context, tx *sqlx.Tx, spending model.Spending) error {
+	if err := s.cache.Delete(ctx, cacheKey(spending.UserId)); err != nil {
+		return err
+	}
+	return s.targetStorage.UpdateTx(tx, spending)
+}
+
+func (s *CachedSpendingStorage) DeleteTx(ctx context.Context, tx *sqlx.Tx, userId int64, id int64) error {
+	if err := s.cache.Delete(ctx, cacheKey(userId)); err != nil {
+		return err
+	}
+	return s.targetStorage.DeleteTx(tx, userId, id)
+}
+
+func (s *CachedSpendingStorage) GetStatsBy(ctx context.Context, userId int64, start time.Time, end time.Time, currencyCode string) (map[string]decimal.Decimal, error) {
+	cacheResult, err := s.cache.Get(ctx, cacheKey(userId))
 	if err != nil && err != cache.ErrNotFound {
 		return nil, err
 	}
 	if err == cache.ErrNotFound {
-		return s.refreshCache(ctx, start, end, make([]model.Report, 0))
+		return s.refreshCache(ctx, userId, start, end, currencyCode, make([]model.Report, 0))
 	}
 
 	reports, err := model.FromJSON(cacheResult)
@@ -52,19 +99,32 @@ func (s *CachedSpendingStorage) GetStatsBy(ctx context.Context, start time.Time,
 		return nil, err
 	}
 	for i := 0; i < len(reports); i++ {
-		if time_util.DatesEq(reports[i].Start, start) && time_util.DatesEq(reports[i].End, end) {
+		if time_util.DatesEq(reports[i].Start, start) && time_util.DatesEq(reports[i].End, end) && reports[i].CurrencyCode == currencyCode {
 			return reports[i].Data, nil
 		}
 	}
-	return s.refreshCache(ctx, start, end, reports)
+	return s.refreshCache(ctx, userId, start, end, currencyCode, reports)
+}
+
+func (s *CachedSpendingStorage) GetBreakdown(ctx context.Context, userId int64, startAt, endAt time.Time, unit string, currencyCode string) ([]model.ReportBucket, error) {
+	return s.targetStorage.GetBreakdown(ctx, userId, startAt, endAt, unit, currencyCode)
+}
+
+func (s *CachedSpendingStorage) GetTop(ctx context.Context, userId int64, startAt, endAt time.Time, count int, currencyCode string) ([]model.ReportEntry, error) {
+	return s.targetStorage.GetTop(ctx, userId, startAt, endAt, count, currencyCode)
+}
+
+func (s *CachedSpendingStorage) GetEntries(ctx context.Context, userId int64, startAt, endAt time.Time, limit int, currencyCode string) ([]model.ReportEntry, error) {
+	return s.targetStorage.GetEntries(ctx, userId, startAt, endAt, limit, currencyCode)
 }
 
-func (s *CachedSpendingStorage) refreshCache(ctx context.Context, start, end time.Time, exists []model.Report) (map[string]decimal.Decimal, error) {
-	result, err := s.targetStorage.GetStatsBy(ctx, start, end)
+func (s *CachedSpendingStorage) refreshCache(ctx context.Context, userId int64, start, end time.Time, currencyCode string, exists []model.Report) (map[string]decimal.Decimal, error) {
+	result, err := s.targetStorage.GetStatsBy(ctx, userId, start, end, currencyCode)
 	if err != nil {
 		return nil, err
 	}
-	newReport := model.NewReport(0, start, end, result)
+	newReport := model.NewReport(userId, start, end, result, nil)
+	newReport.CurrencyCode = currencyCode
 	exists = append(exists, *newReport)
 
 	js, err := model.ToJSON(exists)
@@ -72,7 +132,7 @@ func (s *CachedSpendingStorage) refreshCache(ctx context.Context, start, end tim
 		return nil, err
 	}
 
-	if err := s.cache.Set(ctx, cacheKey, js, time.Hour); err != nil {
+	if err := s.cache.Set(ctx, cacheKey(userId), js, time.Hour); err != nil {
 		return nil, err
 	}
 	return result, nil
@@ -0,0 +1,62 @@
+package pgdatabase
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
+)
+
+// dbCategoryModelStorage keeps word counts of the naive Bayes category suggester.
+type dbCategoryModelStorage struct {
+	ctx context.Context
+	db  *sqlx.DB
+}
+
+func NewCategoryModelStorage(ctx context.Context, db *sqlx.DB) *dbCategoryModelStorage {
+	return &dbCategoryModelStorage{ctx: ctx, db: db}
+}
+
+// LearnTx adds the words of a note to the counts of the category.
+func (s *dbCategoryModelStorage) LearnTx(tx *sqlx.Tx, userId int64, categoryId int, words []string) error {
+	q := `insert into category_words(user_id, category_id, word, count)
+select $1, $2, word, count(*) from unnest($3::text[]) as word group by word
+on conflict (user_id, word, category_id) do update set count = category_words.count + excluded.count`
+	if _, err := tx.ExecContext(s.ctx, q, userId, categoryId, pq.StringArray(words)); err != nil {
+		return err
+	}
+	q = `insert into category_notes(user_id, category_id, notes, words) values($1, $2, 1, $3)
+on conflict (user_id, category_id) do update set notes = category_notes.notes + 1, words = category_notes.words + excluded.words`
+	_, err := tx.ExecContext(s.ctx, q, userId, categoryId, len(words))
+	return err
+}
+
+// GetCategoryNotes returns the counts of active categories of the user.
+func (s *dbCategoryModelStorage) GetCategoryNotes(ctx context.Context, userId int64) ([]model.CategoryNotes, error) {
+	r := []model.CategoryNotes{}
+	q := `select category_id, categories.name as category_name, notes, words
+from category_notes inner join categories on category_notes.category_id = categories.id where user_id = $1 and not categories.archived`
+	if err := s.db.SelectContext(ctx, &r, q, userId); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (s *dbCategoryModelStorage) GetWordCounts(ctx context.Context, userId int64, words []string) ([]model.WordCount, error) {
+	r := []model.WordCount{}
+	q := "select category_id, word, count from category_words where user_id = $1 and word = any($2)"
+	if err := s.db.SelectContext(ctx, &r, q, userId, pq.StringArray(words)); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetVocabularySize returns the number of distinct words in notes of the user.
+func (s *dbCategoryModelStorage) GetVocabularySize(ctx context.Context, userId int64) (int, error) {
+	var r int
+	if err := s.db.GetContext(ctx, &r, "select count(distinct word) from category_words where user_id = $1", userId); err != nil {
+		return 0, err
+	}
+	return r, nil
+}
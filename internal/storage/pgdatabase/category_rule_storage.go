@@ -0,0 +1,49 @@
+package pgdatabase
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
+)
+
+type dbCategoryRuleStorage struct {
+	ctx context.Context
+	db  *sqlx.DB
+}
+
+func NewCategoryRuleStorage(ctx context.Context, db *sqlx.DB) *dbCategoryRuleStorage {
+	return &dbCategoryRuleStorage{ctx: ctx, db: db}
+}
+
+func (s *dbCategoryRuleStorage) Add(r model.CategoryRule) (int64, error) {
+	var id int64
+	q := "insert into category_rules(user_id, kind, pattern, category_id) values($1,$2,$3,$4) returning id"
+	if err := s.db.GetContext(s.ctx, &id, q, r.UserId, r.Kind, r.Pattern, r.CategoryId); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (s *dbCategoryRuleStorage) GetAll(userId int64) ([]model.CategoryRule, error) {
+	r := []model.CategoryRule{}
+	q := `select category_rules.id, user_id, kind, pattern, category_id, categories.name as category_name
+from category_rules inner join categories on category_rules.category_id = categories.id where user_id = $1 order by category_rules.id`
+	if err := s.db.SelectContext(s.ctx, &r, q, userId); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (s *dbCategoryRuleStorage) Delete(userId int64, id int64) error {
+	res, err := s.db.ExecContext(s.ctx, "delete from category_rules where user_id = $1 and id = $2", userId, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return model.ErrCategoryRuleNotFound
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package pgdatabase
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/jmoiron/sqlx"
 	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
@@ -10,14 +11,102 @@ import (
 type dbCategoryStorage struct {
 	ctx context.Context
 	db  *sqlx.DB
+	// таблица категорий и таблица записей, которые на нее ссылаются
+	table        string
+	entriesTable string
+	// запросы, переносящие остальные ссылки на категорию $1 в $2 при слиянии
+	mergeQueries []string
+	// запросы, удаляющие настройки архивируемой категории $1, иначе они вернутся вместе с ней
+	archiveQueries []string
+}
+
+// у пользователя может быть своя запись и для категории, в которую сливаем: суммируем или оставляем её
+var categoryMergeQueries = []string{
+	`update category_limits l set category_id = $2 where category_id = $1
+		and not exists (select 1 from category_limits t where t.user_id = l.user_id and t.category_id = $2)`,
+	"delete from category_limits where category_id = $1",
+	"update recurring_spendings set category_id = $2 where category_id = $1",
+	"update category_rules set category_id = $2 where category_id = $1",
+	`insert into category_words(user_id, category_id, word, count)
+		select user_id, $2, word, count from category_words where category_id = $1
+		on conflict (user_id, word, category_id) do update set count = category_words.count + excluded.count`,
+	"delete from category_words where category_id = $1",
+	`insert into category_notes(user_id, category_id, notes, words)
+		select user_id, $2, notes, words from category_notes where category_id = $1
+		on conflict (user_id, category_id) do update
+		set notes = category_notes.notes + excluded.notes, words = category_notes.words + excluded.words`,
+	"delete from category_notes where category_id = $1",
+}
+
+// траты и история категоризации остаются, лимит архивной категории не должен учитываться в бюджете
+var categoryArchiveQueries = []string{
+	"delete from category_limits where category_id = $1",
 }
 
 func NewCategoryStorage(ctx context.Context, db *sqlx.DB) *dbCategoryStorage {
-	return &dbCategoryStorage{ctx: ctx, db: db}
+	return &dbCategoryStorage{ctx: ctx, db: db, table: "categories", entriesTable: "spendings", mergeQueries: categoryMergeQueries, archiveQueries: categoryArchiveQueries}
+}
+
+func NewIncomeCategoryStorage(ctx context.Context, db *sqlx.DB) *dbCategoryStorage {
+	return &dbCategoryStorage{ctx: ctx, db: db, table: "income_categories", entriesTable: "incomes"}
 }
 
 func (s *dbCategoryStorage) GetAll() ([]model.Category, error) {
 	r := []model.Category{}
-	err := s.db.SelectContext(s.ctx, &r, "select id, name from categories")
+	err := s.db.SelectContext(s.ctx, &r, fmt.Sprintf("select id, name from %v where not archived order by id", s.table))
 	return r, err
 }
+
+// Add creates a category, an archived category with the same name is restored instead.
+func (s *dbCategoryStorage) Add(name string) (model.Category, error) {
+	var c model.Category
+	q := fmt.Sprintf("insert into %v(name) values($1) on conflict(name) do update set archived = false returning id, name", s.table)
+	if err := s.db.GetContext(s.ctx, &c, q, name); err != nil {
+		return model.Category{}, err
+	}
+	return c, nil
+}
+
+func (s *dbCategoryStorage) Rename(id int, name string) error {
+	_, err := s.db.ExecContext(s.ctx, fmt.Sprintf("update %v set name = $2 where id = $1", s.table), id, name)
+	return err
+}
+
+// Archive hides the category, its entries are kept.
+func (s *dbCategoryStorage) Archive(id int) error {
+	queries := append([]string{fmt.Sprintf("update %v set archived = true where id = $1", s.table)}, s.archiveQueries...)
+	fs := make([]func(tx *sqlx.Tx) error, 0, len(queries))
+	for i := range queries {
+		q := queries[i]
+		fs = append(fs, func(tx *sqlx.Tx) error {
+			_, err := tx.ExecContext(s.ctx, q, id)
+			return err
+		})
+	}
+	return RunInTx(fs...)
+}
+
+// GetUserIds returns the users who have entries in the category.
+func (s *dbCategoryStorage) GetUserIds(id int) ([]int64, error) {
+	r := []int64{}
+	err := s.db.SelectContext(s.ctx, &r, fmt.Sprintf("select distinct user_id from %v where category_id = $1", s.entriesTable), id)
+	return r, err
+}
+
+// Merge moves all entries of the category fromId and everything else referring to it to toId and archives fromId.
+func (s *dbCategoryStorage) Merge(fromId int, toId int) error {
+	queries := append([]string{fmt.Sprintf("update %v set category_id = $2 where category_id = $1", s.entriesTable)}, s.mergeQueries...)
+	fs := make([]func(tx *sqlx.Tx) error, 0, len(queries)+1)
+	for i := range queries {
+		q := queries[i]
+		fs = append(fs, func(tx *sqlx.Tx) error {
+			_, err := tx.ExecContext(s.ctx, q, fromId, toId)
+			return err
+		})
+	}
+	fs = append(fs, func(tx *sqlx.Tx) error {
+		_, err := tx.ExecContext(s.ctx, fmt.Sprintf("update %v set archived = true where id = $1", s.table), fromId)
+		return err
+	})
+	return RunInTx(fs...)
+}
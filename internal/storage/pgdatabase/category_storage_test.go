@@ -0,0 +1,110 @@
+package pgdatabase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_AddCategory(t *testing.T) {
+	storage := NewCategoryStorage(context.Background(), DB)
+	tests := []struct {
+		name     string
+		category string
+		prepareF func()
+		checkF   func(err error)
+	}{
+		{
+			name:     "added successfully",
+			category: "taxi",
+			prepareF: func() {},
+			checkF: func(err error) {
+				assert.NoError(t, err)
+				checkIsExist(t, "select count(1) from categories where name = 'taxi' and id = 2 and not archived", 1)
+			},
+		},
+		{
+			name:     "archived category is restored",
+			category: "food",
+			prepareF: func() {
+				DB.MustExec("update categories set archived = true where name = 'food'")
+			},
+			checkF: func(err error) {
+				assert.NoError(t, err)
+				checkIsExist(t, "select count(1) from categories where name = 'food' and id = 0 and not archived", 1)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			BeforeTest()
+			tt.prepareF()
+			_, err := storage.Add(tt.category)
+			tt.checkF(err)
+		})
+	}
+}
+
+func Test_ArchiveCategory_shouldDropLimits(t *testing.T) {
+	BeforeTest()
+	storage := NewCategoryStorage(context.Background(), DB)
+	DB.MustExec("insert into category_limits(user_id, category_id, limit_value) values(1, 1, 100), (1, 0, 200)")
+
+	assert.NoError(t, storage.Archive(1))
+
+	checkIsExist(t, "select count(1) from categories where id = 1 and archived", 1)
+	checkIsExist(t, "select count(1) from category_limits where category_id = 1", 0)
+	checkIsExist(t, "select count(1) from category_limits where category_id = 0", 1)
+	// восстановленная категория возвращается без старого лимита
+	_, err := storage.Add("other")
+	assert.NoError(t, err)
+	checkIsExist(t, "select count(1) from category_limits where category_id = 1", 0)
+}
+
+func Test_MergeCategory(t *testing.T) {
+	BeforeTest()
+	storage := NewCategoryStorage(context.Background(), DB)
+	DB.MustExec("insert into spendings(user_id, value, original_value, category_id, date) values(1, 1, 1, 1, now())")
+
+	assert.NoError(t, storage.Merge(1, 0))
+
+	checkIsExist(t, "select count(1) from spendings where category_id = 0", 1)
+	checkIsExist(t, "select count(1) from categories where id = 1 and archived", 1)
+	categories, err := storage.GetAll()
+	assert.NoError(t, err)
+	assert.Len(t, categories, 1)
+}
+
+func Test_MergeCategory_shouldMoveEverythingReferringToCategory(t *testing.T) {
+	BeforeTest()
+	storage := NewCategoryStorage(context.Background(), DB)
+	// у пользователя 1 лимит и слова есть в обеих категориях, у пользователя 2 - только в сливаемой
+	DB.MustExec("insert into category_limits(user_id, category_id, limit_value) values(1, 1, 100), (1, 0, 200), (2, 1, 300)")
+	DB.MustExec("insert into recurring_spendings(user_id, value, currency_code, category_id, schedule, next_run_at) values(1, 1, 'rub', 1, '0 9 * * *', now())")
+	DB.MustExec("insert into category_rules(user_id, pattern, category_id) values(1, 'lavka', 1)")
+	DB.MustExec("insert into category_words(user_id, category_id, word, count) values(1, 1, 'lunch', 2), (1, 0, 'lunch', 3)")
+	DB.MustExec("insert into category_notes(user_id, category_id, notes, words) values(1, 1, 2, 2), (1, 0, 3, 3)")
+
+	assert.NoError(t, storage.Merge(1, 0))
+
+	checkIsExist(t, "select count(1) from category_limits where category_id = 1", 0)
+	checkIsExist(t, "select count(1) from category_limits where user_id = 1 and category_id = 0 and limit_value = 200", 1)
+	checkIsExist(t, "select count(1) from category_limits where user_id = 2 and category_id = 0 and limit_value = 300", 1)
+	checkIsExist(t, "select count(1) from recurring_spendings where category_id = 0", 1)
+	checkIsExist(t, "select count(1) from category_rules where category_id = 0", 1)
+	checkIsExist(t, "select count(1) from category_words where category_id = 1", 0)
+	checkIsExist(t, "select count(1) from category_words where category_id = 0 and word = 'lunch' and count = 5", 1)
+	checkIsExist(t, "select count(1) from category_notes where category_id = 0 and notes = 5 and words = 5", 1)
+}
+
+func Test_GetUserIds(t *testing.T) {
+	BeforeTest()
+	storage := NewCategoryStorage(context.Background(), DB)
+	DB.MustExec("insert into spendings(user_id, value, original_value, category_id, date) values(1, 1, 1, 1, now()), (1, 2, 2, 1, now()), (2, 1, 1, 1, now()), (3, 1, 1, 0, now())")
+
+	userIds, err := storage.GetUserIds(1)
+
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []int64{1, 2}, userIds)
+}
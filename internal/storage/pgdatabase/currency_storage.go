@@ -2,10 +2,14 @@ package pgdatabase
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
+	"github.com/shopspring/decimal"
 	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
 )
 
@@ -18,13 +22,35 @@ func NewCurrencyStorage(ctx context.Context, db *sqlx.DB) *dbCurrencyStorage {
 	return &dbCurrencyStorage{ctx: ctx, db: db}
 }
 
-func (s *dbCurrencyStorage) GetCurrentCurrency(ctx context.Context) (model.Currency, error) {
+// currencyColumns selects the rate of today and the time it was loaded.
+var currencyColumns = `code, currency_rate(code, current_date) as ratio,
+(select updated_at from currency_rates where currency_rates.code = currencies.code order by date desc limit 1) as updated_at`
+
+// withRate skips currencies without rates, there is nothing to convert at.
+const withRate = "currency_rate(code, current_date) is not null"
+
+// CheckRate returns model.ErrNoRate if sums can't be converted to the currency, currency_rate gives null for it.
+func CheckRate(ctx context.Context, db *sqlx.DB, code string) error {
+	var ok bool
+	if err := db.GetContext(ctx, &ok, "select currency_rate($1, current_date) is not null", code); err != nil {
+		return err
+	}
+	if !ok {
+		return model.ErrNoRate
+	}
+	return nil
+}
+
+func (s *dbCurrencyStorage) GetCurrentCurrency(ctx context.Context, userId int64) (model.Currency, error) {
 	span, _ := opentracing.StartSpanFromContext(ctx, "currency_storage: GetCurrentCurrency")
 	defer span.Finish()
 	var c model.Currency
-	q := "select code, ratio from currencies where code = (select current_currency_code from state)"
-	if err := s.db.GetContext(s.ctx, &c, q); err != nil {
+	q := "select " + currencyColumns + " from currencies where code = coalesce((select current_currency_code from state where user_id = $1), $2) and " + withRate
+	if err := s.db.GetContext(s.ctx, &c, q, userId, model.BaseCurrency); err != nil {
 		ext.Error.Set(span, true)
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Currency{}, model.ErrNoRate
+		}
 		return model.Currency{}, err
 	}
 	return c, nil
@@ -32,14 +58,17 @@ func (s *dbCurrencyStorage) GetCurrentCurrency(ctx context.Context) (model.Curre
 
 func (s *dbCurrencyStorage) GetCurrencies() ([]model.Currency, error) {
 	cs := []model.Currency{}
-	if err := s.db.SelectContext(s.ctx, &cs, "select code, ratio from currencies"); err != nil {
+	if err := s.db.SelectContext(s.ctx, &cs, "select "+currencyColumns+" from currencies where "+withRate); err != nil {
 		return nil, err
 	}
 	return cs, nil
 }
 
-func (s *dbCurrencyStorage) UpdateCurrentCurrency(code string) error {
-	_, err := s.db.ExecContext(s.ctx, "update state set current_currency_code = $1", code)
+func (s *dbCurrencyStorage) UpdateCurrentCurrency(userId int64, code string) error {
+	if _, err := s.db.ExecContext(s.ctx, ensureStateQ, userId, model.BaseCurrency); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(s.ctx, "update state set current_currency_code = $2 where user_id = $1", userId, code)
 	return err
 }
 
@@ -50,7 +79,7 @@ func (s *dbCurrencyStorage) UpdateCurrencies(newcrns []model.Currency) error {
 	}
 
 	for i := 0; i < len(newcrns); i++ {
-		if _, err := s.db.ExecContext(s.ctx, "insert into currencies values($1,$2) on conflict(code) do update set ratio = $2", newcrns[i].Code, newcrns[i].Ratio); err != nil {
+		if err := s.saveRateTx(tx, newcrns[i]); err != nil {
 			if err := tx.Rollback(); err != nil {
 				return err
 			}
@@ -60,3 +89,42 @@ func (s *dbCurrencyStorage) UpdateCurrencies(newcrns []model.Currency) error {
 
 	return tx.Commit()
 }
+
+// saveRateTx adds the currency if it is new and stores its rate for today.
+func (s *dbCurrencyStorage) saveRateTx(tx *sqlx.Tx, c model.Currency) error {
+	if _, err := tx.ExecContext(s.ctx, "insert into currencies(code) values($1) on conflict do nothing", c.Code); err != nil {
+		return err
+	}
+	q := "insert into currency_rates(code, date, ratio) values($1, current_date, $2) on conflict(code, date) do update set ratio = $2, updated_at = now()"
+	_, err := tx.ExecContext(s.ctx, q, c.Code, c.Ratio)
+	return err
+}
+
+// GetRate returns the rate of the currency on the date, the earliest known rate is used for dates before the history.
+// model.ErrNoRate is returned if the currency has no rates.
+func (s *dbCurrencyStorage) GetRate(ctx context.Context, code string, date time.Time) (decimal.Decimal, error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "currency_storage: GetRate")
+	defer span.Finish()
+	var rate decimal.NullDecimal
+	if err := s.db.GetContext(s.ctx, &rate, "select currency_rate($1, $2)", code, date); err != nil {
+		ext.Error.Set(span, true)
+		return decimal.Zero, err
+	}
+	if !rate.Valid {
+		return decimal.Zero, model.ErrNoRate
+	}
+	return rate.Decimal, nil
+}
+
+// GetRates returns the history of the rate of the currency in the period ordered by date.
+func (s *dbCurrencyStorage) GetRates(ctx context.Context, code string, startAt, endAt time.Time) ([]model.CurrencyRate, error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "currency_storage: GetRates")
+	defer span.Finish()
+	r := []model.CurrencyRate{}
+	q := "select code, date, ratio from currency_rates where code = $1 and date between $2 and $3 order by date"
+	if err := s.db.SelectContext(s.ctx, &r, q, code, startAt, endAt); err != nil {
+		ext.Error.Set(span, true)
+		return nil, err
+	}
+	return r, nil
+}
@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
@@ -98,7 +99,7 @@ func Test_GetCurrency(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			BeforeTest()
 			tt.prepareF()
-			ctype, err := storage.GetCurrentCurrency(context.Background())
+			ctype, err := storage.GetCurrentCurrency(context.Background(), 0)
 			assert.ErrorIs(t, err, tt.err)
 
 			assert.Equal(t, ctype.Code, tt.ctype.Code)
@@ -118,10 +119,10 @@ func Test_dbCurrencyStorage_UpdateCurrentCurrency(t *testing.T) {
 			"updated successfully",
 			"eur",
 			func() {
-				DB.MustExec("insert into currencies(code, ratio) values('eur', 1)")
+				DB.MustExec("insert into currencies(code) values('eur')")
 			},
 			func(err error) {
-				checkIsExist(t, "select count(1) from state where current_currency_code = 'eur'", 1)
+				checkIsExist(t, "select count(1) from state where user_id = 0 and current_currency_code = 'eur'", 1)
 			},
 		},
 		{
@@ -138,7 +139,7 @@ func Test_dbCurrencyStorage_UpdateCurrentCurrency(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			BeforeTest()
 			tt.prepareF()
-			tt.checkF(storage.UpdateCurrentCurrency(tt.code))
+			tt.checkF(storage.UpdateCurrentCurrency(0, tt.code))
 		})
 	}
 }
@@ -157,11 +158,16 @@ func Test_UpdateCurrencies(t *testing.T) {
 				{Code: "eur", Ratio: decimal.NewFromInt(2)},
 			},
 			prepareF: func() {
-				DB.MustExec("insert into currencies values('cny', 0)")
+				DB.MustExec("insert into currencies(code) values('cny')")
+				DB.MustExec("insert into currency_rates(code, date, ratio) values('cny', current_date, 0)")
+				DB.MustExec("insert into currency_rates(code, date, ratio) values('cny', current_date - 1, 3)")
 			},
 			checkF: func(err error) {
 				assert.ErrorIs(t, err, nil)
-				checkIsExist(t, "select count(1) from currencies where (code = 'cny' and ratio = 1) or (code = 'eur' and ratio = 2)", 2)
+				checkIsExist(t, "select count(1) from currencies where code in ('cny', 'eur')", 2)
+				checkIsExist(t, "select count(1) from currency_rates where date = current_date and ((code = 'cny' and ratio = 1) or (code = 'eur' and ratio = 2))", 2)
+				// курсы прошлых дней остаются в истории
+				checkIsExist(t, "select count(1) from currency_rates where code = 'cny' and date = current_date - 1 and ratio = 3", 1)
 			},
 		},
 	}
@@ -173,3 +179,69 @@ func Test_UpdateCurrencies(t *testing.T) {
 		})
 	}
 }
+
+func Test_GetRate(t *testing.T) {
+	BeforeTest()
+	day := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	DB.MustExec("insert into currencies(code) values('usd')")
+	DB.MustExec("insert into currency_rates(code, date, ratio) values('usd', $1, 0.01)", day)
+	DB.MustExec("insert into currency_rates(code, date, ratio) values('usd', $1, 0.0125)", day.AddDate(0, 0, 5))
+
+	tests := []struct {
+		name string
+		date time.Time
+		rate decimal.Decimal
+	}{
+		{name: "on the date", date: day, rate: decimal.RequireFromString("0.01")},
+		{name: "last known before the date", date: day.AddDate(0, 0, 3), rate: decimal.RequireFromString("0.01")},
+		{name: "new rate", date: day.AddDate(0, 0, 6), rate: decimal.RequireFromString("0.0125")},
+		{name: "earliest before the history", date: day.AddDate(-1, 0, 0), rate: decimal.RequireFromString("0.01")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, err := storage.GetRate(context.TODO(), "usd", tt.date)
+			assert.NoError(t, err)
+			assert.True(t, tt.rate.Equal(rate), "got %v", rate)
+		})
+	}
+}
+
+func Test_GetRates(t *testing.T) {
+	BeforeTest()
+	day := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	DB.MustExec("insert into currencies(code) values('usd')")
+	for i := 0; i < 3; i++ {
+		DB.MustExec("insert into currency_rates(code, date, ratio) values('usd', $1, 0.01)", day.AddDate(0, 0, i))
+	}
+
+	r, err := storage.GetRates(context.TODO(), "usd", day.AddDate(0, 0, 1), day.AddDate(0, 0, 5))
+	assert.NoError(t, err)
+	assert.Len(t, r, 2)
+	assert.Equal(t, day.AddDate(0, 0, 1), r[0].Date.UTC())
+
+	cs, err := storage.GetCurrencies()
+	assert.NoError(t, err)
+	for i := range cs {
+		assert.False(t, cs[i].UpdatedAt.IsZero(), cs[i].Code)
+	}
+}
+
+func Test_UpdatedAtMigration_shouldKeepOldRatesStale(t *testing.T) {
+	BeforeTest()
+	migrations.Migrate(DBURL, "migrations", 13)
+	defer migrations.Up(DBURL, "migrations")
+	day := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	DB.MustExec("insert into currencies(code) values('usd')")
+	DB.MustExec("insert into currency_rates(code, date, ratio) values('usd', $1, 0.01)", day)
+
+	migrations.Up(DBURL, "migrations")
+
+	var updatedAt time.Time
+	assert.NoError(t, DB.Get(&updatedAt, "select updated_at from currency_rates where code = 'usd'"))
+	assert.True(t, day.Equal(updatedAt), "got %v", updatedAt)
+
+	// новые курсы по-прежнему получают время загрузки
+	DB.MustExec("insert into currency_rates(code, date, ratio) values('usd', $1, 0.0125)", day.AddDate(0, 0, 1))
+	assert.NoError(t, DB.Get(&updatedAt, "select updated_at from currency_rates where code = 'usd' and date = $1", day.AddDate(0, 0, 1)))
+	assert.WithinDuration(t, time.Now(), updatedAt, time.Minute)
+}
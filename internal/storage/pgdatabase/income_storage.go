@@ -0,0 +1,58 @@
+package pgdatabase
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/shopspring/decimal"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
+)
+
+type dbIncomeStorage struct {
+	ctx context.Context
+	db  *sqlx.DB
+}
+
+func NewIncomeStorage(ctx context.Context, db *sqlx.DB) *dbIncomeStorage {
+	return &dbIncomeStorage{ctx: ctx, db: db}
+}
+
+func (s *dbIncomeStorage) SaveTx(tx *sqlx.Tx, income model.Income) error {
+	q := "insert into incomes(user_id, value, category_id, date) values($1,$2,$3,$4)"
+	if _, err := tx.ExecContext(s.ctx, q, income.UserId, income.Value, income.CategoryId, income.Date); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetStatsBy returns sums per category in the currency at the rates of the dates of the incomes.
+func (s *dbIncomeStorage) GetStatsBy(ctx context.Context, userId int64, startAt, endAt time.Time, currencyCode string) (map[string]decimal.Decimal, error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "storage: getting income report")
+	defer span.Finish()
+
+	if err := CheckRate(s.ctx, s.db, currencyCode); err != nil {
+		ext.Error.Set(span, true)
+		return nil, err
+	}
+
+	results := []struct {
+		Name  string          `db:"name"`
+		Value decimal.Decimal `db:"value"`
+	}{}
+
+	q := "select income_categories.name as name, sum(" + IncomeValueIn(4) + ") as value from incomes inner join income_categories on incomes.category_id = income_categories.id where user_id = $1 and date between $2 and $3 group by income_categories.name"
+	if err := s.db.Select(&results, q, userId, startAt, endAt, currencyCode); err != nil {
+		ext.Error.Set(span, true)
+		return nil, err
+	}
+
+	r := make(map[string]decimal.Decimal)
+	for i := 0; i < len(results); i++ {
+		r[results[i].Name] = results[i].Value
+	}
+
+	return r, nil
+}
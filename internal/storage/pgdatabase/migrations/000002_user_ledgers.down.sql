@@ -0,0 +1,7 @@
+delete from spendings where user_id <> coalesce(nullif(current_setting('telegram_bot.owner_id', true), '')::bigint, 0);
+drop index idx_spendings_user_id_date;
+alter table spendings drop column user_id;
+create index idx_spendings_date on spendings(date);
+
+delete from state where user_id <> coalesce(nullif(current_setting('telegram_bot.owner_id', true), '')::bigint, 0);
+alter table state drop column user_id;
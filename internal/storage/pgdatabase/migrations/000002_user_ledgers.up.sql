@@ -0,0 +1,13 @@
+-- владелец существующих записей задается параметром подключения telegram_bot.owner_id
+alter table spendings add column user_id bigint;
+update spendings set user_id = coalesce(nullif(current_setting('telegram_bot.owner_id', true), '')::bigint, 0);
+alter table spendings alter column user_id set not null;
+
+-- отчеты всегда строятся по одному пользователю за период
+drop index idx_spendings_date;
+create index idx_spendings_user_id_date on spendings(user_id, date);
+
+alter table state add column user_id bigint;
+update state set user_id = coalesce(nullif(current_setting('telegram_bot.owner_id', true), '')::bigint, 0);
+alter table state alter column user_id set not null;
+alter table state add primary key (user_id);
@@ -0,0 +1,3 @@
+alter table categories alter column id drop default;
+drop sequence categories_id_seq;
+alter table categories drop column archived;
@@ -0,0 +1,6 @@
+-- категории не удаляются физически, т.к. на них ссылаются траты
+alter table categories add column archived boolean not null default false;
+
+create sequence categories_id_seq owned by categories.id;
+select setval('categories_id_seq', (select coalesce(max(id), 0) + 1 from categories), false);
+alter table categories alter column id set default nextval('categories_id_seq');
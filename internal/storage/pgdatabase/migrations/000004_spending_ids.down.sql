@@ -0,0 +1 @@
+alter table spendings drop column id;
@@ -0,0 +1 @@
+alter table spendings add column id bigserial primary key;
@@ -0,0 +1,2 @@
+drop table incomes;
+drop table income_categories;
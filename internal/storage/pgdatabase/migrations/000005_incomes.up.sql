@@ -0,0 +1,18 @@
+create table income_categories(
+    id serial PRIMARY KEY,
+    name varchar(100) unique,
+    archived boolean not null default false
+);
+
+create table incomes(
+    id bigserial PRIMARY KEY,
+    user_id bigint not null,
+    value decimal(100, 2) not null,
+    category_id INTEGER REFERENCES income_categories (id),
+    date date not null
+);
+
+-- отчеты всегда строятся по одному пользователю за период
+CREATE INDEX idx_incomes_user_id_date ON incomes(user_id, date);
+
+insert into income_categories(name) values('salary'),('other');
@@ -0,0 +1,2 @@
+drop table category_limits;
+alter table state drop column budget_started_in;
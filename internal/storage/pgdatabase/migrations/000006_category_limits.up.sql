@@ -0,0 +1,11 @@
+-- начало текущего периода бюджета, с него считаются траты по лимитам категорий
+alter table state add column budget_started_in date;
+update state set budget_started_in = budget_expires_in - interval '1 month';
+alter table state alter column budget_started_in set not null;
+
+create table category_limits(
+    user_id bigint not null,
+    category_id INTEGER REFERENCES categories (id),
+    limit_value decimal(100, 2) not null,
+    PRIMARY KEY (user_id, category_id)
+);
@@ -0,0 +1,4 @@
+drop table budget_periods;
+alter table state drop column budget_rollover;
+alter table state drop column budget_period_day;
+alter table state drop column budget_period;
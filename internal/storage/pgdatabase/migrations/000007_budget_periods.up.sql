@@ -0,0 +1,15 @@
+alter table state add column budget_period varchar(10) not null default 'month';
+-- для месячного периода - день месяца, в который начинается новый период
+alter table state add column budget_period_day integer not null default 1;
+alter table state add column budget_rollover boolean not null default false;
+update state set budget_period_day = extract(day from budget_expires_in);
+
+create table budget_periods(
+    user_id bigint not null,
+    started_in date not null,
+    expires_in date not null,
+    budget_value decimal(10, 2) not null,
+    budget_balance decimal(10, 2) not null
+);
+
+CREATE INDEX idx_budget_periods_user_id_expires_in ON budget_periods(user_id, expires_in);
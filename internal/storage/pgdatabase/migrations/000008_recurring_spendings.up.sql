@@ -0,0 +1,12 @@
+create table recurring_spendings(
+    id bigserial PRIMARY KEY,
+    user_id bigint not null,
+    value decimal(100, 2) not null,
+    currency_code varchar(10) REFERENCES currencies (code),
+    category_id INTEGER REFERENCES categories (id),
+    schedule varchar(100) not null,
+    next_run_at timestamp not null
+);
+
+-- планировщик выбирает записи, время запуска которых уже наступило
+CREATE INDEX idx_recurring_spendings_next_run_at ON recurring_spendings(next_run_at);
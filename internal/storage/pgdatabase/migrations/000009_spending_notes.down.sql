@@ -0,0 +1,4 @@
+drop index idx_spendings_tags;
+drop index idx_spendings_note_fts;
+alter table spendings drop column tags;
+alter table spendings drop column note;
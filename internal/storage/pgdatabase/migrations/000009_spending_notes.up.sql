@@ -0,0 +1,6 @@
+alter table spendings add column note text not null default '';
+-- теги хранятся без '#' в нижнем регистре
+alter table spendings add column tags text[] not null default '{}';
+
+CREATE INDEX idx_spendings_note_fts ON spendings USING gin(to_tsvector('russian', note));
+CREATE INDEX idx_spendings_tags ON spendings USING gin(tags);
@@ -0,0 +1 @@
+drop table merchant_rules;
@@ -0,0 +1,9 @@
+-- правила, по которым продавцам из банковских выписок назначается категория
+create table merchant_rules(
+    id bigserial PRIMARY KEY,
+    user_id bigint not null,
+    merchant varchar(200) not null,
+    category_id INTEGER not null REFERENCES categories (id)
+);
+
+CREATE INDEX idx_merchant_rules_user_id ON merchant_rules(user_id);
@@ -0,0 +1,7 @@
+drop table category_notes;
+drop table category_words;
+
+alter index idx_category_rules_user_id rename to idx_merchant_rules_user_id;
+alter table category_rules drop column kind;
+alter table category_rules rename column pattern to merchant;
+alter table category_rules rename to merchant_rules;
@@ -0,0 +1,40 @@
+-- правила продавцов становятся общими правилами категорий: точное совпадение, подстрока или регулярное выражение
+alter table merchant_rules rename to category_rules;
+alter table category_rules rename column merchant to pattern;
+alter table category_rules add column kind varchar(10) not null default 'substring';
+alter index idx_merchant_rules_user_id rename to idx_category_rules_user_id;
+
+-- наивный байесовский классификатор: сколько раз слово встречалось в заметках трат категории
+create table category_words(
+    user_id bigint not null,
+    category_id INTEGER not null REFERENCES categories (id),
+    word varchar(100) not null,
+    count integer not null,
+    PRIMARY KEY (user_id, word, category_id)
+);
+
+-- сколько у категории заметок и слов в них
+create table category_notes(
+    user_id bigint not null,
+    category_id INTEGER not null REFERENCES categories (id),
+    notes integer not null,
+    words integer not null,
+    PRIMARY KEY (user_id, category_id)
+);
+
+-- обучаем на уже внесённых заметках, слова делятся так же, как в model.NoteWords
+insert into category_words(user_id, category_id, word, count)
+select user_id, category_id, word, count(*) from (
+    select user_id, category_id, regexp_split_to_table(replace(lower(note), 'ё', 'е'), '[^[:alnum:]]+') as word
+    from spendings where note <> '' and category_id is not null
+) w
+where length(word) between 2 and 100 and word ~ '[[:alpha:]]'
+group by user_id, category_id, word;
+
+insert into category_notes(user_id, category_id, notes, words)
+select n.user_id, n.category_id, n.notes, coalesce(w.words, 0) from (
+    select user_id, category_id, count(*) as notes from spendings
+    where note <> '' and category_id is not null group by user_id, category_id
+) n left join (
+    select user_id, category_id, sum(count) as words from category_words group by user_id, category_id
+) w using (user_id, category_id);
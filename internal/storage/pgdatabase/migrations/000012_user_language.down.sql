@@ -0,0 +1,2 @@
+alter table state drop column language_fixed;
+alter table state drop column language;
@@ -0,0 +1,3 @@
+-- язык ответов: пустой - ещё не определён, fixed - выбран командой /lang и не следует за языком telegram
+alter table state add column language varchar(5) not null default '';
+alter table state add column language_fixed boolean not null default false;
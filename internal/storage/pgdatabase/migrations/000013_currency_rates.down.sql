@@ -0,0 +1,11 @@
+alter table spendings drop column rate;
+alter table spendings drop column currency_code;
+alter table spendings drop column original_value;
+
+alter table currencies add column ratio decimal(10, 6);
+-- раньше курс был у каждой валюты, валютам без курсов достаётся 1
+update currencies set ratio = coalesce(currency_rate(code, current_date), 1);
+alter table currencies alter column ratio set not null;
+
+drop function currency_rate;
+drop table currency_rates;
@@ -0,0 +1,26 @@
+-- курсы по датам вместо одного текущего: траты прошлых периодов пересчитываются по курсу своего дня
+create table currency_rates(
+    code varchar(10) not null REFERENCES currencies (code),
+    date date not null,
+    ratio decimal(10, 6) not null,
+    PRIMARY KEY (code, date)
+);
+
+-- истории раньше не было, известен только текущий курс
+insert into currency_rates(code, date, ratio) select code, current_date, ratio from currencies;
+alter table currencies drop column ratio;
+
+-- курс на дату: последний известный на эту дату, до начала истории - самый ранний,
+-- без курсов - null, пересчитывать не по чему
+create function currency_rate(currency varchar, on_date date) returns decimal as $$
+    select coalesce(
+        (select ratio from currency_rates where code = currency and date <= on_date order by date desc limit 1),
+        (select ratio from currency_rates where code = currency order by date limit 1))
+$$ language sql stable;
+
+-- сумма в валюте траты и курс на её дату, value остаётся в рублях для баланса и отчётов
+alter table spendings add column original_value decimal(100, 2);
+alter table spendings add column currency_code varchar(10) not null default 'rub' REFERENCES currencies (code);
+alter table spendings add column rate decimal(10, 6) not null default 1;
+update spendings set original_value = value;
+alter table spendings alter column original_value set not null;
@@ -0,0 +1 @@
+alter table currency_rates drop column updated_at;
@@ -0,0 +1,6 @@
+-- когда курс был загружен, чтобы пользователь видел, насколько он устарел
+alter table currency_rates add column updated_at timestamptz;
+-- старые курсы загружены не позже своей даты, now() выдал бы их за свежие
+update currency_rates set updated_at = date;
+alter table currency_rates alter column updated_at set not null;
+alter table currency_rates alter column updated_at set default now();
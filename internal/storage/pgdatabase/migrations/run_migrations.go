@@ -10,6 +10,9 @@ import (
 	"go.uber.org/zap"
 )
 
+// UserLedgers is the version of the migration which gives existing records to the owner of the bot.
+const UserLedgers uint = 2
+
 func run(url, path string, f func(m *migrate.Migrate) error) {
 	m, err := migrate.New(fmt.Sprintf("file://%v", path), url)
 	if err != nil {
@@ -24,6 +27,25 @@ func Up(url, path string) {
 	run(url, path, func(m *migrate.Migrate) error { return m.Up() })
 }
 
+// Version returns the version of the applied migrations, 0 if none were applied.
+func Version(url, path string) uint {
+	var version uint
+	run(url, path, func(m *migrate.Migrate) error {
+		var err error
+		version, _, err = m.Version()
+		if err == migrate.ErrNilVersion {
+			return nil
+		}
+		return err
+	})
+	return version
+}
+
 func Down(url, path string) {
 	run(url, path, func(m *migrate.Migrate) error { return m.Down() })
 }
+
+// Migrate moves the schema up or down to the version.
+func Migrate(url, path string, version uint) {
+	run(url, path, func(m *migrate.Migrate) error { return m.Migrate(version) })
+}
@@ -0,0 +1,74 @@
+package pgdatabase
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
+)
+
+var recurringSelectQ = `select recurring_spendings.id, user_id, value, currency_code, category_id, categories.name as category_name, schedule, next_run_at
+from recurring_spendings inner join categories on recurring_spendings.category_id = categories.id `
+
+type dbRecurringStorage struct {
+	ctx context.Context
+	db  *sqlx.DB
+}
+
+func NewRecurringStorage(ctx context.Context, db *sqlx.DB) *dbRecurringStorage {
+	return &dbRecurringStorage{ctx: ctx, db: db}
+}
+
+func (s *dbRecurringStorage) Add(r model.RecurringSpending) (int64, error) {
+	var id int64
+	q := "insert into recurring_spendings(user_id, value, currency_code, category_id, schedule, next_run_at) values($1,$2,$3,$4,$5,$6) returning id"
+	if err := s.db.GetContext(s.ctx, &id, q, r.UserId, r.Value, r.CurrencyCode, r.CategoryId, r.Schedule, r.NextRunAt); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (s *dbRecurringStorage) GetAll(userId int64) ([]model.RecurringSpending, error) {
+	r := []model.RecurringSpending{}
+	if err := s.db.SelectContext(s.ctx, &r, recurringSelectQ+"where user_id = $1 order by recurring_spendings.id", userId); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (s *dbRecurringStorage) Delete(userId int64, id int64) error {
+	res, err := s.db.ExecContext(s.ctx, "delete from recurring_spendings where user_id = $1 and id = $2", userId, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return model.ErrRecurringSpendingNotFound
+	}
+	return nil
+}
+
+func (s *dbRecurringStorage) GetDue(now time.Time) ([]model.RecurringSpending, error) {
+	r := []model.RecurringSpending{}
+	if err := s.db.SelectContext(s.ctx, &r, recurringSelectQ+"where next_run_at <= $1 order by next_run_at", now); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// UpdateNextRunTx moves the next run of the entry only if it is still at prev,
+// so the same run can't be posted twice.
+func (s *dbRecurringStorage) UpdateNextRunTx(tx *sqlx.Tx, id int64, prev time.Time, next time.Time) error {
+	res, err := tx.ExecContext(s.ctx, "update recurring_spendings set next_run_at = $3 where id = $1 and next_run_at = $2", id, prev, next)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return model.ErrRecurringSpendingNotFound
+	}
+	return nil
+}
@@ -2,9 +2,13 @@ package pgdatabase
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
 	"github.com/shopspring/decimal"
@@ -20,28 +24,149 @@ func NewSpendingStorage(ctx context.Context, db *sqlx.DB) *dbSpendingStorage {
 	return &dbSpendingStorage{ctx: ctx, db: db}
 }
 
+var insertSpendingQ = `insert into spendings(user_id, value, category_id, date, note, tags, original_value, currency_code, rate)
+values($1,$2,$3,$4,$5,$6,$7,$8,$9)`
+
 func (s *dbSpendingStorage) Save(spending model.Spending) error {
-	_, err := s.db.ExecContext(s.ctx, "insert into spendings(value, category_id, date) values($1,$2,$3)", spending.Value, spending.CategoryId, spending.Date)
+	_, err := s.db.ExecContext(s.ctx, insertSpendingQ, spendingArgs(spending)...)
 	return err
 }
 func (s *dbSpendingStorage) SaveTx(tx *sqlx.Tx, spending model.Spending) error {
-	if _, err := tx.ExecContext(s.ctx, "insert into spendings(value, category_id, date) values($1,$2,$3)", spending.Value, spending.CategoryId, spending.Date); err != nil {
+	if _, err := tx.ExecContext(s.ctx, insertSpendingQ, spendingArgs(spending)...); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (s *dbSpendingStorage) GetStatsBy(ctx context.Context, startAt, endAt time.Time) (map[string]decimal.Decimal, error) {
+func spendingArgs(spending model.Spending) []interface{} {
+	return []interface{}{spending.UserId, spending.Value, spending.CategoryId, spending.Date, spending.Note, spendingTags(spending),
+		spending.OriginalValue, spending.CurrencyCode, spending.Rate}
+}
+
+// spendingTags keeps the not null constraint of tags for spendings without tags.
+func spendingTags(spending model.Spending) pq.StringArray {
+	if spending.Tags == nil {
+		return pq.StringArray{}
+	}
+	return spending.Tags
+}
+
+var spendingColumns = "id, user_id, value, category_id, date, note, tags, original_value, currency_code, rate"
+
+// ValueIn converts the value to the currency from the query arg n at the rate of the date of the spending,
+// spendings made in this currency keep their original value.
+// The report service converts its sums with it too, so that both ways of building a report agree.
+func ValueIn(n int) string {
+	return fmt.Sprintf("case when spendings.currency_code = $%[1]d then spendings.original_value else spendings.value * currency_rate($%[1]d, spendings.date) end", n)
+}
+
+// IncomeValueIn converts the income to the currency from the query arg n at the rate of its date, incomes are kept in the base currency.
+func IncomeValueIn(n int) string {
+	return fmt.Sprintf("incomes.value * currency_rate($%d, incomes.date)", n)
+}
+
+// spendingColumnsIn selects the spending with the value in the currency from the query arg n.
+func spendingColumnsIn(n int) string {
+	return "id, user_id, " + ValueIn(n) + " as value, category_id, date, note, tags, original_value, currency_code, rate"
+}
+
+func (s *dbSpendingStorage) GetLast(ctx context.Context, userId int64, count int, currencyCode string) ([]model.Spending, error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "storage: getting last spendings")
+	defer span.Finish()
+
+	if err := CheckRate(s.ctx, s.db, currencyCode); err != nil {
+		ext.Error.Set(span, true)
+		return nil, err
+	}
+
+	r := []model.Spending{}
+	q := "select " + spendingColumnsIn(3) + " from spendings where user_id = $1 order by id desc limit $2"
+	if err := s.db.SelectContext(s.ctx, &r, q, userId, count, currencyCode); err != nil {
+		ext.Error.Set(span, true)
+		return nil, err
+	}
+	return r, nil
+}
+
+// FindByText returns spendings of the period whose note matches the text, newest first.
+func (s *dbSpendingStorage) FindByText(ctx context.Context, userId int64, text string, startAt, endAt time.Time, currencyCode string) ([]model.Spending, error) {
+	return s.find(ctx, "to_tsvector('russian', note) @@ plainto_tsquery('russian', $4)", userId, text, startAt, endAt, currencyCode)
+}
+
+// FindByTag returns spendings of the period marked with the tag, newest first.
+func (s *dbSpendingStorage) FindByTag(ctx context.Context, userId int64, tag string, startAt, endAt time.Time, currencyCode string) ([]model.Spending, error) {
+	return s.find(ctx, "tags @> array[$4::text]", userId, tag, startAt, endAt, currencyCode)
+}
+
+func (s *dbSpendingStorage) find(ctx context.Context, cond string, userId int64, arg string, startAt, endAt time.Time, currencyCode string) ([]model.Spending, error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "storage: finding spendings")
+	defer span.Finish()
+
+	if err := CheckRate(s.ctx, s.db, currencyCode); err != nil {
+		ext.Error.Set(span, true)
+		return nil, err
+	}
+
+	r := []model.Spending{}
+	q := "select " + spendingColumnsIn(5) + " from spendings where user_id = $1 and date between $2 and $3 and " + cond + " order by date desc, id desc"
+	if err := s.db.SelectContext(s.ctx, &r, q, userId, startAt, endAt, arg, currencyCode); err != nil {
+		ext.Error.Set(span, true)
+		return nil, err
+	}
+	return r, nil
+}
+
+func (s *dbSpendingStorage) GetTx(tx *sqlx.Tx, userId int64, id int64) (model.Spending, error) {
+	q := "select " + spendingColumns + " from spendings where user_id = $1 and id = $2 for update"
+	return getSpendingTx(s.ctx, tx, q, userId, id)
+}
+
+func (s *dbSpendingStorage) GetLastTx(tx *sqlx.Tx, userId int64) (model.Spending, error) {
+	q := "select " + spendingColumns + " from spendings where user_id = $1 order by id desc limit 1 for update"
+	return getSpendingTx(s.ctx, tx, q, userId)
+}
+
+func getSpendingTx(ctx context.Context, tx *sqlx.Tx, q string, args ...interface{}) (model.Spending, error) {
+	var r model.Spending
+	if err := tx.GetContext(ctx, &r, q, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Spending{}, model.ErrSpendingNotFound
+		}
+		return model.Spending{}, err
+	}
+	return r, nil
+}
+
+func (s *dbSpendingStorage) UpdateTx(tx *sqlx.Tx, spending model.Spending) error {
+	q := `update spendings set value = $3, category_id = $4, date = $5, original_value = $6, currency_code = $7, rate = $8
+where user_id = $1 and id = $2`
+	_, err := tx.ExecContext(s.ctx, q, spending.UserId, spending.Id, spending.Value, spending.CategoryId, spending.Date,
+		spending.OriginalValue, spending.CurrencyCode, spending.Rate)
+	return err
+}
+
+func (s *dbSpendingStorage) DeleteTx(tx *sqlx.Tx, userId int64, id int64) error {
+	_, err := tx.ExecContext(s.ctx, "delete from spendings where user_id = $1 and id = $2", userId, id)
+	return err
+}
+
+// GetStatsBy returns sums per category in the currency, every spending is converted at the rate of its date.
+func (s *dbSpendingStorage) GetStatsBy(ctx context.Context, userId int64, startAt, endAt time.Time, currencyCode string) (map[string]decimal.Decimal, error) {
 	span, _ := opentracing.StartSpanFromContext(ctx, "storage: getting report")
 	defer span.Finish()
 
+	if err := CheckRate(s.ctx, s.db, currencyCode); err != nil {
+		ext.Error.Set(span, true)
+		return nil, err
+	}
+
 	results := []struct {
 		Name  string          `db:"name"`
 		Value decimal.Decimal `db:"value"`
 	}{}
 
-	q := "select categories.name as name, sum(spendings.value) as value from spendings inner join categories on spendings.category_id = categories.id where date between $1 and $2 group by categories.name"
-	if err := s.db.Select(&results, q, startAt, endAt); err != nil {
+	q := "select categories.name as name, sum(" + ValueIn(4) + ") as value from spendings inner join categories on spendings.category_id = categories.id where user_id = $1 and date between $2 and $3 group by categories.name"
+	if err := s.db.Select(&results, q, userId, startAt, endAt, currencyCode); err != nil {
 		ext.Error.Set(span, true)
 		return nil, err
 	}
@@ -53,3 +178,76 @@ func (s *dbSpendingStorage) GetStatsBy(ctx context.Context, startAt, endAt time.
 
 	return r, nil
 }
+
+// GetBreakdown returns sums per category grouped by unit: day, week or month.
+func (s *dbSpendingStorage) GetBreakdown(ctx context.Context, userId int64, startAt, endAt time.Time, unit string, currencyCode string) ([]model.ReportBucket, error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "storage: getting report breakdown")
+	defer span.Finish()
+
+	if err := CheckRate(s.ctx, s.db, currencyCode); err != nil {
+		ext.Error.Set(span, true)
+		return nil, err
+	}
+
+	results := []struct {
+		Start time.Time       `db:"start"`
+		Name  string          `db:"name"`
+		Value decimal.Decimal `db:"value"`
+	}{}
+
+	// неделя или месяц, с которых начинается период, считаются с его начала, а не с понедельника или первого числа
+	q := "select greatest(date_trunc($4, spendings.date)::date, $2::date) as start, categories.name as name, sum(" + ValueIn(5) + ") as value from spendings inner join categories on spendings.category_id = categories.id where user_id = $1 and date between $2 and $3 group by 1, 2 order by 1"
+	if err := s.db.SelectContext(s.ctx, &results, q, userId, startAt, endAt, unit, currencyCode); err != nil {
+		ext.Error.Set(span, true)
+		return nil, err
+	}
+
+	r := make([]model.ReportBucket, 0)
+	for i := 0; i < len(results); i++ {
+		if len(r) == 0 || !r[len(r)-1].Start.Equal(results[i].Start) {
+			r = append(r, model.ReportBucket{Start: results[i].Start, Data: make(map[string]decimal.Decimal)})
+		}
+		r[len(r)-1].Data[results[i].Name] = results[i].Value
+	}
+	return r, nil
+}
+
+// GetEntries returns up to limit spendings of the period in the order they were made with the rate of the currency on their dates.
+func (s *dbSpendingStorage) GetEntries(ctx context.Context, userId int64, startAt, endAt time.Time, limit int, currencyCode string) ([]model.ReportEntry, error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "storage: getting spendings for export")
+	defer span.Finish()
+
+	if err := CheckRate(s.ctx, s.db, currencyCode); err != nil {
+		ext.Error.Set(span, true)
+		return nil, err
+	}
+
+	r := []model.ReportEntry{}
+	q := `select spendings.date as date, categories.name as category, spendings.value as value, spendings.note as note,
+spendings.original_value as original_value, spendings.currency_code as currency_code, currency_rate($5, spendings.date) as rate
+from spendings inner join categories on spendings.category_id = categories.id where user_id = $1 and date between $2 and $3 order by spendings.date, spendings.id limit $4`
+	if err := s.db.SelectContext(s.ctx, &r, q, userId, startAt, endAt, limit, currencyCode); err != nil {
+		ext.Error.Set(span, true)
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetTop returns count largest spendings of the period.
+func (s *dbSpendingStorage) GetTop(ctx context.Context, userId int64, startAt, endAt time.Time, count int, currencyCode string) ([]model.ReportEntry, error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "storage: getting top spendings")
+	defer span.Finish()
+
+	if err := CheckRate(s.ctx, s.db, currencyCode); err != nil {
+		ext.Error.Set(span, true)
+		return nil, err
+	}
+
+	r := []model.ReportEntry{}
+	q := "select spendings.date as date, categories.name as category, " + ValueIn(5) + " as value, spendings.note as note from spendings inner join categories on spendings.category_id = categories.id where user_id = $1 and date between $2 and $3 order by spendings.value desc, spendings.date desc limit $4"
+	if err := s.db.SelectContext(s.ctx, &r, q, userId, startAt, endAt, count, currencyCode); err != nil {
+		ext.Error.Set(span, true)
+		return nil, err
+	}
+	return r, nil
+}
@@ -23,8 +23,9 @@ func Test_Save(t *testing.T) {
 			name: "save is ok",
 			prepareF: func() {
 			},
-			err:  nil,
-			data: model.Spending{Value: decimal.NewFromInt(1), CategoryId: 1, Date: time.Now()},
+			err: nil,
+			data: model.Spending{UserId: 1, Value: decimal.NewFromInt(1), CategoryId: 1, Date: time.Now(),
+				OriginalValue: decimal.NewFromInt(1), CurrencyCode: "rub", Rate: decimal.NewFromInt(1)},
 		},
 	}
 	for _, tt := range tests {
@@ -54,9 +55,10 @@ func Test_GetStatsBy(t *testing.T) {
 			endAt:   time.Now(),
 			startAt: time.Now().AddDate(0, 0, -7),
 			prepareF: func(start time.Time, end time.Time) {
-				DB.MustExec("insert into spendings(value, category_id, date) values(1, 1, $1)", start)
-				DB.MustExec("insert into spendings(value, category_id, date) values(1, 1, $1)", start.AddDate(0, 0, 1))
-				DB.MustExec("insert into spendings(value, category_id, date) values(1, 0, $1)", end)
+				DB.MustExec("insert into spendings(user_id, value, original_value, category_id, date) values(1, 1, 1, 1, $1)", start)
+				DB.MustExec("insert into spendings(user_id, value, original_value, category_id, date) values(1, 1, 1, 1, $1)", start.AddDate(0, 0, 1))
+				DB.MustExec("insert into spendings(user_id, value, original_value, category_id, date) values(1, 1, 1, 0, $1)", end)
+				DB.MustExec("insert into spendings(user_id, value, original_value, category_id, date) values(2, 5, 5, 0, $1)", end)
 			},
 			data: model.Week,
 			checkF: func(report map[string]decimal.Decimal, err error) {
@@ -72,7 +74,126 @@ func Test_GetStatsBy(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			BeforeTest()
 			tt.prepareF(tt.startAt, tt.endAt)
-			tt.checkF(storage.GetStatsBy(context.TODO(), tt.startAt, tt.endAt))
+			tt.checkF(storage.GetStatsBy(context.TODO(), 1, tt.startAt, tt.endAt, "rub"))
 		})
 	}
 }
+
+func Test_GetStatsBy_shouldConvertAtRatesOfSpendingDates(t *testing.T) {
+	BeforeTest()
+	storage := NewSpendingStorage(context.Background(), DB)
+	day := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	DB.MustExec("insert into currencies(code) values('usd')")
+	DB.MustExec("insert into currency_rates(code, date, ratio) values('usd', $1, 0.01)", day)
+	DB.MustExec("insert into currency_rates(code, date, ratio) values('usd', $1, 0.02)", day.AddDate(0, 0, 1))
+	// 100 рублей по 0.01 и 100 рублей по 0.02
+	DB.MustExec("insert into spendings(user_id, value, original_value, category_id, date) values(1, 100, 100, 1, $1)", day)
+	DB.MustExec("insert into spendings(user_id, value, original_value, category_id, date) values(1, 100, 100, 1, $1)", day.AddDate(0, 0, 1))
+	// трата в долларах сохраняет исходную сумму
+	DB.MustExec("insert into spendings(user_id, value, original_value, currency_code, rate, category_id, date) values(1, 333.33, 7, 'usd', 0.021, 0, $1)", day.AddDate(0, 0, 1))
+
+	report, err := storage.GetStatsBy(context.TODO(), 1, day, day.AddDate(0, 0, 1), "usd")
+	assert.NoError(t, err)
+	assert.True(t, report["other"].Equal(decimal.NewFromInt(3)), "got %v", report["other"])
+	assert.True(t, report["food"].Equal(decimal.NewFromInt(7)), "got %v", report["food"])
+
+	entries, err := storage.GetEntries(context.TODO(), 1, day, day.AddDate(0, 0, 1), 10, "usd")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 3)
+	assert.True(t, entries[0].Rate.Equal(decimal.RequireFromString("0.01")))
+	assert.Equal(t, "usd", entries[2].CurrencyCode)
+	assert.True(t, entries[2].OriginalValue.Equal(decimal.NewFromInt(7)))
+}
+
+func Test_GetBreakdown_shouldStartFirstWeekAtRangeStart(t *testing.T) {
+	BeforeTest()
+	storage := NewSpendingStorage(context.Background(), DB)
+	// период начинается в среду, неделя с траты 10-го - с понедельника 9-го
+	start, end := time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	DB.MustExec("insert into spendings(user_id, value, original_value, category_id, date) values(1, 100, 100, 1, $1)", start)
+	DB.MustExec("insert into spendings(user_id, value, original_value, category_id, date) values(1, 50, 50, 1, $1)", end)
+
+	buckets, err := storage.GetBreakdown(context.TODO(), 1, start, end, "week", "rub")
+	assert.NoError(t, err)
+	assert.Len(t, buckets, 2)
+	assert.Equal(t, "04-03-2026", buckets[0].Start.Format("02-01-2006"))
+	assert.Equal(t, "09-03-2026", buckets[1].Start.Format("02-01-2006"))
+	assert.True(t, buckets[0].Data["other"].Equal(decimal.NewFromInt(100)))
+}
+
+func Test_GetStatsBy_shouldFailWithoutRates(t *testing.T) {
+	BeforeTest()
+	storage := NewSpendingStorage(context.Background(), DB)
+	day := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	DB.MustExec("insert into currencies(code) values('usd')")
+	DB.MustExec("insert into spendings(user_id, value, original_value, category_id, date) values(1, 100, 100, 1, $1)", day)
+
+	// курсов доллара нет, а не 1 к рублю
+	_, err := storage.GetStatsBy(context.TODO(), 1, day, day, "usd")
+	assert.ErrorIs(t, err, model.ErrNoRate)
+	_, err = storage.GetEntries(context.TODO(), 1, day, day, 10, "usd")
+	assert.ErrorIs(t, err, model.ErrNoRate)
+
+	currencies := NewCurrencyStorage(context.Background(), DB)
+	_, err = currencies.GetRate(context.TODO(), "usd", day)
+	assert.ErrorIs(t, err, model.ErrNoRate)
+	cs, err := currencies.GetCurrencies()
+	assert.NoError(t, err)
+	for i := range cs {
+		assert.NotEqual(t, "usd", cs[i].Code)
+	}
+}
+
+func Test_DeleteTx(t *testing.T) {
+	BeforeTest()
+	storage := NewSpendingStorage(context.Background(), DB)
+	DB.MustExec("insert into spendings(user_id, value, original_value, category_id, date) values(1, 1, 1, 1, now())")
+	DB.MustExec("insert into spendings(user_id, value, original_value, category_id, date) values(2, 1, 1, 1, now())")
+
+	tx := DB.MustBegin()
+	last, err := storage.GetLastTx(tx, 1)
+	assert.NoError(t, err)
+	assert.NoError(t, storage.DeleteTx(tx, 1, last.Id))
+	_, err = storage.GetLastTx(tx, 1)
+	assert.ErrorIs(t, err, model.ErrSpendingNotFound)
+	assert.NoError(t, tx.Commit())
+
+	checkIsExist(t, "select count(1) from spendings where user_id = 2", 1)
+}
+
+func Test_GetEntries(t *testing.T) {
+	BeforeTest()
+	storage := NewSpendingStorage(context.Background(), DB)
+	start, end := time.Now().AddDate(0, 0, -7), time.Now()
+	DB.MustExec("insert into spendings(user_id, value, original_value, category_id, date, note, tags) values(1, 1, 1, 1, $1, 'кофе', '{}')", end)
+	DB.MustExec("insert into spendings(user_id, value, original_value, category_id, date, note, tags) values(1, 2, 2, 1, $1, '', '{}')", end.AddDate(0, 0, -1))
+	DB.MustExec("insert into spendings(user_id, value, original_value, category_id, date, note, tags) values(1, 3, 3, 1, $1, '', '{}')", end.AddDate(0, 0, -30))
+
+	entries, err := storage.GetEntries(context.TODO(), 1, start, end, 10, "rub")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.True(t, entries[0].Value.Equal(decimal.NewFromInt(2)))
+	assert.Equal(t, "кофе", entries[1].Note)
+
+	limited, err := storage.GetEntries(context.TODO(), 1, start, end, 1, "rub")
+	assert.NoError(t, err)
+	assert.Len(t, limited, 1)
+}
+
+func Test_Find(t *testing.T) {
+	BeforeTest()
+	storage := NewSpendingStorage(context.Background(), DB)
+	start, end := time.Now().AddDate(0, 0, -7), time.Now()
+	DB.MustExec("insert into spendings(user_id, value, original_value, category_id, date, note, tags) values(1, 1, 1, 1, $1, 'кофе с собой', '{work}')", end)
+	DB.MustExec("insert into spendings(user_id, value, original_value, category_id, date, note, tags) values(1, 2, 2, 1, $1, 'такси', '{trip,work}')", end)
+	DB.MustExec("insert into spendings(user_id, value, original_value, category_id, date, note, tags) values(2, 3, 3, 1, $1, 'кофе', '{work}')", end)
+
+	byText, err := storage.FindByText(context.TODO(), 1, "кофе", start, end, "rub")
+	assert.NoError(t, err)
+	assert.Len(t, byText, 1)
+	assert.Equal(t, "кофе с собой", byText[0].Note)
+
+	byTag, err := storage.FindByTag(context.TODO(), 1, "work", start, end, "rub")
+	assert.NoError(t, err)
+	assert.Len(t, byTag, 2)
+}
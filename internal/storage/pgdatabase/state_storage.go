@@ -2,6 +2,7 @@ package pgdatabase
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -9,6 +10,10 @@ import (
 	"gitlab.ozon.dev/alex.bogushev/telegram-bot/internal/model"
 )
 
+// состояние создается при первом обращении пользователя с бюджетом по умолчанию в базовой валюте $2
+var ensureStateQ = `insert into state(user_id, current_currency_code, budget_value, budget_balance, budget_expires_in, budget_started_in, budget_period_day)
+values($1, $2, 1000, 1000, now() + interval '1 month', now(), extract(day from now())) on conflict(user_id) do nothing`
+
 type dbStateStorage struct {
 	ctx context.Context
 	db  *sqlx.DB
@@ -18,35 +23,151 @@ func NewStateStorage(ctx context.Context, db *sqlx.DB) *dbStateStorage {
 	return &dbStateStorage{ctx: ctx, db: db}
 }
 
-func (s *dbStateStorage) GetState() (model.State, error) {
+func (s *dbStateStorage) GetState(userId int64) (model.State, error) {
+	if _, err := s.db.ExecContext(s.ctx, ensureStateQ, userId, model.BaseCurrency); err != nil {
+		return model.State{}, err
+	}
 	var state model.State
-	if err := s.db.GetContext(s.ctx, &state, "select * from state"); err != nil {
+	if err := s.db.GetContext(s.ctx, &state, "select * from state where user_id = $1", userId); err != nil {
 		return model.State{}, err
 	}
 	return state, nil
 }
 
-func (s *dbStateStorage) UpdateBalance(v decimal.Decimal) error {
-	if _, err := s.db.ExecContext(s.ctx, "update state set budget_balance = $1", v); err != nil {
+func (s *dbStateStorage) UpdateBalance(userId int64, v decimal.Decimal) error {
+	if _, err := s.db.ExecContext(s.ctx, "update state set budget_balance = $2 where user_id = $1", userId, v); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (s *dbStateStorage) UpdateBalanceTx(tx *sqlx.Tx, v decimal.Decimal) error {
-	_, err := tx.ExecContext(s.ctx, "update state set budget_balance = $1", v)
+func (s *dbStateStorage) UpdateBalanceTx(tx *sqlx.Tx, userId int64, v decimal.Decimal) error {
+	_, err := tx.ExecContext(s.ctx, "update state set budget_balance = $2 where user_id = $1", userId, v)
 	return err
 }
 
-func (s *dbStateStorage) DecreaseBalanceTx(tx *sqlx.Tx, v decimal.Decimal) (decimal.Decimal, error) {
+func (s *dbStateStorage) DecreaseBalanceTx(tx *sqlx.Tx, userId int64, v decimal.Decimal) (decimal.Decimal, error) {
+	if _, err := tx.ExecContext(s.ctx, ensureStateQ, userId, model.BaseCurrency); err != nil {
+		return decimal.Decimal{}, err
+	}
 	var result decimal.Decimal
-	err := tx.QueryRowContext(s.ctx, "update state set budget_balance = budget_balance - $1 RETURNING budget_balance", v).Scan(&result)
+	err := tx.QueryRowContext(s.ctx, "update state set budget_balance = budget_balance - $2 where user_id = $1 RETURNING budget_balance", userId, v).Scan(&result)
 	return result, err
 }
 
-func (s *dbStateStorage) UpdateBalanceAndExpiresIn(t time.Time) error {
-	if _, err := s.db.ExecContext(s.ctx, "update state set budget_balance = budget_value, budget_expires_in = $2", t); err != nil {
+// GetNearestExpiresIn returns the earliest budget expiration among all users, ok is false if there are no users yet.
+func (s *dbStateStorage) GetNearestExpiresIn() (t time.Time, ok bool, err error) {
+	var r *time.Time
+	if err := s.db.GetContext(s.ctx, &r, "select min(budget_expires_in) from state"); err != nil {
+		return time.Time{}, false, err
+	}
+	if r == nil {
+		return time.Time{}, false, nil
+	}
+	return *r, true, nil
+}
+
+func (s *dbStateStorage) GetExpiredStates(now time.Time) ([]model.State, error) {
+	r := []model.State{}
+	if err := s.db.SelectContext(s.ctx, &r, "select * from state where budget_expires_in <= $1", now); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ClosePeriod saves the finished period of the state to the history and starts the next one with the given balance.
+func (s *dbStateStorage) ClosePeriod(state model.State, expiresIn time.Time, balance decimal.Decimal) error {
+	return RunInTx(
+		func(tx *sqlx.Tx) error {
+			q := "insert into budget_periods(user_id, started_in, expires_in, budget_value, budget_balance) values($1,$2,$3,$4,$5)"
+			_, err := tx.ExecContext(s.ctx, q, state.UserId, state.BudgetStartedIn, state.BudgetExpiresIn, state.BudgetValue, state.BudgetBalance)
+			return err
+		},
+		func(tx *sqlx.Tx) error {
+			// баланс мог измениться после чтения состояния, поэтому переносим его разницу
+			q := `update state set budget_balance = $2 + (budget_balance - $3), budget_started_in = budget_expires_in, budget_expires_in = $4
+where user_id = $1`
+			_, err := tx.ExecContext(s.ctx, q, state.UserId, balance, state.BudgetBalance, expiresIn)
+			return err
+		},
+	)
+}
+
+func (s *dbStateStorage) UpdatePeriod(userId int64, period model.BudgetPeriod, day int, expiresIn time.Time) error {
+	if _, err := s.db.ExecContext(s.ctx, ensureStateQ, userId, model.BaseCurrency); err != nil {
 		return err
 	}
-	return nil
+	q := "update state set budget_period = $2, budget_period_day = $3, budget_expires_in = $4 where user_id = $1"
+	_, err := s.db.ExecContext(s.ctx, q, userId, period, day, expiresIn)
+	return err
+}
+
+func (s *dbStateStorage) UpdateRollover(userId int64, rollover bool) error {
+	if _, err := s.db.ExecContext(s.ctx, ensureStateQ, userId, model.BaseCurrency); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(s.ctx, "update state set budget_rollover = $2 where user_id = $1", userId, rollover)
+	return err
+}
+
+func (s *dbStateStorage) UpdateLanguage(userId int64, lang string, fixed bool) error {
+	if _, err := s.db.ExecContext(s.ctx, ensureStateQ, userId, model.BaseCurrency); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(s.ctx, "update state set language = $2, language_fixed = $3 where user_id = $1", userId, lang, fixed)
+	return err
+}
+
+func (s *dbStateStorage) GetClosedPeriods(userId int64, count int) ([]model.ClosedPeriod, error) {
+	r := []model.ClosedPeriod{}
+	q := "select started_in, expires_in, budget_value, budget_balance from budget_periods where user_id = $1 order by expires_in desc limit $2"
+	if err := s.db.SelectContext(s.ctx, &r, q, userId, count); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// траты по категориям за текущий период бюджета пользователя
+var categoryBudgetsQ = `select category_limits.category_id, categories.name, category_limits.limit_value, coalesce(sum(spendings.value), 0) as spent
+from category_limits
+inner join categories on categories.id = category_limits.category_id
+inner join state on state.user_id = category_limits.user_id
+left join spendings on spendings.user_id = category_limits.user_id and spendings.category_id = category_limits.category_id
+	and spendings.date >= state.budget_started_in and spendings.date < state.budget_expires_in
+where category_limits.user_id = $1 and not categories.archived %v
+group by category_limits.category_id, categories.name, category_limits.limit_value
+order by category_limits.category_id`
+
+func (s *dbStateStorage) SetCategoryLimit(userId int64, categoryId int, v decimal.Decimal) error {
+	if _, err := s.db.ExecContext(s.ctx, ensureStateQ, userId, model.BaseCurrency); err != nil {
+		return err
+	}
+	q := "insert into category_limits(user_id, category_id, limit_value) values($1,$2,$3) on conflict(user_id, category_id) do update set limit_value = $3"
+	_, err := s.db.ExecContext(s.ctx, q, userId, categoryId, v)
+	return err
+}
+
+func (s *dbStateStorage) DeleteCategoryLimit(userId int64, categoryId int) error {
+	_, err := s.db.ExecContext(s.ctx, "delete from category_limits where user_id = $1 and category_id = $2", userId, categoryId)
+	return err
+}
+
+func (s *dbStateStorage) GetCategoryBudgets(userId int64) ([]model.CategoryBudget, error) {
+	r := []model.CategoryBudget{}
+	if err := s.db.SelectContext(s.ctx, &r, fmt.Sprintf(categoryBudgetsQ, ""), userId); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCategoryBudget returns the budget of the category, ok is false if the user didn't set a limit for it.
+func (s *dbStateStorage) GetCategoryBudget(userId int64, categoryId int) (b model.CategoryBudget, ok bool, err error) {
+	r := []model.CategoryBudget{}
+	if err := s.db.SelectContext(s.ctx, &r, fmt.Sprintf(categoryBudgetsQ, "and category_limits.category_id = $2"), userId, categoryId); err != nil {
+		return model.CategoryBudget{}, false, err
+	}
+	if len(r) == 0 {
+		return model.CategoryBudget{}, false, nil
+	}
+	return r[0], true, nil
 }
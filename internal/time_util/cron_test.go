@@ -0,0 +1,42 @@
+package time_util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCron_Next(t *testing.T) {
+	from := time.Date(2026, 3, 10, 12, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		expr string
+		next time.Time
+	}{
+		{name: "every minute", expr: "* * * * *", next: time.Date(2026, 3, 10, 12, 31, 0, 0, time.UTC)},
+		{name: "daily alias", expr: "@daily", next: time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC)},
+		{name: "monthly on the 5th", expr: "0 9 5 * *", next: time.Date(2026, 4, 5, 9, 0, 0, 0, time.UTC)},
+		{name: "later today", expr: "0 18 * * *", next: time.Date(2026, 3, 10, 18, 0, 0, 0, time.UTC)},
+		{name: "weekdays list", expr: "0 9 * * 1,5", next: time.Date(2026, 3, 13, 9, 0, 0, 0, time.UTC)},
+		{name: "sunday as 7", expr: "0 0 * * 7", next: time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{name: "every 15 minutes", expr: "*/15 * * * *", next: time.Date(2026, 3, 10, 12, 45, 0, 0, time.UTC)},
+		{name: "day of month or day of week", expr: "0 0 1 * 3", next: time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC)},
+		{name: "31st skips short months", expr: "0 0 31 * *", next: time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)},
+		{name: "yearly", expr: "0 0 1 1 *", next: time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseCron(tt.expr)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.next, c.Next(from))
+		})
+	}
+}
+
+func TestParseCron_WrongFormat(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "a * * * *"} {
+		_, err := ParseCron(expr)
+		assert.ErrorIs(t, err, ErrWrongCron, expr)
+	}
+}
@@ -0,0 +1,129 @@
+package time_util
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrWrongRange = errors.New("wrong report period")
+
+// ParseRange parses a report period relative to now, both bounds are dates and are included.
+// Supported: w, m, y - rolling week, month and year; today, yesterday; this/last week, month, year;
+// 90d, 2w, 6m, 1y - rolling number of days, weeks, months or years; q1..q4 with an optional year;
+// 2025; 03-2026; 01-03-2026; 01-03-2026 31-03-2026.
+func ParseRange(s string, now time.Time) (time.Time, time.Time, error) {
+	today := now.Truncate(24 * time.Hour)
+	tokens := strings.Fields(strings.ToLower(s))
+
+	switch len(tokens) {
+	case 1:
+		return parseSingleRange(tokens[0], today)
+	case 2:
+		switch tokens[0] {
+		case "this":
+			return parseCalendarRange(tokens[1], today, 0)
+		case "last":
+			return parseCalendarRange(tokens[1], today, -1)
+		}
+		if start, end, err := parseQuarter(tokens[0], tokens[1]); err == nil {
+			return start, end, nil
+		}
+		start, err1 := DateToTime(tokens[0])
+		end, err2 := DateToTime(tokens[1])
+		if err1 != nil || err2 != nil || end.Before(start) {
+			return time.Time{}, time.Time{}, ErrWrongRange
+		}
+		return start, end, nil
+	}
+	return time.Time{}, time.Time{}, ErrWrongRange
+}
+
+func parseSingleRange(s string, today time.Time) (time.Time, time.Time, error) {
+	switch s {
+	// скользящие периоды, которые были у /report изначально
+	case "w":
+		return today.AddDate(0, 0, -7), today, nil
+	case "m":
+		return today.AddDate(0, -1, 0), today, nil
+	case "y":
+		return today.AddDate(-1, 0, 0), today, nil
+	}
+	if start, ok := parseRolling(s, today); ok {
+		return start, today, nil
+	}
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	switch s {
+	case "today":
+		return today, today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), today.AddDate(0, 0, -1), nil
+	}
+	if strings.HasPrefix(s, "q") {
+		return parseQuarter(s, strconv.Itoa(today.Year()))
+	}
+	if dt, err := DateToTime(s); err == nil {
+		return dt, dt, nil
+	}
+	if start, err := time.Parse("01-2006", s); err == nil {
+		return start, start.AddDate(0, 1, -1), nil
+	}
+	if year, err := strconv.Atoi(s); err == nil && len(s) == 4 {
+		start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(1, 0, -1), nil
+	}
+	return time.Time{}, time.Time{}, ErrWrongRange
+}
+
+// parseRolling returns the start of the rolling period like 90d or 6m ending today.
+func parseRolling(s string, today time.Time) (time.Time, bool) {
+	if len(s) < 2 {
+		return time.Time{}, false
+	}
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n <= 0 {
+		return time.Time{}, false
+	}
+	switch s[len(s)-1] {
+	case 'd':
+		return today.AddDate(0, 0, -n), true
+	case 'w':
+		return today.AddDate(0, 0, -7*n), true
+	case 'm':
+		return today.AddDate(0, -n, 0), true
+	case 'y':
+		return today.AddDate(-n, 0, 0), true
+	}
+	return time.Time{}, false
+}
+
+// parseCalendarRange returns the calendar week, month or year shifted by shift from the current one.
+func parseCalendarRange(unit string, today time.Time, shift int) (time.Time, time.Time, error) {
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	switch unit {
+	case "week":
+		// неделя начинается с понедельника
+		start := today.AddDate(0, 0, -((int(today.Weekday())+6)%7)+7*shift)
+		return start, start.AddDate(0, 0, 6), nil
+	case "month":
+		start := time.Date(today.Year(), today.Month()+time.Month(shift), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, -1), nil
+	case "year":
+		start := time.Date(today.Year()+shift, time.January, 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(1, 0, -1), nil
+	}
+	return time.Time{}, time.Time{}, ErrWrongRange
+}
+
+func parseQuarter(q string, yearStr string) (time.Time, time.Time, error) {
+	if len(q) != 2 || q[0] != 'q' || q[1] < '1' || q[1] > '4' {
+		return time.Time{}, time.Time{}, ErrWrongRange
+	}
+	year, err := strconv.Atoi(yearStr)
+	if err != nil || len(yearStr) != 4 {
+		return time.Time{}, time.Time{}, ErrWrongRange
+	}
+	start := time.Date(year, time.Month(int(q[1]-'1')*3+1), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 3, -1), nil
+}
@@ -0,0 +1,56 @@
+package time_util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRange(t *testing.T) {
+	// среда
+	now := time.Date(2026, 3, 18, 15, 0, 0, 0, time.UTC)
+	day := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		period string
+		start  time.Time
+		end    time.Time
+	}{
+		{period: "w", start: day(2026, 3, 11), end: day(2026, 3, 18)},
+		{period: "m", start: day(2026, 2, 18), end: day(2026, 3, 18)},
+		{period: "90d", start: day(2025, 12, 18), end: day(2026, 3, 18)},
+		{period: "2w", start: day(2026, 3, 4), end: day(2026, 3, 18)},
+		{period: "6m", start: day(2025, 9, 18), end: day(2026, 3, 18)},
+		{period: "today", start: day(2026, 3, 18), end: day(2026, 3, 18)},
+		{period: "yesterday", start: day(2026, 3, 17), end: day(2026, 3, 17)},
+		{period: "this week", start: day(2026, 3, 16), end: day(2026, 3, 22)},
+		{period: "last week", start: day(2026, 3, 9), end: day(2026, 3, 15)},
+		{period: "this month", start: day(2026, 3, 1), end: day(2026, 3, 31)},
+		{period: "last month", start: day(2026, 2, 1), end: day(2026, 2, 28)},
+		{period: "last year", start: day(2025, 1, 1), end: day(2025, 12, 31)},
+		{period: "Q1", start: day(2026, 1, 1), end: day(2026, 3, 31)},
+		{period: "q4 2025", start: day(2025, 10, 1), end: day(2025, 12, 31)},
+		{period: "2025", start: day(2025, 1, 1), end: day(2025, 12, 31)},
+		{period: "02-2024", start: day(2024, 2, 1), end: day(2024, 2, 29)},
+		{period: "05-03-2026", start: day(2026, 3, 5), end: day(2026, 3, 5)},
+		{period: "01-03-2026 31-03-2026", start: day(2026, 3, 1), end: day(2026, 3, 31)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.period, func(t *testing.T) {
+			start, end, err := ParseRange(tt.period, now)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.start, start)
+			assert.Equal(t, tt.end, end)
+		})
+	}
+}
+
+func TestParseRange_Wrong(t *testing.T) {
+	now := time.Date(2026, 3, 18, 15, 0, 0, 0, time.UTC)
+	for _, period := range []string{"", "d", "q5", "next month", "31-03-2026 01-03-2026", "01-03-2026 x", "w m y", "0d", "-5d", "10x"} {
+		_, _, err := ParseRange(period, now)
+		assert.ErrorIs(t, err, ErrWrongRange, period)
+	}
+}